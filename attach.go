@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// imageCommand prefixes a turn that attaches an image, as in
+// "/image photo.png What is this?".
+const imageCommand = "/image"
+
+// maxAttachmentSize caps the size of an attached image.
+const maxAttachmentSize = 10 << 20
+
+// defaultImagePrompt is sent when an image is attached without a question.
+const defaultImagePrompt = "Describe this image."
+
+// buildUserMessage turns a line of user input into a chat message, loading
+// the attachment when the line starts with imageCommand. The path may be
+// quoted to allow spaces. The image bytes are sent base64-encoded by the API
+// client.
+func buildUserMessage(input string) (api.Message, error) {
+	msg := api.Message{Role: "user", Content: input}
+	rest, ok := strings.CutPrefix(input, imageCommand)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return msg, nil
+	}
+
+	path, prompt, err := splitAttachmentArgs(strings.TrimSpace(rest))
+	if err != nil {
+		return api.Message{}, err
+	}
+	image, err := loadAttachment(path)
+	if err != nil {
+		return api.Message{}, err
+	}
+	if prompt == "" {
+		prompt = defaultImagePrompt
+	}
+	msg.Content = prompt
+	msg.Images = []api.ImageData{image}
+	return msg, nil
+}
+
+// splitAttachmentArgs splits "path rest of prompt" or "\"a path\" rest".
+func splitAttachmentArgs(s string) (path, prompt string, err error) {
+	if s == "" {
+		return "", "", fmt.Errorf("usage: %s <path> [question]", imageCommand)
+	}
+	if s[0] == '"' {
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quote in image path")
+		}
+		return s[1 : end+1], strings.TrimSpace(s[end+2:]), nil
+	}
+	path, prompt, _ = strings.Cut(s, " ")
+	return path, strings.TrimSpace(prompt), nil
+}
+
+// loadAttachment reads the image at path, refusing files that are missing,
+// larger than maxAttachmentSize or not recognisable as an image.
+func loadAttachment(path string) (api.ImageData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("image not found: %s", path)
+		}
+		return nil, fmt.Errorf("cannot read image %s: %v", path, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxAttachmentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image %s: %v", path, err)
+	}
+	if len(data) > maxAttachmentSize {
+		return nil, fmt.Errorf("image %s is larger than %d MB", path, maxAttachmentSize>>20)
+	}
+	if kind := http.DetectContentType(data); !strings.HasPrefix(kind, "image/") {
+		return nil, fmt.Errorf("%s does not look like an image (detected %s)", path, kind)
+	}
+	return data, nil
+}
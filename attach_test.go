@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePNG writes a 1x1 PNG into dir and returns its path and contents.
+func writePNG(t *testing.T, dir, name string) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, buf.Bytes()
+}
+
+func TestBuildUserMessage(t *testing.T) {
+	dir := t.TempDir()
+	path, data := writePNG(t, dir, "photo.png")
+	spaced, _ := writePNG(t, dir, "my photo.png")
+
+	tests := []struct {
+		input, content string
+		image          bool
+	}{
+		{"hello there", "hello there", false},
+		{"/imagery is a word", "/imagery is a word", false},
+		{"/image " + path + " What is this?", "What is this?", true},
+		{"/image " + path, defaultImagePrompt, true},
+		{`/image "` + spaced + `" and this?`, "and this?", true},
+	}
+	for _, tt := range tests {
+		msg, err := buildUserMessage(tt.input)
+		if err != nil {
+			t.Errorf("buildUserMessage(%q): %v", tt.input, err)
+			continue
+		}
+		if msg.Role != "user" || msg.Content != tt.content {
+			t.Errorf("buildUserMessage(%q) = %s %q, want user %q", tt.input, msg.Role, msg.Content, tt.content)
+		}
+		if got := len(msg.Images) == 1 && bytes.Equal(msg.Images[0], data); got != tt.image {
+			t.Errorf("buildUserMessage(%q) attached the image = %v, want %v", tt.input, got, tt.image)
+		}
+	}
+
+	// The API client sends the attachment base64-encoded.
+	msg, _ := buildUserMessage("/image " + path)
+	encoded, err := json.Marshal(msg)
+	if err != nil || !strings.Contains(string(encoded), base64.StdEncoding.EncodeToString(data)) {
+		t.Errorf("encoded message %s does not carry the base64 image: %v", encoded, err)
+	}
+}
+
+func TestBuildUserMessageErrors(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.txt")
+	os.WriteFile(text, []byte("just some notes"), 0o644)
+	huge := filepath.Join(dir, "huge.png")
+	f, err := os.Create(huge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Truncate(maxAttachmentSize + 1)
+	f.Close()
+
+	tests := []struct {
+		input, want string
+	}{
+		{"/image", "usage: /image <path>"},
+		{"/image " + filepath.Join(dir, "missing.png"), "image not found"},
+		{`/image "` + dir + `/unterminated.png`, "unterminated quote"},
+		{"/image " + text, "does not look like an image"},
+		{"/image " + huge, "larger than 10 MB"},
+	}
+	for _, tt := range tests {
+		if _, err := buildUserMessage(tt.input); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("buildUserMessage(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
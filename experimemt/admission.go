@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTooBusy is returned by Run when Agent.MaxConcurrentRuns runs are already
+// in progress and Agent.MaxQueuedRuns more are waiting for a slot.
+var ErrTooBusy = errors.New("agent is too busy: too many runs in progress")
+
+// acquireRun waits for one of the MaxConcurrentRuns run slots and returns the
+// function that gives it back. It fails with ErrTooBusy if the wait queue is
+// full and with ctx.Err() if ctx ends while waiting.
+func (a *Agent) acquireRun(ctx context.Context) (func(), error) {
+	slots := a.currentRunSlots()
+	if slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if queued := a.queuedRuns.Add(1); a.MaxQueuedRuns > 0 && queued > int32(a.MaxQueuedRuns) {
+		a.queuedRuns.Add(-1)
+		return nil, ErrTooBusy
+	}
+	defer a.queuedRuns.Add(-1)
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// currentRunSlots returns the semaphore sized for MaxConcurrentRuns, or nil
+// when runs are unlimited. Changing MaxConcurrentRuns replaces it: runs
+// holding a slot in the old one give it back there, so for a while the old
+// and new limits both apply.
+func (a *Agent) currentRunSlots() chan struct{} {
+	a.runSlotsMu.Lock()
+	defer a.runSlotsMu.Unlock()
+	if a.MaxConcurrentRuns <= 0 {
+		return nil
+	}
+	if cap(a.runSlots) != a.MaxConcurrentRuns {
+		a.runSlots = make(chan struct{}, a.MaxConcurrentRuns)
+	}
+	return a.runSlots
+}
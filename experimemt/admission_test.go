@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedClient answers once gate is closed, reporting each call on entered and
+// recording how many calls were in progress at once.
+func gatedClient(gate <-chan struct{}, entered chan<- struct{}, peak *atomic.Int32) LLMClient {
+	var active atomic.Int32
+	return LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		entered <- struct{}{}
+		<-gate
+		return "Final Answer: ok", nil
+	})
+}
+
+func TestMaxConcurrentRunsSerializes(t *testing.T) {
+	gate := make(chan struct{})
+	entered := make(chan struct{}, 5)
+	var peak atomic.Int32
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = gatedClient(gate, entered, &peak)
+	agent.MaxConcurrentRuns = 2
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+			errs <- err
+		}()
+	}
+	<-entered
+	<-entered
+	select {
+	case <-entered:
+		t.Fatal("a third run reached the model while two were in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gate)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("queued run failed: %v", err)
+		}
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want 2", got)
+	}
+}
+
+func TestMaxQueuedRunsRejects(t *testing.T) {
+	gate := make(chan struct{})
+	entered := make(chan struct{}, 2)
+	var peak atomic.Int32
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = gatedClient(gate, entered, &peak)
+	agent.MaxConcurrentRuns = 1
+	agent.MaxQueuedRuns = 1
+
+	done := make(chan error, 2)
+	run := func() {
+		_, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+		done <- err
+	}
+	go run()
+	<-entered
+	go run()
+	for agent.queuedRuns.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); !errors.Is(err, ErrTooBusy) {
+		t.Errorf("run past the queue limit = %v, want ErrTooBusy", err)
+	}
+	close(gate)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("admitted run failed: %v", err)
+		}
+	}
+}
+
+func TestAcquireRunRespectsContext(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.MaxConcurrentRuns = 1
+	release, err := agent.acquireRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := agent.acquireRun(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquireRun while saturated = %v, want the context error", err)
+	}
+	if got := agent.queuedRuns.Load(); got != 0 {
+		t.Errorf("%d runs still counted as queued after giving up", got)
+	}
+
+	release()
+	release, err = agent.acquireRun(context.Background())
+	if err != nil {
+		t.Fatalf("acquireRun after release = %v", err)
+	}
+	release()
+}
+
+func TestMaxConcurrentRunsChange(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.MaxConcurrentRuns = 1
+	release, err := agent.acquireRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	agent.MaxConcurrentRuns = 2
+	for i := 0; i < 2; i++ {
+		if _, err := agent.acquireRun(context.Background()); err != nil {
+			t.Fatalf("acquireRun %d after raising the limit = %v", i+1, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := agent.acquireRun(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquireRun past the new limit = %v, want the context error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrAnswerSchemaMismatch is returned by Run when the final answer does not
+// match Agent.RequiredAnswerSchema, even after the model was asked to
+// reformat it.
+var ErrAnswerSchemaMismatch = errors.New("final answer does not match the required schema")
+
+// checkAnswerSchema reports why answer, read as JSON, does not match the JSON
+// Schema document schema. Code fences around the answer are ignored.
+func checkAnswerSchema(schema, answer string) error {
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return fmt.Errorf("invalid answer schema: %v", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(stripCodeFence(answer)), &v); err != nil {
+		return fmt.Errorf("answer is not valid JSON: %v", err)
+	}
+	return matchSchema(s, v, "answer")
+}
+
+// matchSchema checks v against the commonly used subset of JSON Schema: type,
+// enum, properties, required, additionalProperties (as a boolean) and items.
+func matchSchema(schema map[string]interface{}, v interface{}, at string) error {
+	if t, ok := schema["type"].(string); ok && !hasJSONType(v, t) {
+		return fmt.Errorf("%s must be of type %s", at, t)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s must be one of %v", at, enum)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if name, ok := key.(string); ok {
+					if _, present := v[name]; !present {
+						return fmt.Errorf("%s is missing required key %q", at, name)
+					}
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			sub, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+					return fmt.Errorf("%s has unexpected key %q", at, key)
+				}
+				continue
+			}
+			if err := matchSchema(sub, v[key], at+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := matchSchema(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether v, as decoded by encoding/json, is of the JSON
+// Schema type t.
+func hasJSONType(v interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return true // unknown types are not enforced
+	}
+}
+
+// stripCodeFence removes a Markdown code fence, such as ```json ... ```,
+// wrapped around text.
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "```"), "```")
+	if i := strings.IndexByte(text, '\n'); i >= 0 && !strings.ContainsAny(text[:i], "{[\"") {
+		text = text[i+1:] // drop the language tag
+	}
+	return strings.TrimSpace(text)
+}
+
+// reformatAnswer asks the model once to rewrite answer so that it matches
+// RequiredAnswerSchema, returning the rewritten answer if it does.
+func (a *Agent) reformatAnswer(ctx context.Context, userInput, answer string, mismatch error) (string, error) {
+	prompt := fmt.Sprintf(`Your answer to the task below must be JSON matching this JSON Schema:
+%s
+
+Task: %s
+
+Your answer:
+%s
+
+It does not match because %v. Rewrite the answer so that it matches the schema, keeping its content. Reply with the JSON only.`, a.RequiredAnswerSchema, userInput, answer, mismatch)
+	response, err := a.generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	reformatted := stripCodeFence(a.trimAnswerPrefix(strings.TrimSpace(response)))
+	if err := checkAnswerSchema(a.RequiredAnswerSchema, reformatted); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAnswerSchemaMismatch, err)
+	}
+	return reformatted, nil
+}
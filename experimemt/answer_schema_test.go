@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const cityAnswerSchema = `{
+	"type": "object",
+	"required": ["city", "population"],
+	"additionalProperties": false,
+	"properties": {
+		"city": {"type": "string"},
+		"population": {"type": "integer"},
+		"tags": {"type": "array", "items": {"enum": ["capital", "port"]}}
+	}
+}`
+
+func TestCheckAnswerSchema(t *testing.T) {
+	tests := []struct {
+		answer, want string
+	}{
+		{`{"city": "Paris", "population": 2100000}`, ""},
+		{"```json\n{\"city\": \"Oslo\", \"population\": 700000, \"tags\": [\"capital\", \"port\"]}\n```", ""},
+		{`Paris has about 2.1 million people.`, "answer is not valid JSON"},
+		{`["Paris"]`, "answer must be of type object"},
+		{`{"city": "Paris"}`, `answer is missing required key "population"`},
+		{`{"city": "Paris", "population": 2.5}`, "answer.population must be of type integer"},
+		{`{"city": "Paris", "population": 1, "country": "FR"}`, `answer has unexpected key "country"`},
+		{`{"city": "Paris", "population": 1, "tags": ["capital", "river"]}`, "answer.tags[1] must be one of [capital port]"},
+	}
+	for _, tt := range tests {
+		err := checkAnswerSchema(cityAnswerSchema, tt.answer)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("checkAnswerSchema(%q) = %v, want %q", tt.answer, err, tt.want)
+		}
+	}
+}
+
+func TestRunReformatsAnswerToSchema(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		"Final Answer: Paris has about 2.1 million people.",
+		"Final Answer: ```json\n{\"city\": \"Paris\", \"population\": 2100000}\n```",
+	})
+	agent.RequiredAnswerSchema = cityAnswerSchema
+
+	got, err := agent.Run(context.Background(), historyPath(t), "How many people live in Paris?", nil)
+	if err != nil || got != `{"city": "Paris", "population": 2100000}` {
+		t.Fatalf("Run = %q, %v; want the reformatted JSON", got, err)
+	}
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("made %d model calls, want one reformat", len(prompts))
+	}
+	for _, want := range []string{cityAnswerSchema, "Task: How many people live in Paris?", "Paris has about 2.1 million people.", "answer is not valid JSON"} {
+		if !strings.Contains(prompts[1], want) {
+			t.Errorf("reformat prompt lacks %q:\n%s", want, prompts[1])
+		}
+	}
+}
+
+func TestRunAnswerSchemaMismatch(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Final Answer: {"city": "Paris"}`,
+		`{"city": "Paris", "population": "lots"}`,
+	})
+	agent.RequiredAnswerSchema = cityAnswerSchema
+
+	got, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+	if !errors.Is(err, ErrAnswerSchemaMismatch) || !strings.Contains(err.Error(), "answer.population must be of type integer") {
+		t.Fatalf("Run error = %v, want ErrAnswerSchemaMismatch with the reason", err)
+	}
+	if got != `{"city": "Paris"}` {
+		t.Errorf("answer = %q, want the original answer kept", got)
+	}
+	if n := len(agent.Client.(*ScriptedClient).Prompts()); n != 2 {
+		t.Errorf("made %d model calls, want only one reformat attempt", n)
+	}
+}
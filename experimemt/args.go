@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stringListArg reads a list argument as trimmed strings. Models send lists
+// either as JSON arrays or as comma-separated strings, so both are accepted;
+// non-string array items are formatted with fmt.Sprint.
+func stringListArg(args map[string]interface{}, key string) ([]string, error) {
+	var items []string
+	switch v := args[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			items = append(items, strings.TrimSpace(fmt.Sprint(item)))
+		}
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	default:
+		return nil, fmt.Errorf("missing or invalid '%s' argument, expected a list", key)
+	}
+	return items, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// AuditEntry is one line of the tool call audit log.
+type AuditEntry struct {
+	Time       time.Time              `json:"time"`
+	SessionID  string                 `json:"session_id"`
+	Tool       string                 `json:"tool"`
+	Args       map[string]interface{} `json:"args"`
+	Status     string                 `json:"status"` // "ok" or "error"
+	Error      string                 `json:"error,omitempty"`
+	DurationMS int64                  `json:"duration_ms"`
+}
+
+// sensitiveArgWords mark argument names whose values are kept out of the
+// audit log. They are matched against the name lowercased and with '_', '-'
+// and '.' removed, so "apikey" covers "api_key" and "X-Api-Key". Plain "key"
+// and "auth" are left out because they also match harmless names such as a
+// memory key or "author"; sensitiveArgNames lists names matched exactly.
+var sensitiveArgWords = []string{
+	"password", "passwd", "passphrase", "secret", "token", "credential", "cookie",
+	"apikey", "accesskey", "privatekey", "signingkey", "authorization",
+}
+
+// sensitiveArgNames are normalized argument names that are sensitive on their
+// own but too short to look for inside other names.
+var sensitiveArgNames = []string{"auth", "pin", "otp"}
+
+// isSensitiveArg reports whether the argument called name should be redacted.
+func isSensitiveArg(name string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(name))
+	for _, word := range sensitiveArgWords {
+		if strings.Contains(normalized, word) {
+			return true
+		}
+	}
+	for _, exact := range sensitiveArgNames {
+		if normalized == exact {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArgs returns a copy of args with the values of sensitive-looking
+// arguments, such as "api_key" or "password", replaced, including those
+// nested in objects and arrays.
+func redactArgs(args map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if isSensitiveArg(name) {
+			value = "[REDACTED]"
+		} else {
+			value = redactValue(value)
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// redactValue returns value with redactArgs applied to any objects in it.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactArgs(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, elem := range v {
+			redacted[i] = redactValue(elem)
+		}
+		return redacted
+	}
+	return value
+}
+
+// sessionID returns the "session_id" run metadata, or a random ID if ctx has
+// none, to tie together the audit entries of one run.
+func sessionID(ctx context.Context) string {
+	if id := RunMetadataFrom(ctx)["session_id"]; id != "" {
+		return id
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// auditToolCall records a call to tool that started at started and failed
+// with err, if non-nil.
+func (a *Agent) auditToolCall(session, tool string, args map[string]interface{}, started time.Time, err error) {
+	entry := AuditEntry{Time: started, SessionID: session, Tool: tool, Args: redactArgs(args), Status: "ok", DurationMS: time.Since(started).Milliseconds()}
+	if err != nil {
+		entry.Status, entry.Error = "error", err.Error()
+	}
+	a.audit(entry)
+}
+
+// audit appends entry to AuditLogPath, if set. Failures are logged rather
+// than failing the run.
+func (a *Agent) audit(entry AuditEntry) {
+	if a.AuditLogPath == "" {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to encode audit entry: %v\n", err)
+		return
+	}
+
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+	f, err := os.OpenFile(a.AuditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("Failed to open audit log: %v\n", err)
+		return
+	}
+	defer f.Close()
+	// A single write per entry keeps lines whole even if another process
+	// appends to the same file.
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed to write audit log %s: %v\n", a.AuditLogPath, err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsSensitiveArg(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"password", true},
+		{"api_key", true},
+		{"apiKey", true},
+		{"X-Api-Key", true},
+		{"secret_key", true},
+		{"aws_access_key_id", true},
+		{"private-key", true},
+		{"auth_token", true},
+		{"Authorization", true},
+		{"auth", true},
+		{"session_cookie", true},
+		{"key", false},
+		{"join_key", false},
+		{"author", false},
+		{"monkey", false},
+		{"keyword", false},
+		{"value", false},
+	}
+	for _, tt := range tests {
+		if got := isSensitiveArg(tt.name); got != tt.want {
+			t.Errorf("isSensitiveArg(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedactArgsNested(t *testing.T) {
+	args := map[string]interface{}{
+		"url": "https://example.com",
+		"headers": map[string]interface{}{
+			"Accept":        "application/json",
+			"Authorization": "Bearer abc",
+		},
+		"accounts": []interface{}{
+			map[string]interface{}{"user": "ann", "password": "hunter2"},
+			"plain",
+		},
+	}
+	want := map[string]interface{}{
+		"url": "https://example.com",
+		"headers": map[string]interface{}{
+			"Accept":        "application/json",
+			"Authorization": "[REDACTED]",
+		},
+		"accounts": []interface{}{
+			map[string]interface{}{"user": "ann", "password": "[REDACTED]"},
+			"plain",
+		},
+	}
+	if got := redactArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("redactArgs = %v, want %v", got, want)
+	}
+	if args["headers"].(map[string]interface{})["Authorization"] != "Bearer abc" {
+		t.Error("redactArgs modified the original arguments")
+	}
+}
+
+func TestRunWritesAuditLog(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "store", "arguments": {"key": "colour", "api_key": "sk-123"}}`,
+		`Action: {"name": "store", "arguments": {"key": "size"}}`,
+		`Final Answer: stored`,
+	})
+	agent.AddTool(ScriptedTool("store", "ok", "error: disk full"))
+	agent.AuditLogPath = filepath.Join(t.TempDir(), "audit.jsonl")
+
+	ctx := WithRunMetadata(context.Background(), RunMetadata{"session_id": "s1"})
+	if _, err := agent.Run(ctx, historyPath(t), "store things", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(agent.AuditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("bad audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+
+	first, second := entries[0], entries[1]
+	if first.SessionID != "s1" || first.Tool != "store" || first.Status != "ok" {
+		t.Errorf("first entry = %+v", first)
+	}
+	if first.Args["key"] != "colour" || first.Args["api_key"] != "[REDACTED]" {
+		t.Errorf("first entry args = %v, want key kept and api_key redacted", first.Args)
+	}
+	if second.Status != "error" || second.Error == "" {
+		t.Errorf("second entry = %+v, want the tool failure recorded", second)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NewCaseTool returns a tool that changes the case style of text. For snake,
+// camel and kebab case the input is split into words at spaces, punctuation
+// and case changes, so "HTTPServer error" becomes http_server_error.
+func NewCaseTool() Tool {
+	return Tool{
+		Name:        "case",
+		Description: "A tool that converts text to another case style, e.g. 'hello world' to 'helloWorld' or 'hello_world'.",
+		Args: map[string]string{
+			"input": "string",
+			"style": "string (e.g., 'upper', 'lower', 'title', 'snake', 'camel', 'kebab')",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			input, ok := args["input"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'input' argument")
+			}
+			style, ok := args["style"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'style' argument")
+			}
+
+			switch strings.ToLower(style) {
+			case "upper":
+				return strings.ToUpper(input), nil
+			case "lower":
+				return strings.ToLower(input), nil
+			case "title":
+				return titleCase(input), nil
+			case "snake":
+				return strings.ToLower(strings.Join(splitWords(input), "_")), nil
+			case "kebab":
+				return strings.ToLower(strings.Join(splitWords(input), "-")), nil
+			case "camel":
+				words := splitWords(input)
+				for i, w := range words {
+					w = strings.ToLower(w)
+					if i > 0 {
+						w = capitalize(w)
+					}
+					words[i] = w
+				}
+				return strings.Join(words, ""), nil
+			default:
+				return "", fmt.Errorf("unsupported style: %s", style)
+			}
+		},
+	}
+}
+
+// splitWords splits s into words at runs of characters other than letters
+// and digits, and at case changes: "parseHTTPRequest" gives parse, HTTP and
+// Request.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	r := []rune(s)
+	for i, c := range r {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(c) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, c)
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestCaseTool(t *testing.T) {
+	tool := NewCaseTool()
+	tests := []struct {
+		style, input, want string
+	}{
+		{"upper", "hello World", "HELLO WORLD"},
+		{"lower", "Hello WORLD", "hello world"},
+		{"title", "the quick brown fox", "The Quick Brown Fox"},
+		{"snake", "Hello World", "hello_world"},
+		{"snake", "HTTPServer error", "http_server_error"},
+		{"snake", "parseHTTPRequest", "parse_http_request"},
+		{"snake", "  user-id (v2)  ", "user_id_v2"},
+		{"kebab", "Hello World again", "hello-world-again"},
+		{"kebab", "myVar_name", "my-var-name"},
+		{"camel", "hello world", "helloWorld"},
+		{"camel", "user_account ID", "userAccountId"},
+		{"camel", "XML http request", "xmlHttpRequest"},
+		{"CAMEL", "Version 2 release", "version2Release"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"input": tt.input, "style": tt.style})
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %q, %v; want %q", tt.style, tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestCaseToolUnknownStyle(t *testing.T) {
+	_, err := NewCaseTool().Function(map[string]interface{}{"input": "x", "style": "pascal"})
+	if err == nil || err.Error() != "unsupported style: pascal" {
+		t.Errorf("error = %v, want the style rejected", err)
+	}
+}
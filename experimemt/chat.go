@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// generateChat sends prompt to the chat endpoint as a single user message and
+// streams the reply, forwarding every chunk to the Observer and returning the
+// accumulated text.
+func (a *Agent) generateChat(ctx context.Context, prompt string) (string, error) {
+	base, err := url.Parse(a.endpoint(""))
+	if err != nil {
+		return "", fmt.Errorf("invalid Ollama URL: %v", err)
+	}
+	client := api.NewClient(base, http.DefaultClient)
+	req := &api.ChatRequest{
+		Model:    a.Model,
+		Messages: []api.Message{{Role: RoleUser, Content: prompt}},
+		Options:  a.modelOptions(nil),
+	}
+	if a.KeepAlive > 0 {
+		req.KeepAlive = &api.Duration{Duration: a.KeepAlive}
+	}
+	return a.streamChat(ctx, func(fn api.ChatResponseFunc) error {
+		return client.Chat(ctx, req, fn)
+	})
+}
+
+// streamChat drives a chat stream started by chat, accumulating the content
+// of each chunk and reporting it as an EventModelChunk. Server errors become
+// OllamaErrors so that Run's context-length handling applies to both paths.
+func (a *Agent) streamChat(ctx context.Context, chat func(api.ChatResponseFunc) error) (string, error) {
+	var sb strings.Builder
+	truncated := false
+	err := chat(func(resp api.ChatResponse) error {
+		if resp.Done {
+			recordTokenCounts(ctx, resp.PromptEvalCount, resp.EvalCount)
+			truncated = resp.DoneReason == "length"
+		}
+		if resp.Message.Content == "" {
+			return nil
+		}
+		sb.WriteString(resp.Message.Content)
+		a.emit(ctx, RunEvent{Kind: EventModelChunk, Step: -1, Text: resp.Message.Content})
+		return nil
+	})
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		return sb.String(), &OllamaError{StatusCode: statusErr.StatusCode, Body: statusErr.ErrorMessage}
+	}
+	if err != nil {
+		return sb.String(), fmt.Errorf("failed to send request to Ollama: %v", err)
+	}
+	if truncated {
+		return sb.String(), ErrResponseTruncated
+	}
+	return sb.String(), nil
+}
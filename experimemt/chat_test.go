@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+// chatServer streams chunks as the /api/chat response to every request and
+// records the requests it receives.
+func chatServer(t *testing.T, chunks ...string) (*httptest.Server, *[]api.ChatRequest) {
+	t.Helper()
+	var requests []api.ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("request to %s, want /api/chat", r.URL.Path)
+		}
+		var req api.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		for _, chunk := range chunks {
+			fmt.Fprintln(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestRunStreamsChatChunksToObserver(t *testing.T) {
+	srv, requests := chatServer(t,
+		`{"model":"m","message":{"role":"assistant","content":"Final "},"done":false}`,
+		`{"model":"m","message":{"role":"assistant","content":""},"done":false}`,
+		`{"model":"m","message":{"role":"assistant","content":"Answer: hi"},"done":false}`,
+		`{"model":"m","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`,
+	)
+	agent := NewAgent(srv.URL, "m")
+	agent.UseChat = true
+	var chunks []string
+	agent.Observer = func(ev RunEvent) {
+		if ev.Kind == EventModelChunk {
+			chunks = append(chunks, ev.Text)
+		}
+	}
+
+	got, err := agent.Run(context.Background(), historyPath(t), "say hi", nil)
+	if err != nil || got != "hi" {
+		t.Fatalf("Run = %q, %v; want hi", got, err)
+	}
+	if strings.Join(chunks, "|") != "Final |Answer: hi" {
+		t.Errorf("observed chunks = %q, want each non-empty chunk", chunks)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("made %d chat requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Model != "m" || len(req.Messages) != 1 || req.Messages[0].Role != "user" || !strings.Contains(req.Messages[0].Content, "say hi") {
+		t.Errorf("chat request = %+v, want the prompt as one user message", req)
+	}
+}
+
+func TestGenerateChatErrors(t *testing.T) {
+	srv, _ := chatServer(t,
+		`{"model":"m","message":{"role":"assistant","content":"partial"},"done":false}`,
+		`{"model":"m","message":{"role":"assistant","content":""},"done":true,"done_reason":"length"}`,
+	)
+	got, err := NewAgent(srv.URL, "m").generateChat(context.Background(), "p")
+	if !errors.Is(err, ErrResponseTruncated) || got != "partial" {
+		t.Errorf("generateChat = %q, %v; want the partial text and ErrResponseTruncated", got, err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"model \"m\" not found"}`)
+	}))
+	defer failing.Close()
+	_, err = NewAgent(failing.URL, "m").generateChat(context.Background(), "p")
+	var ollamaErr *OllamaError
+	if !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusBadRequest || !strings.Contains(ollamaErr.Body, "not found") {
+		t.Errorf("generateChat error = %v, want an OllamaError with the server's message", err)
+	}
+}
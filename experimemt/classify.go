@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// finalAnswerMarker introduces the model's final answer unless
+// Agent.FinalAnswerPrefix says otherwise.
+const finalAnswerMarker = "Final Answer:"
+
+// Settings for Agent.MixedResponsePrecedence, deciding what to do with a
+// response that contains both a final answer and a tool call.
+const (
+	// PreferAuto takes the tool call when the final answer looks like
+	// reasoning about what to do next, and the final answer otherwise.
+	PreferAuto = ""
+	// PreferToolCall always takes the tool call.
+	PreferToolCall = "tool_call"
+	// PreferFinalAnswer always takes the final answer.
+	PreferFinalAnswer = "final_answer"
+)
+
+// responseKind is what a model response asks the agent loop to do.
+type responseKind int
+
+const (
+	responseMalformed responseKind = iota
+	responseFinalAnswer
+	responseToolCall
+)
+
+// reasoningPhrases suggest that text labelled as a final answer is really the
+// model planning its next step.
+var reasoningPhrases = []string{
+	"i will", "i'll", "i need to", "i should", "let me", "let's", "first,", "next,",
+	"use the", "using the", "call the", "calling the",
+}
+
+// classifyResponse decides whether a response is a final answer, a tool call
+// or malformed. For a final answer the payload is the answer text; for a tool
+// call it is the JSON object. Small models sometimes emit both; which one wins
+// is governed by MixedResponsePrecedence. With AllowBareAnswer, a response
+// with neither that reads as a plain reply to the user is a final answer,
+// unless StrictMode is set.
+func (a *Agent) classifyResponse(resp string) (responseKind, string) {
+	callJSON, callAt := findToolCallJSON(resp)
+	marker := a.finalAnswerPrefix()
+	answerAt := indexFold(resp, marker)
+	var answer string
+	if answerAt >= 0 {
+		answer = strings.TrimSpace(resp[answerAt+len(marker):])
+	}
+
+	switch {
+	case callAt < 0 && answerAt < 0:
+		if a.AllowBareAnswer && !a.StrictMode && a.isBareAnswer(resp) {
+			return responseFinalAnswer, strings.TrimSpace(resp)
+		}
+		return responseMalformed, ""
+	case callAt < 0:
+		return responseFinalAnswer, answer
+	case answerAt < 0:
+		return responseToolCall, callJSON
+	}
+
+	preferCall := false
+	switch a.MixedResponsePrecedence {
+	case PreferToolCall:
+		preferCall = true
+	case PreferFinalAnswer:
+		preferCall = false
+	default:
+		// A call written after the marker is part of the "answer", so the
+		// answer is really a plan; otherwise judge the answer's wording.
+		preferCall = callAt > answerAt || looksLikeReasoning(answer)
+	}
+	if preferCall {
+		return responseToolCall, callJSON
+	}
+	return responseFinalAnswer, answer
+}
+
+// finalAnswerPrefix returns the marker that introduces a final answer, with
+// surrounding whitespace removed.
+func (a *Agent) finalAnswerPrefix() string {
+	if prefix := strings.TrimSpace(a.FinalAnswerPrefix); prefix != "" {
+		return prefix
+	}
+	return finalAnswerMarker
+}
+
+// indexFold returns the byte offset of the first case-insensitive occurrence
+// of substr in s, or -1 if there is none.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// trimAnswerPrefix removes a leading final answer marker, in any case, from
+// response along with surrounding whitespace.
+func (a *Agent) trimAnswerPrefix(response string) string {
+	response = strings.TrimSpace(response)
+	if marker := a.finalAnswerPrefix(); indexFold(response, marker) == 0 {
+		response = response[len(marker):]
+	}
+	return strings.TrimSpace(response)
+}
+
+// isBareAnswer reports whether resp, which has neither a tool call nor a final
+// answer marker, is a conversational reply rather than a broken attempt at
+// one: it must not carry ReAct labels, look like a tool call JSON, or read
+// like the model planning its next step.
+func (a *Agent) isBareAnswer(resp string) bool {
+	resp = strings.TrimSpace(resp)
+	if resp == "" || looksLikeReasoning(resp) {
+		return false
+	}
+	for _, label := range []string{"Thought:", "Action:", a.label(EntryObservation)} {
+		if indexFold(resp, label) >= 0 {
+			return false
+		}
+	}
+	return !(strings.Contains(resp, "{") && strings.Contains(resp, `"name"`))
+}
+
+// looksLikeReasoning reports whether text reads like the model planning rather
+// than answering.
+func looksLikeReasoning(text string) bool {
+	lower := strings.ToLower(text)
+	for _, phrase := range reasoningPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// findToolCallJSON returns the first balanced JSON object in s that decodes to
+// a tool invocation with a name, along with its byte offset. It returns -1 when
+// there is none.
+func findToolCallJSON(s string) (string, int) {
+	for start := strings.IndexByte(s, '{'); start >= 0; {
+		if end := matchingBrace(s, start); end > start {
+			candidate := s[start : end+1]
+			var call ToolInvocation
+			if json.Unmarshal([]byte(candidate), &call) == nil && call.Name != "" {
+				return candidate, start
+			}
+		}
+		next := strings.IndexByte(s[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return "", -1
+}
+
+// matchingBrace returns the index of the brace closing the object that opens
+// at s[start], skipping braces inside JSON strings, or -1 if it is unclosed.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
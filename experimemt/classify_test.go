@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestClassifyMixedResponses(t *testing.T) {
+	call := `{"name": "search", "arguments": {"q": "go"}}`
+	tests := []struct {
+		name       string
+		precedence string
+		resp       string
+		wantKind   responseKind
+		wantText   string
+	}{
+		{"answer only", PreferAuto, "Final Answer: Go is a language.", responseFinalAnswer, "Go is a language."},
+		{"call only", PreferAuto, "Thought: look up\nAction: " + call, responseToolCall, call},
+		{"neither", PreferAuto, "hmm", responseMalformed, ""},
+
+		{"call before real answer", PreferAuto, "Action: " + call + "\nFinal Answer: Go is a language.", responseFinalAnswer, "Go is a language."},
+		{"call before planning answer", PreferAuto, "Action: " + call + "\nFinal Answer: I need to search first.", responseToolCall, call},
+		{"call after answer", PreferAuto, "Final Answer: here it is\n" + call, responseToolCall, call},
+
+		{"prefer call", PreferToolCall, "Action: " + call + "\nFinal Answer: Go is a language.", responseToolCall, call},
+		{"prefer answer over trailing call", PreferFinalAnswer, "Final Answer: Let me search.\n" + call, responseFinalAnswer, "Let me search.\n" + call},
+		{"marker case", PreferAuto, "final answer: done", responseFinalAnswer, "done"},
+	}
+	for _, tt := range tests {
+		agent := NewAgent("http://unused.invalid", "m")
+		agent.MixedResponsePrecedence = tt.precedence
+		kind, text := agent.classifyResponse(tt.resp)
+		if kind != tt.wantKind || text != tt.wantText {
+			t.Errorf("%s: classifyResponse = %v, %q; want %v, %q", tt.name, kind, text, tt.wantKind, tt.wantText)
+		}
+	}
+}
+
+func TestFindToolCallJSON(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   string
+		wantAt int
+	}{
+		{`Action: {"name": "a", "arguments": {"x": "}"}}`, `{"name": "a", "arguments": {"x": "}"}}`, 8},
+		{`{"not": "a call"} then {"name": "b"}`, `{"name": "b"}`, 23},
+		{`{"name": "unclosed"`, "", -1},
+		{`no json`, "", -1},
+	}
+	for _, tt := range tests {
+		if got, at := findToolCallJSON(tt.s); got != tt.want || at != tt.wantAt {
+			t.Errorf("findToolCallJSON(%q) = %q, %d; want %q, %d", tt.s, got, at, tt.want, tt.wantAt)
+		}
+	}
+}
+
+func TestFinalAnswerPrefix(t *testing.T) {
+	agent := NewAgent("http://unused.invalid", "m")
+	if got := agent.finalAnswerPrefix(); got != "Final Answer:" {
+		t.Errorf("default prefix = %q", got)
+	}
+	agent.FinalAnswerPrefix = "  FINAL: "
+	for _, resp := range []string{"FINAL: 42", "final:42", "  Final:   42  ", "Thought: done\nfInAl: 42"} {
+		if kind, text := agent.classifyResponse(resp); kind != responseFinalAnswer || text != "42" {
+			t.Errorf("classifyResponse(%q) = %v, %q; want final answer 42", resp, kind, text)
+		}
+	}
+	if kind, _ := agent.classifyResponse("Final Answer: 42"); kind != responseMalformed {
+		t.Errorf("the default marker is still recognized with a custom prefix")
+	}
+	if got := agent.trimAnswerPrefix("  final: 42 "); got != "42" {
+		t.Errorf("trimAnswerPrefix = %q, want 42", got)
+	}
+}
+
+func TestRunUsesFinalAnswerPrefix(t *testing.T) {
+	agent := ScriptedAgent([]string{"answer: Paris"})
+	agent.FinalAnswerPrefix = "Answer:"
+	got, err := agent.Run(context.Background(), historyPath(t), "capital of France?", nil)
+	if err != nil || got != "Paris" {
+		t.Fatalf("Run = %q, %v; want Paris", got, err)
+	}
+	prompt := agent.Client.(*ScriptedClient).Prompts()[0]
+	if !strings.Contains(prompt, "Answer:") || strings.Contains(prompt, "Final Answer:") {
+		t.Errorf("prompt does not instruct the custom prefix:\n%s", prompt)
+	}
+}
+
+func TestClassifyBareAnswers(t *testing.T) {
+	tests := []struct {
+		resp     string
+		wantKind responseKind
+	}{
+		{"You're welcome! Let me know if you need anything else later.", responseMalformed}, // "let me" reads as planning
+		{"You're welcome, glad it helped.", responseFinalAnswer},
+		{"Paris is the capital of France.", responseFinalAnswer},
+		{"I need to look up the population first.", responseMalformed},
+		{"Thought: the user is happy", responseMalformed},
+		{`Sure: {"name": "search", "arguments": `, responseMalformed},
+		{"Observation: 42", responseMalformed},
+		{"   ", responseMalformed},
+	}
+	for _, tt := range tests {
+		agent := NewAgent("http://unused.invalid", "m")
+		agent.AllowBareAnswer = true
+		if kind, _ := agent.classifyResponse(tt.resp); kind != tt.wantKind {
+			t.Errorf("classifyResponse(%q) = %v, want %v", tt.resp, kind, tt.wantKind)
+		}
+	}
+
+	agent := NewAgent("http://unused.invalid", "m")
+	agent.AllowBareAnswer, agent.StrictMode = true, true
+	if kind, _ := agent.classifyResponse("Paris is the capital of France."); kind != responseMalformed {
+		t.Errorf("StrictMode accepted a bare answer")
+	}
+}
+
+func TestRunBareAnswer(t *testing.T) {
+	responses := []string{
+		`{"name": "lookup", "arguments": {}}`,
+		"  The capital of France is Paris.  ",
+		"Final Answer: Paris",
+	}
+	for _, allow := range []bool{true, false} {
+		agent := ScriptedAgent(responses)
+		agent.AllowBareAnswer = allow
+		agent.AddTool(ScriptedTool("lookup", "capital=Paris"))
+
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), "What is the capital of France?", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allow && (result.Answer.Text != "The capital of France is Paris." || len(result.Trace) != 2) {
+			t.Errorf("with AllowBareAnswer: answer %q after %d steps, want the bare reply accepted", result.Answer.Text, len(result.Trace))
+		}
+		if !allow {
+			if result.Answer.Text != "Paris" || len(result.Trace) != 3 {
+				t.Errorf("without AllowBareAnswer: answer %q after %d steps, want a retry", result.Answer.Text, len(result.Trace))
+			}
+			if obs := result.Trace[1].Observation; !strings.HasPrefix(obs, "Invalid response:") {
+				t.Errorf("bare reply observation = %q, want a request to retry", obs)
+			}
+		}
+	}
+}
+
+func TestRunStrictMode(t *testing.T) {
+	responses := []string{"Paris is the capital of France.", "Final Answer: Paris"}
+	for _, strict := range []bool{false, true} {
+		agent := ScriptedAgent(responses)
+		agent.AllowBareAnswer = true
+		agent.StrictMode = strict
+
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), "capital of France?", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prompts := agent.Client.(*ScriptedClient).Prompts()
+		emphasized := strings.Contains(prompts[0], "Every response MUST contain either a tool call")
+		if !strict {
+			if result.Answer.Text != "Paris is the capital of France." || len(prompts) != 1 || emphasized {
+				t.Errorf("without StrictMode: answer %q after %d calls, strict prompt %v; want the freeform reply accepted", result.Answer.Text, len(prompts), emphasized)
+			}
+			continue
+		}
+		if result.Answer.Text != "Paris" || len(prompts) != 2 {
+			t.Errorf("StrictMode: answer %q after %d calls, want the freeform reply retried", result.Answer.Text, len(prompts))
+		}
+		if !emphasized {
+			t.Errorf("StrictMode prompt does not demand a tool call or final answer:\n%s", prompts[0])
+		}
+		if obs := result.Trace[0].Observation; !strings.HasPrefix(obs, "Invalid response:") {
+			t.Errorf("freeform reply observation = %q, want it rejected", obs)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "time"
+
+// Clock abstracts the current time so that time-dependent tools can be driven
+// by a fixed time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock backed by time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the default Clock, reporting the real wall-clock time.
+var SystemClock Clock = systemClock{}
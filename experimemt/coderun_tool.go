@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxCodeOutput caps how many bytes of stdout and stderr are returned each.
+const maxCodeOutput = 4096
+
+// codeRunners describes how to run a source file for each supported language.
+var codeRunners = map[string]struct {
+	file string
+	argv func(path string) []string
+}{
+	"python": {"main.py", func(path string) []string { return []string{"python3", path} }},
+	"go":     {"main.go", func(path string) []string { return []string{"go", "run", path} }},
+	"bash":   {"main.sh", func(path string) []string { return []string{"bash", path} }},
+}
+
+// SupportedCodeLanguage reports whether the code tool can run lang.
+func SupportedCodeLanguage(lang string) bool {
+	_, ok := codeRunners[lang]
+	return ok
+}
+
+// cappedWriter keeps the first n bytes written to it and counts the rest
+// without storing them, so a chatty program cannot exhaust memory.
+type cappedWriter struct {
+	buf     bytes.Buffer
+	n       int
+	dropped int
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	keep := min(len(p), w.n-w.buf.Len())
+	w.buf.Write(p[:keep])
+	w.dropped += len(p) - keep
+	return len(p), nil
+}
+
+// String returns the kept output, marking that some was dropped.
+func (w *cappedWriter) String() string {
+	if w.dropped == 0 {
+		return w.buf.String()
+	}
+	return w.buf.String() + "\n... (truncated)"
+}
+
+// CodeLimits bounds the resources of a program run by the code tool. The
+// memory, CPU and file size limits are applied with ulimit in a /bin/sh
+// wrapper, so they also cover any processes the program starts; a zero value
+// leaves that resource unlimited.
+type CodeLimits struct {
+	Timeout  time.Duration // wall-clock time before the program is killed
+	CPUTime  time.Duration // CPU time, rounded up to whole seconds
+	Memory   int64         // bytes of virtual address space per process
+	FileSize int64         // bytes of any single file the program writes
+}
+
+// DefaultCodeLimits returns the limits used by NewCodeRunTool: timeout of wall
+// and CPU time, 1 GiB of address space (enough for the go toolchain) and
+// 16 MiB files.
+func DefaultCodeLimits(timeout time.Duration) CodeLimits {
+	return CodeLimits{Timeout: timeout, CPUTime: timeout, Memory: 1 << 30, FileSize: 16 << 20}
+}
+
+// wrap returns argv prefixed with a shell that applies the limits before
+// replacing itself with the program.
+func (l CodeLimits) wrap(argv []string) []string {
+	var script []string
+	if l.Memory > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", (l.Memory+1023)/1024))
+	}
+	if l.CPUTime > 0 {
+		// The kernel sends SIGXCPU at the soft limit but SIGKILL at the hard
+		// one, so leave a second between them to report why it stopped.
+		secs := int64((l.CPUTime + time.Second - 1) / time.Second)
+		script = append(script, fmt.Sprintf("ulimit -S -t %d", secs), fmt.Sprintf("ulimit -H -t %d", secs+1))
+	}
+	if l.FileSize > 0 {
+		script = append(script, fmt.Sprintf("ulimit -f %d", (l.FileSize+511)/512))
+	}
+	if len(script) == 0 {
+		return argv
+	}
+	script = append(script, `exec "$@"`)
+	return append([]string{"/bin/sh", "-c", strings.Join(script, " && "), "sh"}, argv...)
+}
+
+// NewCodeRunTool returns a run_<lang> tool that writes the model's code to a
+// file in a fresh temporary directory and executes it there under
+// DefaultCodeLimits(timeout). Supported languages are python, go and bash;
+// check lang with SupportedCodeLanguage before registering the tool. Stdout
+// and stderr are each truncated to a few kilobytes as the program writes them.
+//
+// The limits bound resource use only: the code still runs with the privileges
+// of the agent process and can read and write its files and use the network,
+// so only register this tool when code execution has been explicitly enabled.
+func NewCodeRunTool(lang string, timeout time.Duration) Tool {
+	return NewCodeRunToolWithLimits(lang, DefaultCodeLimits(timeout))
+}
+
+// NewCodeRunToolWithLimits is like NewCodeRunTool but runs the code under
+// limits.
+func NewCodeRunToolWithLimits(lang string, limits CodeLimits) Tool {
+	timeout := limits.Timeout
+	return Tool{
+		Name:        "run_" + lang,
+		Description: fmt.Sprintf("A tool that runs a short %s program and returns its output. Print the values you need.", lang),
+		Args:        map[string]string{"code": "string (complete " + lang + " source code)"},
+		Function: func(args map[string]interface{}) (string, error) {
+			code, ok := args["code"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'code' argument")
+			}
+			runner, ok := codeRunners[lang]
+			if !ok {
+				return "", fmt.Errorf("unsupported language: %s", lang)
+			}
+
+			dir, err := os.MkdirTemp("", "agent-code-*")
+			if err != nil {
+				return "", fmt.Errorf("failed to create work directory: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, runner.file)
+			if err := os.WriteFile(path, []byte(code), 0600); err != nil {
+				return "", fmt.Errorf("failed to write code file: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			argv := limits.wrap(runner.argv(path))
+			cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+			cmd.Dir = dir
+			cmd.WaitDelay = time.Second
+			stdout := &cappedWriter{n: maxCodeOutput}
+			stderr := &cappedWriter{n: maxCodeOutput}
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+
+			runErr := cmd.Run()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("code did not finish within %s", timeout)
+			}
+			var exitErr *exec.ExitError
+			if runErr != nil && !errors.As(runErr, &exitErr) {
+				return "", fmt.Errorf("failed to run code: %v", runErr)
+			}
+
+			result := fmt.Sprintf("stdout:\n%s\nstderr:\n%s", stdout, stderr)
+			if exitErr != nil {
+				status := fmt.Sprintf("exit code %d", exitErr.ExitCode())
+				if exitErr.ExitCode() == -1 {
+					status = exitErr.ProcessState.String() // e.g. "signal: CPU time limit exceeded"
+				}
+				result = fmt.Sprintf("%s\n%s", status, result)
+			}
+			return result, nil
+		},
+	}
+}
+
+// truncate shortens s to at most n bytes, marking that it was cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "\n... (truncated)"
+}
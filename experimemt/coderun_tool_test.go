@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func requireCommand(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not available: %v", name, err)
+	}
+}
+
+func TestCodeLimitsWrap(t *testing.T) {
+	argv := []string{"python3", "main.py"}
+	if got := (CodeLimits{Timeout: time.Second}).wrap(argv); !reflect.DeepEqual(got, argv) {
+		t.Errorf("no limits: got %q, want the program unwrapped", got)
+	}
+
+	got := CodeLimits{CPUTime: 1500 * time.Millisecond, Memory: 1 << 20, FileSize: 1000}.wrap(argv)
+	want := []string{"/bin/sh", "-c", `ulimit -v 1024 && ulimit -S -t 2 && ulimit -H -t 3 && ulimit -f 2 && exec "$@"`, "sh", "python3", "main.py"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCodeRunTool(t *testing.T) {
+	requireCommand(t, "bash")
+	tool := NewCodeRunTool("bash", 10*time.Second)
+
+	got, err := tool.Function(map[string]interface{}{"code": "echo out; echo err >&2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "stdout:\nout\n\nstderr:\nerr\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err = tool.Function(map[string]interface{}{"code": "exit 3"})
+	if err != nil || !strings.HasPrefix(got, "exit code 3\n") {
+		t.Errorf("failing program: got %q, %v", got, err)
+	}
+
+	got, err = tool.Function(map[string]interface{}{"code": "head -c 10000 /dev/zero | tr '\\0' x"})
+	if err != nil || !strings.Contains(got, "... (truncated)") || len(got) > 2*maxCodeOutput {
+		t.Errorf("long output: got %d bytes, %v", len(got), err)
+	}
+}
+
+func TestCodeRunToolTimeout(t *testing.T) {
+	requireCommand(t, "bash")
+	tool := NewCodeRunToolWithLimits("bash", CodeLimits{Timeout: 200 * time.Millisecond})
+
+	start := time.Now()
+	_, err := tool.Function(map[string]interface{}{"code": "sleep 30"})
+	if err == nil || !strings.Contains(err.Error(), "did not finish within 200ms") {
+		t.Errorf("got error %v, want a timeout", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("timed out program took %v to stop", d)
+	}
+}
+
+func TestCodeRunToolEnforcesLimits(t *testing.T) {
+	requireCommand(t, "bash")
+	requireCommand(t, "python3")
+
+	memory := NewCodeRunToolWithLimits("python", CodeLimits{Timeout: 10 * time.Second, Memory: 256 << 20})
+	got, err := memory.Function(map[string]interface{}{"code": "x = bytearray(1 << 30)\nprint(len(x))"})
+	if err != nil || !strings.Contains(got, "MemoryError") {
+		t.Errorf("memory limit: got %q, %v", got, err)
+	}
+
+	cpu := NewCodeRunToolWithLimits("bash", CodeLimits{Timeout: 10 * time.Second, CPUTime: time.Second})
+	got, err = cpu.Function(map[string]interface{}{"code": "while :; do :; done"})
+	if err != nil || !strings.HasPrefix(got, "signal: CPU time limit exceeded") {
+		t.Errorf("CPU limit: got %q, %v", got, err)
+	}
+
+	files := NewCodeRunToolWithLimits("bash", CodeLimits{Timeout: 10 * time.Second, FileSize: 4096})
+	got, err = files.Function(map[string]interface{}{"code": "head -c 100000 /dev/zero > big; wc -c < big"})
+	if err != nil || !strings.Contains(got, "stdout:\n4096\n") {
+		t.Errorf("file size limit: got %q, %v", got, err)
+	}
+}
+
+func TestCodeRunToolErrors(t *testing.T) {
+	if _, err := NewCodeRunTool("bash", time.Second).Function(map[string]interface{}{}); err == nil || err.Error() != "missing 'code' argument" {
+		t.Errorf("missing code: got error %v", err)
+	}
+	if _, err := NewCodeRunTool("cobol", time.Second).Function(map[string]interface{}{"code": "x"}); err == nil || err.Error() != "unsupported language: cobol" {
+		t.Errorf("unknown language: got error %v", err)
+	}
+}
+
+func TestCodeRunToolCapsOutput(t *testing.T) {
+	requireCommand(t, "bash")
+	tool := NewCodeRunTool("bash", 10*time.Second)
+
+	got, err := tool.Function(map[string]interface{}{"code": "head -c 1000000 /dev/zero | tr '\\0' x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "stdout:\n" + strings.Repeat("x", maxCodeOutput) + "\n... (truncated)\nstderr:\n"
+	if got != want {
+		t.Errorf("got %d bytes, want %d ending in a truncation note", len(got), len(want))
+	}
+}
+
+func TestSupportedCodeLanguage(t *testing.T) {
+	for _, lang := range []string{"python", "go", "bash"} {
+		if !SupportedCodeLanguage(lang) {
+			t.Errorf("SupportedCodeLanguage(%q) = false", lang)
+		}
+	}
+	if SupportedCodeLanguage("cobol") {
+		t.Error("SupportedCodeLanguage(\"cobol\") = true")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NewColorTool returns a tool that converts colors between hex, RGB and HSL.
+// RGB components range over 0-255, hue over 0-360 degrees, and saturation and
+// lightness over 0-100 percent. Hex colors may be written as #RRGGBB or #RGB.
+func NewColorTool() Tool {
+	return Tool{
+		Name:        "color",
+		Description: "A tool that converts colors between hex (#RRGGBB), RGB (0-255) and HSL (hue 0-360, saturation and lightness 0-100%).",
+		Args: map[string]string{
+			"operation": "string (e.g., 'hex_to_rgb', 'rgb_to_hex', 'rgb_to_hsl', 'hsl_to_rgb', 'hex_to_hsl', 'hsl_to_hex')",
+			"hex":       "string (e.g. '#ff8800', for hex_to_*)",
+			"r":         "number (0-255, for rgb_to_*)",
+			"g":         "number (0-255, for rgb_to_*)",
+			"b":         "number (0-255, for rgb_to_*)",
+			"h":         "number (0-360, for hsl_to_*)",
+			"s":         "number (0-100, for hsl_to_*)",
+			"l":         "number (0-100, for hsl_to_*)",
+		},
+		OptionalArgs: []string{"hex", "r", "g", "b", "h", "s", "l"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			from, to, ok := strings.Cut(op, "_to_")
+			if !ok {
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+
+			var r, g, b int
+			var err error
+			switch from {
+			case "hex":
+				hex, ok := args["hex"].(string)
+				if !ok {
+					return "", fmt.Errorf("missing 'hex' argument")
+				}
+				r, g, b, err = parseHexColor(hex)
+			case "rgb":
+				r, g, b, err = rgbArgs(args)
+			case "hsl":
+				r, g, b, err = hslArgs(args)
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+			if err != nil {
+				return "", err
+			}
+
+			switch to {
+			case "hex":
+				return fmt.Sprintf("#%02x%02x%02x", r, g, b), nil
+			case "rgb":
+				return fmt.Sprintf("rgb(%d, %d, %d)", r, g, b), nil
+			case "hsl":
+				h, s, l := rgbToHSL(r, g, b)
+				return fmt.Sprintf("hsl(%.0f, %.0f%%, %.0f%%)", h, s, l), nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// parseHexColor parses #RRGGBB or #RGB, with or without the leading '#'.
+func parseHexColor(hex string) (r, g, b int, err error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q: expected #RRGGBB or #RGB", hex)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q: expected #RRGGBB or #RGB", hex)
+	}
+	return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), nil
+}
+
+// colorComponent reads a numeric argument and checks it lies within [0, limit].
+func colorComponent(args map[string]interface{}, key string, limit float64) (float64, error) {
+	v, ok := args[key].(float64)
+	if !ok {
+		return 0, fmt.Errorf("missing or invalid '%s' argument", key)
+	}
+	if v < 0 || v > limit {
+		return 0, fmt.Errorf("'%s' must be between 0 and %g, got %g", key, limit, v)
+	}
+	return v, nil
+}
+
+// rgbArgs reads the r, g and b arguments.
+func rgbArgs(args map[string]interface{}) (r, g, b int, err error) {
+	var c [3]int
+	for i, key := range []string{"r", "g", "b"} {
+		v, err := colorComponent(args, key, 255)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		c[i] = int(math.Round(v))
+	}
+	return c[0], c[1], c[2], nil
+}
+
+// hslArgs reads the h, s and l arguments and converts them to RGB.
+func hslArgs(args map[string]interface{}) (r, g, b int, err error) {
+	h, err := colorComponent(args, "h", 360)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	s, err := colorComponent(args, "s", 100)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	l, err := colorComponent(args, "l", 100)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	r, g, b = hslToRGB(h, s/100, l/100)
+	return r, g, b, nil
+}
+
+// hslToRGB converts hue in degrees and saturation and lightness in [0, 1].
+func hslToRGB(h, s, l float64) (r, g, b int) {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := math.Mod(h, 360) / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r1, g1, b1 float64
+	switch {
+	case hp < 1:
+		r1, g1 = c, x
+	case hp < 2:
+		r1, g1 = x, c
+	case hp < 3:
+		g1, b1 = c, x
+	case hp < 4:
+		g1, b1 = x, c
+	case hp < 5:
+		r1, b1 = x, c
+	default:
+		r1, b1 = c, x
+	}
+	m := l - c/2
+	to255 := func(v float64) int { return int(math.Round((v + m) * 255)) }
+	return to255(r1), to255(g1), to255(b1)
+}
+
+// rgbToHSL returns hue in degrees and saturation and lightness in percent.
+func rgbToHSL(r, g, b int) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	hi := max(rf, gf, bf)
+	lo := min(rf, gf, bf)
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l * 100
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case rf:
+		h = math.Mod((gf-bf)/d, 6)
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s * 100, l * 100
+}
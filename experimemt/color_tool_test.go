@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestColorTool(t *testing.T) {
+	tool := NewColorTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "hex_to_rgb", "hex": "#ff8800"}, "rgb(255, 136, 0)"},
+		{map[string]interface{}{"operation": "hex_to_rgb", "hex": " 0F0 "}, "rgb(0, 255, 0)"},
+		{map[string]interface{}{"operation": "rgb_to_hex", "r": 255.0, "g": 136.0, "b": 0.0}, "#ff8800"},
+		{map[string]interface{}{"operation": "rgb_to_hsl", "r": 255.0, "g": 0.0, "b": 0.0}, "hsl(0, 100%, 50%)"},
+		{map[string]interface{}{"operation": "hex_to_hsl", "hex": "#808080"}, "hsl(0, 0%, 50%)"},
+		{map[string]interface{}{"operation": "hsl_to_rgb", "h": 120.0, "s": 100.0, "l": 25.0}, "rgb(0, 128, 0)"},
+		{map[string]interface{}{"operation": "hsl_to_hex", "h": 210.0, "s": 50.0, "l": 40.0}, "#336699"},
+		{map[string]interface{}{"operation": "hsl_to_hex", "h": 360.0, "s": 100.0, "l": 50.0}, "#ff0000"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("color(%v) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestColorToolHexRoundTrip(t *testing.T) {
+	tool := NewColorTool()
+	for _, hex := range []string{"#000000", "#ffffff", "#ff8800", "#123abc", "#7f7f80"} {
+		rgb, err := tool.Function(map[string]interface{}{"operation": "hex_to_rgb", "hex": hex})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r, g, b float64
+		if _, err := fmt.Sscanf(rgb, "rgb(%g, %g, %g)", &r, &g, &b); err != nil {
+			t.Fatalf("hex_to_rgb(%s) = %q: %v", hex, rgb, err)
+		}
+		back, err := tool.Function(map[string]interface{}{"operation": "rgb_to_hex", "r": r, "g": g, "b": b})
+		if err != nil || back != hex {
+			t.Errorf("%s -> %s -> %s, %v; want the original", hex, rgb, back, err)
+		}
+	}
+}
+
+func TestColorToolErrors(t *testing.T) {
+	tool := NewColorTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "rgb_to_hex", "r": 256.0, "g": 0.0, "b": 0.0}, "'r' must be between 0 and 255, got 256"},
+		{map[string]interface{}{"operation": "rgb_to_hex", "r": 0.0, "g": -1.0, "b": 0.0}, "'g' must be between 0 and 255"},
+		{map[string]interface{}{"operation": "hsl_to_rgb", "h": 361.0, "s": 50.0, "l": 50.0}, "'h' must be between 0 and 360"},
+		{map[string]interface{}{"operation": "hsl_to_rgb", "h": 10.0, "s": 101.0, "l": 50.0}, "'s' must be between 0 and 100"},
+		{map[string]interface{}{"operation": "hex_to_rgb", "hex": "#12345"}, "invalid hex color"},
+		{map[string]interface{}{"operation": "hex_to_rgb", "hex": "#ggg"}, "invalid hex color"},
+		{map[string]interface{}{"operation": "rgb_to_hex", "r": 1.0}, "missing or invalid 'g'"},
+		{map[string]interface{}{"operation": "cmyk_to_rgb"}, "unsupported operation"},
+		{map[string]interface{}{"operation": "hex_to_cmyk", "hex": "#fff"}, "unsupported operation"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("color(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// CommandPolicy decides which programs a shell command may run. A program on
+// Deny is always refused; when Allow is non-empty, only programs on it may
+// run. Programs are matched by base name, so "/bin/rm" counts as rm.
+type CommandPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// DefaultCommandPolicy refuses programs that destroy data, change privileges,
+// stop the machine or run their arguments as code, and allows the rest.
+var DefaultCommandPolicy = CommandPolicy{Deny: []string{
+	"rm", "rmdir", "shred", "dd", "mkfs", "truncate",
+	"sudo", "su", "doas", "chmod", "chown",
+	"shutdown", "reboot", "halt", "poweroff", "kill", "killall", "pkill",
+	"bash", "sh", "zsh", "eval", "exec", "source", ".", "env", "xargs",
+}}
+
+// Check reports why command, a bash script, may not run under the policy.
+// Every program it runs must be named literally: one taken from a variable
+// or command substitution cannot be checked and is refused.
+func (p CommandPolicy) Check(command string) error {
+	for _, name := range commandNames(command) {
+		if strings.ContainsAny(name, "$`") {
+			return fmt.Errorf("command policy requires a literal program name, not %q", name)
+		}
+		program := path.Base(name)
+		if slices.Contains(p.Deny, program) || len(p.Allow) > 0 && !slices.Contains(p.Allow, program) {
+			return fmt.Errorf("command policy does not allow running %s", program)
+		}
+	}
+	return nil
+}
+
+// shellKeywords may precede the program in a simple command.
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "do": true, "done": true,
+	"while": true, "until": true, "!": true, "{": true, "}": true, "time": true,
+}
+
+// commandNames returns the programs a bash script runs: the first word of
+// every simple command, including those in pipelines, lists and command
+// substitutions, with quotes removed. Variable assignments and keywords before
+// the program are skipped. A command substitution in the program's place is
+// returned as "$(...)".
+func commandNames(script string) []string {
+	type substitution struct {
+		closer  byte // ')' or '`'
+		quote   byte // quoting outside the substitution
+		program bool // it stands where the program name belongs
+	}
+	var names []string
+	var stack []substitution
+	var word strings.Builder
+	var quote byte
+	atProgram := true // the next word names a program
+	inWord := false
+	skipWord := false // the next word is a redirection target
+	flush := func() {
+		if !inWord {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		inWord = false
+		switch {
+		case skipWord:
+			skipWord = false
+		case !atProgram:
+		case shellKeywords[w]:
+		case strings.Contains(w, "=") && !strings.HasPrefix(w, "="):
+			// A variable assignment for the command.
+		case w == "for" || w == "case" || w == "select":
+			atProgram = false // the next words are not programs
+		default:
+			names = append(names, w)
+			atProgram = false
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(script):
+			i++
+			word.WriteByte(script[i])
+			inWord = true
+		case c == '$' && i+1 < len(script) && script[i+1] == '(', c == '`' && (len(stack) == 0 || stack[len(stack)-1].closer != '`'):
+			program := atProgram && !inWord
+			flush()
+			closer := byte('`')
+			if c == '$' {
+				closer = ')'
+				i++
+			}
+			stack = append(stack, substitution{closer: closer, quote: quote, program: program})
+			quote, atProgram = 0, true
+		case len(stack) > 0 && quote == 0 && c == stack[len(stack)-1].closer:
+			flush()
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			quote, atProgram = top.quote, false
+			if top.program {
+				names = append(names, "$(...)")
+			}
+			inWord = true // the substitution is part of a word
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '<' || c == '>':
+			if word.Len() > 0 && strings.Trim(word.String(), "0123456789") == "" {
+				word.Reset() // a file descriptor such as the 2 in 2>
+				inWord = false
+			}
+			flush()
+			for i+1 < len(script) && strings.IndexByte("<>&|", script[i+1]) >= 0 {
+				i++
+			}
+			skipWord = true
+		case strings.IndexByte(";&|\n()", c) >= 0:
+			flush()
+			atProgram = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return names
+}
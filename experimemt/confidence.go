@@ -0,0 +1,34 @@
+package main
+
+import "strings"
+
+// factualCues suggest that a question asks for specific facts or data rather
+// than, say, an opinion or a piece of writing.
+var factualCues = []string{
+	"who ", "what ", "when ", "where ", "which ", "how many", "how much", "how old", "how far", "how long",
+	"price", "population", "capital of", "weather", "latest", "current", "today",
+}
+
+// LowConfidence is the default Agent.ConfidenceFunc. It flags answers that
+// were given without a successful tool call when the question looks factual:
+// it contains a question word or a cue such as "price", or asks about
+// numbers. Refused and failed tool calls are no evidence.
+func LowConfidence(question string, result *RunResult) bool {
+	for _, step := range result.Trace {
+		if step.Succeeded {
+			return false
+		}
+	}
+	return looksFactual(question)
+}
+
+// looksFactual reports whether question appears to ask for specific facts.
+func looksFactual(question string) bool {
+	lower := " " + strings.ToLower(strings.TrimSpace(question))
+	for _, cue := range factualCues {
+		if strings.Contains(lower, " "+cue) {
+			return true
+		}
+	}
+	return len(numericTokens(lower)) > 0 && strings.HasSuffix(lower, "?")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLooksFactual(t *testing.T) {
+	tests := map[string]bool{
+		"What is the capital of Peru?":  true,
+		"how many moons does Mars have": true,
+		"Bitcoin price":                 true,
+		"Is 1024 bigger than 999?":      true,
+		"Tell me a joke":                false,
+		"Write a haiku about autumn":    false,
+		"somewhat unrelated: whatever":  false,
+	}
+	for question, want := range tests {
+		if got := looksFactual(question); got != want {
+			t.Errorf("looksFactual(%q) = %v, want %v", question, got, want)
+		}
+	}
+}
+
+func TestRunFlagsLowConfidence(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		question  string
+		want      bool
+		answer    string
+	}{
+		{"tool-free factual", []string{"Final Answer: Lima"}, "What is the capital of Peru?", true, "Lima\n\n(unverified)"},
+		{"tool-backed factual", []string{`Action: {"name": "search", "arguments": {}}`, "Final Answer: Lima"}, "What is the capital of Peru?", false, "Lima"},
+		{"failed tool", []string{`Action: {"name": "broken", "arguments": {}}`, "Final Answer: Lima"}, "What is the capital of Peru?", true, "Lima\n\n(unverified)"},
+		{"refused tool", []string{`Action: {"name": "missing", "arguments": {}}`, "Final Answer: Lima"}, "What is the capital of Peru?", true, "Lima\n\n(unverified)"},
+		{"tool-free creative", []string{"Final Answer: Leaves fall"}, "Write a haiku about autumn", false, "Leaves fall"},
+	}
+	for _, tt := range tests {
+		agent := ScriptedAgent(tt.responses)
+		agent.AddTool(ScriptedTool("search", "Lima is the capital of Peru."))
+		agent.AddTool(Tool{Name: "broken", Function: func(map[string]interface{}) (string, error) {
+			return "", errors.New("service unavailable")
+		}})
+		agent.LowConfidenceDisclaimer = "(unverified)"
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), tt.question, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if result.LowConfidence != tt.want {
+			t.Errorf("%s: LowConfidence = %v, want %v", tt.name, result.LowConfidence, tt.want)
+		}
+		if result.Answer.Text != tt.answer {
+			t.Errorf("%s: answer = %q, want %q", tt.name, result.Answer.Text, tt.answer)
+		}
+	}
+}
+
+func TestConfidenceFunc(t *testing.T) {
+	agent := ScriptedAgent([]string{"Final Answer: maybe"})
+	var gotQuestion string
+	agent.ConfidenceFunc = func(question string, result *RunResult) bool {
+		gotQuestion = question
+		return result.Answer.Text == "maybe"
+	}
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "Tell me a joke", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotQuestion != "Tell me a joke" || !result.LowConfidence {
+		t.Errorf("custom ConfidenceFunc not used: question %q, LowConfidence %v", gotQuestion, result.LowConfidence)
+	}
+	if result.Answer.Text != "maybe" {
+		t.Errorf("answer = %q, want no disclaimer when none is configured", result.Answer.Text)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// maxCronRuns caps how many fire times 'next' returns.
+const maxCronRuns = 10
+
+// cronParser accepts standard five-field expressions and descriptors such as
+// @daily or @every 1h.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// NewCronTool returns a tool that evaluates cron expressions using the system
+// clock.
+func NewCronTool() Tool {
+	return NewCronToolWithClock(SystemClock)
+}
+
+// NewCronToolWithClock is like NewCronTool but reads the current time from
+// clock. It supports two operations:
+//
+//   - next: lists the next fire times after now as RFC 3339 timestamps
+//   - describe: explains the expression in plain English
+func NewCronToolWithClock(clock Clock) Tool {
+	return Tool{
+		Name:        "cron",
+		Description: "A tool that validates cron expressions (e.g. '*/15 9-17 * * 1-5'), lists their next fire times or describes them in plain English.",
+		Args: map[string]string{
+			"operation":  "string (e.g., 'next', 'describe')",
+			"expression": "string (five-field cron expression or descriptor such as '@daily')",
+			"count":      "number (how many fire times 'next' returns, default 1, at most 10)",
+		},
+		OptionalArgs: []string{"count"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			expr, ok := args["expression"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'expression' argument")
+			}
+			expr = strings.TrimSpace(expr)
+			schedule, err := cronParser.Parse(expr)
+			if err != nil {
+				return "", fmt.Errorf("invalid cron expression %q: %v", expr, err)
+			}
+
+			switch op {
+			case "next":
+				count := 1
+				if n, ok := args["count"].(float64); ok {
+					count = int(n)
+				}
+				if count < 1 || count > maxCronRuns {
+					return "", fmt.Errorf("'count' must be between 1 and %d", maxCronRuns)
+				}
+				runs := make([]string, 0, count)
+				t := clock.Now()
+				for len(runs) < count {
+					t = schedule.Next(t)
+					if t.IsZero() {
+						break // the expression never fires, e.g. 0 0 30 2 *
+					}
+					runs = append(runs, t.Format(time.RFC3339))
+				}
+				if len(runs) == 0 {
+					return "", fmt.Errorf("cron expression %q never fires", expr)
+				}
+				out, err := json.Marshal(runs)
+				if err != nil {
+					return "", fmt.Errorf("failed to encode fire times: %v", err)
+				}
+				return string(out), nil
+			case "describe":
+				return describeCron(expr), nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// cronDescriptors explains the predefined schedules.
+var cronDescriptors = map[string]string{
+	"@yearly":   "At 00:00 on January 1st",
+	"@annually": "At 00:00 on January 1st",
+	"@monthly":  "At 00:00 on the first day of every month",
+	"@weekly":   "At 00:00 every Sunday",
+	"@daily":    "At 00:00 every day",
+	"@midnight": "At 00:00 every day",
+	"@hourly":   "At minute 0 of every hour",
+}
+
+var (
+	monthNames   = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+)
+
+// describeCron renders an already validated expression in plain English,
+// e.g. "At minute 0 past hour 9, on Monday through Friday".
+func describeCron(expr string) string {
+	if strings.HasPrefix(expr, "@every ") {
+		return "Every " + strings.TrimSpace(strings.TrimPrefix(expr, "@every "))
+	}
+	if desc, ok := cronDescriptors[expr]; ok {
+		return desc
+	}
+
+	fields := strings.Fields(expr)
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	var sb strings.Builder
+	if isCronNumber(minute) && isCronNumber(hour) {
+		m, _ := strconv.Atoi(minute)
+		h, _ := strconv.Atoi(hour)
+		fmt.Fprintf(&sb, "At %02d:%02d", h, m)
+	} else {
+		desc := describeCronField(minute, "minute", nil)
+		if strings.HasPrefix(desc, "every ") {
+			sb.WriteString("E" + desc[1:])
+		} else {
+			sb.WriteString("At " + desc)
+		}
+		if hour != "*" {
+			sb.WriteString(" past " + describeCronField(hour, "hour", nil))
+		}
+	}
+	if dom != "*" && dom != "?" {
+		sb.WriteString(", on " + describeCronField(dom, "day-of-month", nil))
+	}
+	if month != "*" {
+		sb.WriteString(", in " + describeCronField(month, "month", monthNames))
+	}
+	if dow != "*" && dow != "?" {
+		sb.WriteString(", on " + describeCronField(dow, "day-of-week", weekdayNames))
+	}
+	return sb.String()
+}
+
+// describeCronField explains one field such as "*/15", "1-5" or "1,15". When
+// names is non-nil, numeric values are replaced by their names.
+func describeCronField(field, unit string, names []string) string {
+	name := func(v string) string {
+		if n, err := strconv.Atoi(v); err == nil && names != nil && n >= 0 && n < len(names) {
+			return names[n]
+		}
+		return v
+	}
+
+	var parts []string
+	for _, part := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+		var desc string
+		switch {
+		case rng == "*" || rng == "?":
+			desc = "every " + unit
+		case strings.Contains(rng, "-"):
+			lo, hi, _ := strings.Cut(rng, "-")
+			desc = fmt.Sprintf("%s %s through %s", unit, name(lo), name(hi))
+			if names != nil {
+				desc = fmt.Sprintf("%s through %s", name(lo), name(hi))
+			}
+		default:
+			desc = unit + " " + name(rng)
+			if names != nil {
+				desc = name(rng)
+			}
+		}
+		if hasStep {
+			if rng == "*" || rng == "?" {
+				desc = fmt.Sprintf("every %s %ss", step, unit)
+			} else {
+				desc = fmt.Sprintf("every %s %ss from %s", step, unit, strings.TrimPrefix(desc, unit+" "))
+			}
+		}
+		parts = append(parts, desc)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// isCronNumber reports whether field is a single plain number.
+func isCronNumber(field string) bool {
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
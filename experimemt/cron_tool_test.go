@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronToolNext(t *testing.T) {
+	// Friday 15 March 2024, 10:07 UTC.
+	tool := NewCronToolWithClock(newFakeClock(time.Date(2024, 3, 15, 10, 7, 0, 0, time.UTC)))
+	tests := []struct {
+		expr  string
+		count float64
+		want  string
+	}{
+		{"*/15 9-17 * * 1-5", 3, `["2024-03-15T10:15:00Z","2024-03-15T10:30:00Z","2024-03-15T10:45:00Z"]`},
+		{"0 9 * * 1", 0, `["2024-03-18T09:00:00Z"]`},
+		{" @daily ", 2, `["2024-03-16T00:00:00Z","2024-03-17T00:00:00Z"]`},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"operation": "next", "expression": tt.expr}
+		if tt.count > 0 {
+			args["count"] = tt.count
+		}
+		got, err := tool.Function(args)
+		if err != nil || got != tt.want {
+			t.Errorf("next(%q) = %s, %v; want %s", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestCronToolDescribe(t *testing.T) {
+	tool := NewCronTool()
+	tests := map[string]string{
+		"0 9 * * 1-5":  "At 09:00, on Monday through Friday",
+		"*/15 * * * *": "Every 15 minutes",
+		"30 */2 1 1 *": "At minute 30 past every 2 hours, on day-of-month 1, in January",
+		"@weekly":      "At 00:00 every Sunday",
+		"@every 1h30m": "Every 1h30m",
+		"0 0 1,15 * 0": "At 00:00, on day-of-month 1 and day-of-month 15, on Sunday",
+	}
+	for expr, want := range tests {
+		got, err := tool.Function(map[string]interface{}{"operation": "describe", "expression": expr})
+		if err != nil || got != want {
+			t.Errorf("describe(%q) = %q, %v; want %q", expr, got, err, want)
+		}
+	}
+}
+
+func TestCronToolErrors(t *testing.T) {
+	tool := NewCronToolWithClock(newFakeClock(time.Date(2024, 3, 15, 10, 7, 0, 0, time.UTC)))
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "next", "expression": "61 * * * *"}, "invalid cron expression"},
+		{map[string]interface{}{"operation": "next", "expression": "* * *"}, "invalid cron expression"},
+		{map[string]interface{}{"operation": "next", "expression": "0 0 30 2 *"}, "never fires"},
+		{map[string]interface{}{"operation": "next", "expression": "@hourly", "count": 11.0}, "'count' must be between 1 and 10"},
+		{map[string]interface{}{"operation": "explain", "expression": "@hourly"}, "unsupported operation"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("cron(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// NewDictionaryTool returns a define tool that looks words up in a local
+// dictionary file instead of letting the model invent definitions. The file
+// is read once, on first use, and is either a JSON object of word to
+// definition (.json) or a text file with one "word<TAB>definition" or
+// "word: definition" entry per line, where lines starting with # are comments.
+// Lookups are case-insensitive. A missing word is reported as a normal result
+// starting with "not found", not as an error.
+func NewDictionaryTool(path string) Tool {
+	var (
+		once    sync.Once
+		entries map[string]string
+		loadErr error
+	)
+	return Tool{
+		Name:        "define",
+		Description: "A tool that looks up the definition of a word in a local dictionary.",
+		Args:        map[string]string{"word": "string"},
+		Function: func(args map[string]interface{}) (string, error) {
+			word, ok := args["word"].(string)
+			if !ok || strings.TrimSpace(word) == "" {
+				return "", fmt.Errorf("missing 'word' argument")
+			}
+
+			once.Do(func() { entries, loadErr = loadDictionary(path) })
+			if loadErr != nil {
+				return "", loadErr
+			}
+
+			definition, ok := entries[strings.ToLower(strings.TrimSpace(word))]
+			if !ok {
+				return fmt.Sprintf("not found: %q is not in the dictionary", word), nil
+			}
+			return definition, nil
+		},
+	}
+}
+
+// loadDictionary reads the dictionary file at path, keyed by lowercase word.
+func loadDictionary(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary: %v", err)
+	}
+	defer f.Close()
+
+	entries := make(map[string]string)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var raw map[string]string
+		if err := json.NewDecoder(f).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("failed to parse dictionary: %v", err)
+		}
+		for word, definition := range raw {
+			entries[strings.ToLower(strings.TrimSpace(word))] = definition
+		}
+		return entries, nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sep := strings.IndexAny(line, "\t:")
+		if sep < 0 {
+			return nil, fmt.Errorf("dictionary line %d: expected 'word<TAB>definition' or 'word: definition'", lineNo)
+		}
+		word := strings.ToLower(strings.TrimSpace(line[:sep]))
+		entries[word] = strings.TrimSpace(line[sep+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %v", err)
+	}
+	return entries, nil
+}
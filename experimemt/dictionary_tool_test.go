@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDictionary(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDictionaryTool(t *testing.T) {
+	dictionaries := map[string]string{
+		"words.txt":  "# test dictionary\nserendipity\tfinding something good by chance\nLaconic: using few words\n",
+		"words.json": `{"Serendipity": "finding something good by chance", "laconic": "using few words"}`,
+	}
+	for name, content := range dictionaries {
+		tool := NewDictionaryTool(writeDictionary(t, name, content))
+		for word, want := range map[string]string{
+			"serendipity":   "finding something good by chance",
+			" SERENDIPITY ": "finding something good by chance",
+			"laconic":       "using few words",
+		} {
+			got, err := tool.Function(map[string]interface{}{"word": word})
+			if err != nil || got != want {
+				t.Errorf("%s: define(%q) = %q, %v; want %q", name, word, got, err, want)
+			}
+		}
+
+		got, err := tool.Function(map[string]interface{}{"word": "quixotic"})
+		if err != nil || !strings.HasPrefix(got, "not found") {
+			t.Errorf("%s: define(quixotic) = %q, %v; want a not found result", name, got, err)
+		}
+	}
+}
+
+func TestDictionaryToolErrors(t *testing.T) {
+	tool := NewDictionaryTool(filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := tool.Function(map[string]interface{}{"word": "x"}); err == nil {
+		t.Error("lookup in a missing dictionary succeeded")
+	}
+	if _, err := tool.Function(map[string]interface{}{"word": " "}); err == nil {
+		t.Error("lookup of a blank word succeeded")
+	}
+
+	tool = NewDictionaryTool(writeDictionary(t, "bad.txt", "no separator here\n"))
+	if _, err := tool.Function(map[string]interface{}{"word": "x"}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("lookup in a malformed dictionary = %v, want a line 1 error", err)
+	}
+}
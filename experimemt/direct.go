@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// directToolName matches the tool name of a direct command.
+var directToolName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// commandNumber matches unquoted direct command values taken as numbers. It
+// is stricter than strconv.ParseFloat, which also accepts "inf" and "nan".
+var commandNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// parseDirectCommand recognizes input of the form
+//
+//	!calculator operation=add num1=2 num2=3 note="two words"
+//
+// that calls a tool without going through the model. Values may be quoted
+// with single or double quotes; unquoted numbers and true/false become
+// numbers and booleans, as they would in a JSON tool call. It returns false if
+// input is not a well-formed direct command.
+func parseDirectCommand(input string) (*ToolInvocation, bool) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "!") || strings.HasPrefix(input, "! ") {
+		return nil, false
+	}
+	words, ok := splitCommandLine(input[1:])
+	if !ok || len(words) == 0 || !directToolName.MatchString(words[0].text) || words[0].quoted {
+		return nil, false
+	}
+
+	call := &ToolInvocation{Name: words[0].text, Args: make(map[string]interface{})}
+	for _, w := range words[1:] {
+		name, value, found := strings.Cut(w.text, "=")
+		if !found || name == "" || w.nameQuoted {
+			return nil, false
+		}
+		call.Args[name] = commandValue(value, w.quoted)
+	}
+	return call, true
+}
+
+// commandWord is one word of a direct command.
+type commandWord struct {
+	text       string
+	quoted     bool // some of the value was quoted, so it stays a string
+	nameQuoted bool // a quote appeared before the '='
+}
+
+// splitCommandLine splits s into words at unquoted whitespace, removing the
+// quotes. Inside double quotes, a backslash escapes the next character. It
+// returns false for an unterminated quote.
+func splitCommandLine(s string) ([]commandWord, bool) {
+	var words []commandWord
+	var cur strings.Builder
+	var w commandWord
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case quote != 0:
+			switch {
+			case c == quote:
+				quote = 0
+			case c == '\\' && quote == '"':
+				escaped = true
+			default:
+				cur.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inWord, w.quoted = c, true, true
+			if !strings.Contains(cur.String(), "=") {
+				w.nameQuoted = true
+			}
+		case unicode.IsSpace(c):
+			if inWord {
+				w.text = cur.String()
+				words = append(words, w)
+				cur.Reset()
+				w, inWord = commandWord{}, false
+			}
+		default:
+			cur.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, false
+	}
+	if inWord {
+		w.text = cur.String()
+		words = append(words, w)
+	}
+	return words, true
+}
+
+// commandValue converts an unquoted direct command value to a number or
+// boolean where it looks like one.
+func commandValue(value string, quoted bool) interface{} {
+	if quoted {
+		return value
+	}
+	if commandNumber.MatchString(value) {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
+
+// runDirectCommand calls the tool named by a direct command and makes its
+// result the answer, applying the same checks as a tool call from the model.
+func (a *Agent) runDirectCommand(ctx context.Context, historyFilePath string, call *ToolInvocation, result *RunResult) error {
+	history, err := a.GetConversationHistory(historyFilePath)
+	if err != nil {
+		return err
+	}
+	result.Trace = append(result.Trace, Step{Action: call})
+	step := &result.Trace[len(result.Trace)-1]
+
+	calls := make(map[string]int)
+	tool, refused := a.checkToolCall(ctx, call, calls)
+	if refused != nil {
+		step.Error = refused.Err
+		return step.Error
+	}
+	toolResult, err := a.dispatchToolCall(ctx, sessionID(ctx), 0, tool, call.Args, calls)
+	if err != nil {
+		step.Error = fmt.Errorf("tool execution failed: %v", err)
+		return step.Error
+	}
+
+	step.Observation = a.guardObservation(tool.formatObservation(toolResult.Observation))
+	if a.NormalizeObservations {
+		step.Observation = normalizeObservation(step.Observation)
+	}
+	a.emit(ctx, RunEvent{Kind: EventObservation, Step: 0, Tool: tool.Name, Text: step.Observation})
+	if callJSON, err := json.Marshal(call); err == nil {
+		history = a.AppendHistory(history, newHistoryEntry(EntryToolCall, string(callJSON)))
+	}
+	history = a.AppendHistory(history, newHistoryEntry(EntryObservation, step.Observation))
+	a.SaveConversationHistory(historyFilePath, history)
+
+	result.Answer = FinalAnswer{Text: step.Observation}
+	a.emit(ctx, RunEvent{Kind: EventFinalAnswer, Step: 0, Text: result.Answer.Text})
+	return nil
+}
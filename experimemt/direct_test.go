@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDirectCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *ToolInvocation
+	}{
+		{"!calculator operation=add num1=2 num2=3", &ToolInvocation{Name: "calculator", Args: map[string]interface{}{"operation": "add", "num1": 2.0, "num2": 3.0}}},
+		{"  !note text=\"two words\" tag='a b' ", &ToolInvocation{Name: "note", Args: map[string]interface{}{"text": "two words", "tag": "a b"}}},
+		{`!note text="say \"hi\"" id="42" n=-1.5e2 ok=true raw=inf`, &ToolInvocation{Name: "note", Args: map[string]interface{}{"text": `say "hi"`, "id": "42", "n": -150.0, "ok": true, "raw": "inf"}}},
+		{"!clock", &ToolInvocation{Name: "clock", Args: map[string]interface{}{}}},
+		{"!web-search q=go a=b=c", &ToolInvocation{Name: "web-search", Args: map[string]interface{}{"q": "go", "a": "b=c"}}},
+		{"what is 2+3?", nil},
+		{"! calculator", nil},
+		{"!", nil},
+		{"!2fast x=1", nil},
+		{`!"calc" x=1`, nil},
+		{"!calc operation add", nil},
+		{"!calc =1", nil},
+		{`!calc "op"=add`, nil},
+		{`!calc note="unterminated`, nil},
+	}
+	for _, tt := range tests {
+		got, ok := parseDirectCommand(tt.input)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDirectCommand(%q) = %+v, %v; want %+v", tt.input, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRunDirectCommand(t *testing.T) {
+	agent := ScriptedAgent(nil) // the model is never asked
+	agent.AddTool(Tool{Name: "calculator", Args: map[string]string{"operation": "string", "num1": "number", "num2": "number"},
+		Function: func(args map[string]interface{}) (string, error) {
+			return fmt.Sprint(args["num1"].(float64) + args["num2"].(float64)), nil
+		}})
+	path := historyPath(t)
+
+	got, err := agent.Run(context.Background(), path, "!calculator operation=add num1=2 num2=3", nil)
+	if err != nil || got != "5" {
+		t.Fatalf("Run = %q, %v; want 5", got, err)
+	}
+	if n := len(agent.Client.(*ScriptedClient).Prompts()); n != 0 {
+		t.Errorf("model called %d times for a direct command", n)
+	}
+	history, err := agent.GetConversationHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(history, `"name":"calculator"`) || !strings.Contains(history, "Observation: 5") {
+		t.Errorf("history does not record the call and its result:\n%s", history)
+	}
+
+	if _, err := agent.Run(context.Background(), path, "!nosuchtool x=1", nil); err == nil || !strings.Contains(err.Error(), "unknown tool: nosuchtool") {
+		t.Errorf("Run error = %v, want the unknown tool reported", err)
+	}
+}
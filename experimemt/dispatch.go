@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// refusedCall is why checkToolCall turned a tool call down: Err for the user,
+// Observation for the model. Corrective refusals, such as missing arguments,
+// draw on the run's retry budget.
+type refusedCall struct {
+	Err         error
+	Observation string
+	Corrective  bool
+}
+
+func (r *refusedCall) Error() string { return r.Err.Error() }
+
+// checkToolCall looks up the tool named by call and applies the checks every
+// call goes through before it is made, whether the model or the user asked
+// for it: the tool must exist and be available, be under its MaxCallsPerRun
+// (counted in calls), get all of its required arguments, and pass the
+// BeforeToolCall hook and any confirmation. With CanonicalizeArgs set,
+// call.Args is canonicalized first.
+func (a *Agent) checkToolCall(ctx context.Context, call *ToolInvocation, calls map[string]int) (Tool, *refusedCall) {
+	logf := a.runLogger(ctx)
+	tool, ok := a.Tools[call.Name]
+	if !ok {
+		err := fmt.Errorf("unknown tool: %s", call.Name)
+		return tool, &refusedCall{Err: err, Observation: fmt.Sprintf("Invalid tool call: %v. Use one of the tools listed above.", err), Corrective: true}
+	}
+	if a.CanonicalizeArgs {
+		call.Args = canonicalizeArgs(call.Args, tool.Args)
+	}
+
+	if !tool.IsAvailable() {
+		logf("--- Tool %s is currently unavailable ---", tool.Name)
+		return tool, &refusedCall{
+			Err:         fmt.Errorf("tool %s is not available right now", tool.Name),
+			Observation: fmt.Sprintf("Tool %s is not available right now. Use another tool or give your Final Answer.", tool.Name),
+		}
+	}
+	if tool.MaxCallsPerRun > 0 && calls[tool.Name] >= tool.MaxCallsPerRun {
+		logf("--- Tool %s has reached its limit of %d calls ---", tool.Name, tool.MaxCallsPerRun)
+		return tool, &refusedCall{
+			Err:         fmt.Errorf("tool %s has reached its limit of %d calls", tool.Name, tool.MaxCallsPerRun),
+			Observation: fmt.Sprintf("Tool %s has reached its call limit for this task. Use another tool or give your Final Answer.", tool.Name),
+		}
+	}
+	if err := tool.ValidateArgs(call.Args); err != nil {
+		// Let the model fill in what it forgot rather than call the tool
+		// with nil values.
+		logf("--- Tool call %s rejected: %v ---", tool.Name, err)
+		return tool, &refusedCall{
+			Err:         err,
+			Observation: fmt.Sprintf("Invalid tool call: %v. Call the tool again with all of them.", err),
+			Corrective:  true,
+		}
+	}
+
+	var err error
+	if a.BeforeToolCall != nil {
+		err = a.BeforeToolCall(tool.Name, call.Args)
+	}
+	if err == nil && tool.RequiresConfirmation && (a.ConfirmToolCall == nil || !a.ConfirmToolCall(tool.Name, call.Args)) {
+		err = fmt.Errorf("the user did not confirm the %s call", tool.Name)
+	}
+	if err != nil {
+		logf("--- Tool call %s blocked: %v ---", tool.Name, err)
+		return tool, &refusedCall{Err: err, Observation: fmt.Sprintf("Tool call blocked: %v", err)}
+	}
+	return tool, nil
+}
+
+// dispatchToolCall makes a call that passed checkToolCall as step of the run,
+// counting it in calls, tracing it in a "tool.call" span and auditing it.
+func (a *Agent) dispatchToolCall(ctx context.Context, session string, step int, tool Tool, args map[string]interface{}, calls map[string]int) (ToolResult, error) {
+	a.runLogger(ctx)("--- Calling tool: %s with arguments: %v ---", tool.Name, args)
+	a.emit(ctx, RunEvent{Kind: EventToolCall, Step: step, Tool: tool.Name})
+	calls[tool.Name]++
+	_, span := a.tracer().Start(ctx, "tool.call", trace.WithAttributes(attrToolName.String(tool.Name), attrStep.Int(step)))
+	started := time.Now()
+	result, err := a.callTool(tool, args)
+	endSpan(span, err)
+	a.auditToolCall(session, tool.Name, args, started, err)
+	return result, err
+}
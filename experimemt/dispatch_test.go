@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoTool returns a tool declaring from and to, with to optional, that
+// reports the arguments it got.
+func echoTool(called *int) Tool {
+	return Tool{
+		Name:         "echo",
+		Args:         map[string]string{"from": "string", "to": "string"},
+		OptionalArgs: []string{"to"},
+		Function: func(args map[string]interface{}) (string, error) {
+			*called++
+			return "from " + args["from"].(string), nil
+		},
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	tool := Tool{Name: "fx", Args: map[string]string{"amount": "", "from": "", "to": ""}, OptionalArgs: []string{"amount"}}
+	if err := tool.ValidateArgs(map[string]interface{}{"from": "USD", "to": "EUR"}); err != nil {
+		t.Errorf("ValidateArgs with all required args = %v", err)
+	}
+	err := tool.ValidateArgs(map[string]interface{}{"amount": 1.0, "from": nil})
+	if err == nil || !strings.Contains(err.Error(), "'from', 'to'") {
+		t.Errorf("ValidateArgs error = %v, want both missing args listed", err)
+	}
+}
+
+func TestRunReportsMissingArgs(t *testing.T) {
+	calls := 0
+	agent := ScriptedAgent([]string{
+		`{"name": "echo", "arguments": {"to": "b"}}`,
+		`{"name": "echo", "arguments": {"from": "a"}}`,
+		"Final Answer: done",
+	})
+	agent.AddTool(echoTool(&calls))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("tool called %d times, want once, after the model fixed its call", calls)
+	}
+	if obs := result.Trace[0].Observation; !strings.Contains(obs, "missing required arguments for echo: 'from'") {
+		t.Errorf("first observation = %q, want the missing argument named", obs)
+	}
+	if obs := result.Trace[1].Observation; obs != "from a" {
+		t.Errorf("second observation = %q", obs)
+	}
+}
+
+func TestDirectCommandUsesToolCallChecks(t *testing.T) {
+	calls := 0
+	agent := ScriptedAgent(nil) // direct commands never reach the model
+	agent.CanonicalizeArgs = true
+	agent.AddTool(echoTool(&calls))
+
+	if _, err := agent.Run(context.Background(), historyPath(t), "!echo to=b", nil); err == nil || !strings.Contains(err.Error(), "'from'") {
+		t.Fatalf("Run error = %v, want the missing argument reported", err)
+	}
+	if calls != 0 {
+		t.Fatal("tool was called without its required argument")
+	}
+
+	// Keys are canonicalized just as for the model's calls.
+	answer, err := agent.Run(context.Background(), historyPath(t), "!echo FROM=a", nil)
+	if err != nil || answer != "from a" {
+		t.Fatalf("Run = %q, %v; want the canonicalized call to succeed", answer, err)
+	}
+}
+
+func TestToolAvailability(t *testing.T) {
+	open := false
+	calls := 0
+	deploy := echoTool(&calls)
+	deploy.Name = "deploy"
+	deploy.Available = func() bool { return open }
+
+	agent := ScriptedAgent([]string{
+		`{"name": "deploy", "arguments": {"from": "main"}}`,
+		"Final Answer: could not deploy",
+		`{"name": "deploy", "arguments": {"from": "main"}}`,
+		"Final Answer: deployed",
+	})
+	agent.AddTool(deploy)
+	agent.AddTool(ScriptedTool("status"))
+
+	if prompt := agent.GetToolsPrompt(); strings.Contains(prompt, "deploy") || !strings.Contains(prompt, "Name: status") {
+		t.Errorf("tools prompt while unavailable:\n%s", prompt)
+	}
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "ship it", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 || result.Trace[0].Observation != "Tool deploy is not available right now. Use another tool or give your Final Answer." {
+		t.Errorf("unavailable tool: %d calls, observation %q", calls, result.Trace[0].Observation)
+	}
+
+	open = true
+	if prompt := agent.GetToolsPrompt(); !strings.Contains(prompt, "Name: deploy") {
+		t.Errorf("tools prompt while available:\n%s", prompt)
+	}
+	if got, err := agent.Run(context.Background(), historyPath(t), "ship it", nil); err != nil || got != "deployed" || calls != 1 {
+		t.Errorf("Run = %q, %v with %d calls; want the tool used once available", got, err, calls)
+	}
+}
+
+func TestBeforeToolCallHook(t *testing.T) {
+	echoCalls, deleteCalls := 0, 0
+	agent := ScriptedAgent([]string{
+		`{"name": "delete", "arguments": {"path": "/etc"}}`,
+		`{"name": "echo", "arguments": {"from": "a"}}`,
+		"Final Answer: done",
+	})
+	agent.AddTool(echoTool(&echoCalls))
+	agent.AddTool(Tool{Name: "delete", Args: map[string]string{"path": "string"}, Function: func(map[string]interface{}) (string, error) {
+		deleteCalls++
+		return "deleted", nil
+	}})
+	var audited []string
+	agent.BeforeToolCall = func(name string, args map[string]interface{}) error {
+		audited = append(audited, name)
+		if name == "delete" {
+			return fmt.Errorf("policy forbids deleting %v", args["path"])
+		}
+		return nil
+	}
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if deleteCalls != 0 || echoCalls != 1 {
+		t.Errorf("delete called %d times, echo %d times; want 0 and 1", deleteCalls, echoCalls)
+	}
+	if strings.Join(audited, ",") != "delete,echo" {
+		t.Errorf("hook saw %v, want every call", audited)
+	}
+	if obs := result.Trace[0].Observation; obs != "Tool call blocked: policy forbids deleting /etc" {
+		t.Errorf("denied call observation = %q, want the hook's error", obs)
+	}
+	if obs := result.Trace[1].Observation; obs != "from a" {
+		t.Errorf("allowed call observation = %q", obs)
+	}
+}
+
+func TestPerToolTimeout(t *testing.T) {
+	sleepy := func(name string, timeout time.Duration) Tool {
+		return Tool{Name: name, Timeout: timeout, Function: func(map[string]interface{}) (string, error) {
+			time.Sleep(100 * time.Millisecond)
+			return name + " done", nil
+		}}
+	}
+	agent := ScriptedAgent([]string{
+		`{"name": "search", "arguments": {}}`,
+		`{"name": "lookup", "arguments": {}}`,
+		"Final Answer: done",
+	})
+	agent.ToolTimeout = 20 * time.Millisecond
+	agent.AddTool(sleepy("search", time.Second)) // its own timeout wins
+	agent.AddTool(sleepy("lookup", 0))           // falls back to ToolTimeout
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if obs := result.Trace[0].Observation; obs != "search done" {
+		t.Errorf("search observation = %q, want it to finish within its own timeout", obs)
+	}
+	if obs := result.Trace[1].Observation; !strings.Contains(obs, "tool lookup timed out after 20ms") {
+		t.Errorf("lookup observation = %q, want the agent's timeout", obs)
+	}
+}
+
+func TestCanonicalizeArgs(t *testing.T) {
+	declared := map[string]string{"operation": "string", "num1": "number"}
+	got := canonicalizeArgs(map[string]interface{}{" Operation": " add ", "NUM1": 2.0, "Extra": " x "}, declared)
+	want := map[string]interface{}{"operation": "add", "num1": 2.0, "Extra": "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("canonicalizeArgs = %v, want %v", got, want)
+	}
+}
+
+func TestRunCanonicalizesArgs(t *testing.T) {
+	for _, canonicalize := range []bool{true, false} {
+		var got interface{}
+		agent := ScriptedAgent([]string{`{"name": "op", "arguments": {"Operation": " add "}}`, "Final Answer: done"})
+		agent.CanonicalizeArgs = canonicalize
+		agent.AddTool(Tool{Name: "op", Args: map[string]string{"operation": "string"}, Function: func(args map[string]interface{}) (string, error) {
+			got = args["operation"]
+			return "ok", nil
+		}})
+
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if canonicalize && got != "add" {
+			t.Errorf("tool got operation %#v, want \"add\"", got)
+		}
+		if !canonicalize && !strings.Contains(result.Trace[0].Observation, "missing required arguments for op: 'operation'") {
+			t.Errorf("without canonicalization, observation = %q, want the argument missing", result.Trace[0].Observation)
+		}
+	}
+}
+
+func TestMaxCallsPerRun(t *testing.T) {
+	searches, echoes := 0, 0
+	agent := ScriptedAgent([]string{
+		`{"name": "web_search", "arguments": {}}`,
+		`{"name": "web_search", "arguments": {}}`,
+		`{"name": "web_search", "arguments": {}}`,
+		`{"name": "echo", "arguments": {"from": "a"}}`,
+		"Final Answer: done",
+		`{"name": "web_search", "arguments": {}}`,
+		"Final Answer: searched again",
+	})
+	agent.AddTool(Tool{Name: "web_search", MaxCallsPerRun: 2, Function: func(map[string]interface{}) (string, error) {
+		searches++
+		return "results", nil
+	}})
+	agent.AddTool(echoTool(&echoes))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if searches != 2 || echoes != 1 {
+		t.Errorf("web_search called %d times, echo %d times; want 2 and 1", searches, echoes)
+	}
+	if obs := result.Trace[2].Observation; obs != "Tool web_search has reached its call limit for this task. Use another tool or give your Final Answer." {
+		t.Errorf("over-limit observation = %q", obs)
+	}
+	if obs := result.Trace[3].Observation; obs != "from a" {
+		t.Errorf("other tool observation = %q, want it still callable", obs)
+	}
+
+	// The count starts afresh with each run.
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil || searches != 3 {
+		t.Errorf("second run: %v with %d searches, want the tool callable again", err, searches)
+	}
+}
+
+func TestFormatObservation(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`{"name": "search", "arguments": {}}`,
+		`{"name": "broken", "arguments": {}}`,
+		`{"name": "echo", "arguments": {"from": "a"}}`,
+		"Final Answer: done",
+	})
+	numbered := func(result string) string {
+		lines := strings.Split(result, "\n")
+		for i, line := range lines {
+			lines[i] = fmt.Sprintf("%d. %s", i+1, line)
+		}
+		return strings.Join(lines, "\n")
+	}
+	search := ScriptedTool("search", "go.dev\npkg.go.dev")
+	search.FormatObservation = numbered
+	agent.AddTool(search)
+	agent.AddTool(Tool{Name: "broken", FormatObservation: numbered, Function: func(map[string]interface{}) (string, error) {
+		return "", fmt.Errorf("index offline")
+	}})
+	calls := 0
+	agent.AddTool(echoTool(&calls)) // no formatter: the result is left as is
+	path := historyPath(t)
+
+	result, err := agent.RunWithTrace(context.Background(), path, "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs := result.Trace[0].Observation; obs != "1. go.dev\n2. pkg.go.dev" {
+		t.Errorf("formatted observation = %q", obs)
+	}
+	if obs := result.Trace[1].Observation; obs != "Tool execution failed with error: index offline" {
+		t.Errorf("failed call observation = %q, want errors left unformatted", obs)
+	}
+	if obs := result.Trace[2].Observation; obs != "from a" {
+		t.Errorf("unformatted observation = %q", obs)
+	}
+	history, err := agent.GetConversationHistory(path)
+	if err != nil || !strings.Contains(history, "1. go.dev\n2. pkg.go.dev") {
+		t.Errorf("history does not hold the formatted observation (%v):\n%s", err, history)
+	}
+	if prompts := agent.Client.(*ScriptedClient).Prompts(); !strings.Contains(prompts[1], "1. go.dev\n2. pkg.go.dev") {
+		t.Errorf("next prompt does not show the formatted observation:\n%s", prompts[1])
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// dnsTimeout bounds a single dns tool lookup.
+const dnsTimeout = 5 * time.Second
+
+// dnsRecordTypes are the record types the dns tool looks up.
+var dnsRecordTypes = map[string]bool{"A": true, "AAAA": true, "MX": true, "TXT": true, "CNAME": true}
+
+// dnsResult is the dns tool's output. Status is "NXDOMAIN" when the name has
+// no records of the requested type, so the model can tell a missing record
+// apart from a failed lookup.
+type dnsResult struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Status  string   `json:"status"`
+	Records []string `json:"records"`
+}
+
+// NewDNSTool returns a tool that looks up A, AAAA, MX, TXT and CNAME records
+// with the system resolver.
+func NewDNSTool() Tool {
+	return NewDNSToolWithResolver(net.DefaultResolver)
+}
+
+// NewDNSToolWithResolver is NewDNSTool with a custom resolver, e.g. one that
+// queries a particular DNS server.
+func NewDNSToolWithResolver(resolver *net.Resolver) Tool {
+	return Tool{
+		Name:        "dns",
+		Description: "A tool that resolves DNS records for a domain name. Supported types are A, AAAA, MX, TXT and CNAME.",
+		Args: map[string]string{
+			"name": "string (domain name, e.g. 'example.com')",
+			"type": "string (e.g., 'A', 'AAAA', 'MX', 'TXT', 'CNAME'; defaults to 'A')",
+		},
+		OptionalArgs: []string{"type"},
+		Function: func(args map[string]interface{}) (string, error) {
+			name, ok := args["name"].(string)
+			if !ok || strings.TrimSpace(name) == "" {
+				return "", fmt.Errorf("missing 'name' argument")
+			}
+			name = strings.TrimSpace(name)
+			recordType := "A"
+			if t, ok := args["type"].(string); ok && strings.TrimSpace(t) != "" {
+				recordType = strings.ToUpper(strings.TrimSpace(t))
+			}
+			if !dnsRecordTypes[recordType] {
+				return "", fmt.Errorf("unsupported record type: %s", recordType)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+			defer cancel()
+			records, err := lookupDNS(ctx, resolver, name, recordType)
+			result := dnsResult{Name: name, Type: recordType, Status: "NOERROR", Records: records}
+			var dnsErr *net.DNSError
+			switch {
+			case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+				result.Status = "NXDOMAIN"
+				result.Records = []string{}
+			case err != nil:
+				return "", fmt.Errorf("DNS lookup failed: %v", err)
+			}
+
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// lookupDNS returns the records of recordType for name in compact text form:
+// addresses, "preference host" for MX, and the strings themselves for TXT.
+func lookupDNS(ctx context.Context, resolver *net.Resolver, name, recordType string) ([]string, error) {
+	var records []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = txts
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = []string{cname}
+	default:
+		return nil, fmt.Errorf("unsupported record type: %s", recordType)
+	}
+	return records, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNSServer answers DNS queries over UDP for example.test and
+// www.example.test, a CNAME for it, with NXDOMAIN for missing.test and
+// SERVFAIL for anything else. It returns a resolver that queries it.
+func fakeDNSServer(t *testing.T) *net.Resolver {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			reply := dnsReply(query)
+			if packed, err := reply.Pack(); err == nil {
+				conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+
+	return &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", conn.LocalAddr().String())
+	}}
+}
+
+// dnsReply builds fakeDNSServer's answer to query.
+func dnsReply(query dnsmessage.Message) dnsmessage.Message {
+	q := query.Questions[0]
+	reply := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true, RecursionAvailable: true},
+		Questions: query.Questions,
+	}
+	header := func(name string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET, TTL: 60}
+	}
+	name := q.Name.String()
+	switch name {
+	case "www.example.test.":
+		reply.Answers = append(reply.Answers, dnsmessage.Resource{Header: header(name, dnsmessage.TypeCNAME), Body: &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.test.")}})
+		name = "example.test."
+		fallthrough
+	case "example.test.":
+		switch q.Type {
+		case dnsmessage.TypeA:
+			for _, ip := range [][4]byte{{192, 0, 2, 1}, {192, 0, 2, 2}} {
+				reply.Answers = append(reply.Answers, dnsmessage.Resource{Header: header(name, q.Type), Body: &dnsmessage.AResource{A: ip}})
+			}
+		case dnsmessage.TypeAAAA:
+			reply.Answers = append(reply.Answers, dnsmessage.Resource{Header: header(name, q.Type), Body: &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}})
+		case dnsmessage.TypeMX:
+			reply.Answers = append(reply.Answers, dnsmessage.Resource{Header: header(name, q.Type), Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.test.")}})
+		case dnsmessage.TypeTXT:
+			reply.Answers = append(reply.Answers, dnsmessage.Resource{Header: header(name, q.Type), Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}})
+		}
+	case "missing.test.":
+		reply.RCode = dnsmessage.RCodeNameError
+	default:
+		reply.RCode = dnsmessage.RCodeServerFailure
+	}
+	return reply
+}
+
+func TestDNSTool(t *testing.T) {
+	tool := NewDNSToolWithResolver(fakeDNSServer(t))
+	tests := []struct {
+		name, typ, want string
+	}{
+		{"example.test", "", `{"name":"example.test","type":"A","status":"NOERROR","records":["192.0.2.1","192.0.2.2"]}`},
+		{"example.test", "aaaa", `{"name":"example.test","type":"AAAA","status":"NOERROR","records":["2001:db8::1"]}`},
+		{"example.test", "MX", `{"name":"example.test","type":"MX","status":"NOERROR","records":["10 mail.example.test."]}`},
+		{"example.test", "TXT", `{"name":"example.test","type":"TXT","status":"NOERROR","records":["v=spf1 -all"]}`},
+		{"www.example.test", "CNAME", `{"name":"www.example.test","type":"CNAME","status":"NOERROR","records":["example.test."]}`},
+		{"missing.test", "A", `{"name":"missing.test","type":"A","status":"NXDOMAIN","records":[]}`},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"name": tt.name}
+		if tt.typ != "" {
+			args["type"] = tt.typ
+		}
+		got, err := tool.Function(args)
+		if err != nil || got != tt.want {
+			t.Errorf("dns %s %s = %s, %v; want %s", tt.name, tt.typ, got, err, tt.want)
+		}
+	}
+}
+
+func TestDNSToolErrors(t *testing.T) {
+	tool := NewDNSToolWithResolver(fakeDNSServer(t))
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"name": "broken.test"}, "DNS lookup failed"},
+		{map[string]interface{}{"name": "example.test", "type": "SRV"}, "unsupported record type: SRV"},
+		{map[string]interface{}{"name": " "}, "missing 'name' argument"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
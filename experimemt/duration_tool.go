@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// NewDurationTool returns a tool for duration arithmetic, which models routinely
+// get wrong. It supports three operations:
+//
+//   - parse: converts a Go duration string such as "1h30m" to seconds
+//   - add: adds two duration strings and returns the total as a duration string
+//   - humanize: renders a number of seconds as e.g. "1 hour 30 minutes"
+func NewDurationTool() Tool {
+	return Tool{
+		Name:        "duration",
+		Description: "A tool that parses, adds and humanizes time durations. Durations use Go syntax such as '1h30m' or '45s'.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'parse', 'add', 'humanize')",
+			"duration":  "string (duration for 'parse' and first operand for 'add', e.g. '1h30m')",
+			"other":     "string (second operand for 'add')",
+			"seconds":   "number (for 'humanize')",
+		},
+		OptionalArgs: []string{"duration", "other", "seconds"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+
+			switch op {
+			case "parse":
+				d, err := durationArg(args, "duration")
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%g", d.Seconds()), nil
+			case "add":
+				d1, err := durationArg(args, "duration")
+				if err != nil {
+					return "", err
+				}
+				d2, err := durationArg(args, "other")
+				if err != nil {
+					return "", err
+				}
+				return (d1 + d2).String(), nil
+			case "humanize":
+				secs, ok := args["seconds"].(float64)
+				if !ok {
+					return "", fmt.Errorf("missing or invalid 'seconds' argument")
+				}
+				if math.IsNaN(secs) || math.Abs(secs) > maxDurationSeconds {
+					return "", fmt.Errorf("'seconds' must be between -%.0f and %.0f (about 292 years)", maxDurationSeconds, maxDurationSeconds)
+				}
+				return humanizeDuration(time.Duration(secs * float64(time.Second))), nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// maxDurationSeconds is the longest span, in whole seconds, a time.Duration
+// can hold.
+const maxDurationSeconds = float64(math.MaxInt64 / int64(time.Second))
+
+// durationArg reads and parses the duration string stored under key.
+func durationArg(args map[string]interface{}, key string) (time.Duration, error) {
+	s, ok := args[key].(string)
+	if !ok {
+		return 0, fmt.Errorf("missing '%s' argument", key)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q for '%s': %v", s, key, err)
+	}
+	return d, nil
+}
+
+// humanizeDuration renders d as a list of non-zero units, e.g.
+// "1 day 2 hours 5 seconds". Sub-second remainders are dropped.
+func humanizeDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	total := int64(math.Round(d.Seconds()))
+	if total == 0 {
+		return "0 seconds"
+	}
+
+	units := []struct {
+		name string
+		secs int64
+	}{
+		{"day", 86400},
+		{"hour", 3600},
+		{"minute", 60},
+		{"second", 1},
+	}
+
+	var parts []string
+	for _, u := range units {
+		n := total / u.secs
+		total %= u.secs
+		if n == 0 {
+			continue
+		}
+		name := u.name
+		if n != 1 {
+			name += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, name))
+	}
+	return sign + strings.Join(parts, " ")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDurationTool(t *testing.T) {
+	tool := NewDurationTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "parse", "duration": "1h30m"}, "5400"},
+		{map[string]interface{}{"operation": "parse", "duration": " 1.5s "}, "1.5"},
+		{map[string]interface{}{"operation": "add", "duration": "1h45m", "other": "30m"}, "2h15m0s"},
+		{map[string]interface{}{"operation": "add", "duration": "1h", "other": "-90m"}, "-30m0s"},
+		{map[string]interface{}{"operation": "humanize", "seconds": 5400.0}, "1 hour 30 minutes"},
+		{map[string]interface{}{"operation": "humanize", "seconds": 90061.0}, "1 day 1 hour 1 minute 1 second"},
+		{map[string]interface{}{"operation": "humanize", "seconds": 172800.0}, "2 days"},
+		{map[string]interface{}{"operation": "humanize", "seconds": -61.0}, "-1 minute 1 second"},
+		{map[string]interface{}{"operation": "humanize", "seconds": 0.2}, "0 seconds"},
+		{map[string]interface{}{"operation": "humanize", "seconds": -9223372036.0}, "-106751 days 23 hours 47 minutes 16 seconds"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("duration(%v) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestDurationToolErrors(t *testing.T) {
+	tool := NewDurationTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "parse", "duration": "90 minutes"}, `invalid duration "90 minutes" for 'duration'`},
+		{map[string]interface{}{"operation": "add", "duration": "1h"}, "missing 'other' argument"},
+		{map[string]interface{}{"operation": "humanize", "seconds": "60"}, "missing or invalid 'seconds' argument"},
+		{map[string]interface{}{"operation": "humanize", "seconds": 1e10}, "'seconds' must be between -9223372036 and 9223372036 (about 292 years)"},
+		{map[string]interface{}{"operation": "humanize", "seconds": -1e10}, "'seconds' must be between -9223372036 and 9223372036 (about 292 years)"},
+		{map[string]interface{}{"operation": "multiply"}, "unsupported operation: multiply"},
+		{map[string]interface{}{}, "missing 'operation' argument"},
+	}
+	for _, tt := range tests {
+		_, err := tool.Function(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("duration(%v) error = %v, want one containing %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ResultEncoder renders a run's outcome in a caller-chosen representation.
+type ResultEncoder interface {
+	EncodeResult(w io.Writer, result *RunResult) error
+}
+
+// ResultEncoderFunc adapts a function to the ResultEncoder interface.
+type ResultEncoderFunc func(w io.Writer, result *RunResult) error
+
+// EncodeResult calls f(w, result).
+func (f ResultEncoderFunc) EncodeResult(w io.Writer, result *RunResult) error {
+	return f(w, result)
+}
+
+// TextEncoder writes the final answer as plain text, optionally preceded by
+// the trace of tool calls and observations.
+type TextEncoder struct {
+	ShowTrace bool
+}
+
+// EncodeResult implements ResultEncoder.
+func (e TextEncoder) EncodeResult(w io.Writer, result *RunResult) error {
+	var sb strings.Builder
+	if e.ShowTrace {
+		for i, step := range result.Trace {
+			if step.Action != nil {
+				args, _ := json.Marshal(step.Action.Args)
+				fmt.Fprintf(&sb, "Step %d: %s %s\n", i+1, step.Action.Name, args)
+			}
+			if step.Observation != "" {
+				fmt.Fprintf(&sb, "  -> %s\n", step.Observation)
+			}
+			if step.Error != nil {
+				fmt.Fprintf(&sb, "Step %d stopped: %v\n", i+1, step.Error)
+			}
+		}
+	}
+	sb.WriteString(result.Answer.Text)
+	sb.WriteString("\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// JSONEncoder writes the result as a JSON object with the answer, its
+// citations, the low-confidence flag and every step of the trace.
+type JSONEncoder struct {
+	Indent string // e.g. "  "; empty writes compact JSON
+}
+
+// jsonStep is the JSON form of a Step; errors become their message.
+type jsonStep struct {
+	Response    string          `json:"response,omitempty"`
+	Action      *ToolInvocation `json:"action,omitempty"`
+	Observation string          `json:"observation,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// EncodeResult implements ResultEncoder.
+func (e JSONEncoder) EncodeResult(w io.Writer, result *RunResult) error {
+	out := struct {
+		Answer        string     `json:"answer"`
+		Citations     []int      `json:"citations"`
+		LowConfidence bool       `json:"low_confidence"`
+		Steps         []jsonStep `json:"steps"`
+	}{
+		Answer:        result.Answer.Text,
+		Citations:     result.Answer.Citations,
+		LowConfidence: result.LowConfidence,
+		Steps:         make([]jsonStep, 0, len(result.Trace)),
+	}
+	if out.Citations == nil {
+		out.Citations = []int{}
+	}
+	for _, step := range result.Trace {
+		js := jsonStep{Response: step.Response, Action: step.Action, Observation: step.Observation}
+		if step.Error != nil {
+			js.Error = step.Error.Error()
+		}
+		out.Steps = append(out.Steps, js)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", e.Indent)
+	return enc.Encode(out)
+}
+
+// EncodeResult renders result with the agent's ResultEncoder, or TextEncoder
+// when none is set.
+func (a *Agent) EncodeResult(w io.Writer, result *RunResult) error {
+	encoder := a.ResultEncoder
+	if encoder == nil {
+		encoder = TextEncoder{}
+	}
+	return encoder.EncodeResult(w, result)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func sampleResult() *RunResult {
+	return &RunResult{
+		Answer: FinalAnswer{Text: "It is 42.", Citations: []int{0}},
+		Trace: []Step{
+			{Response: "Action: ...", Action: &ToolInvocation{Name: "lookup", Args: map[string]interface{}{"q": "answer"}}, Observation: "42"},
+			{Response: "Final Answer: It is 42. [obs:1]"},
+		},
+	}
+}
+
+func TestTextEncoder(t *testing.T) {
+	var sb strings.Builder
+	if err := (TextEncoder{}).EncodeResult(&sb, sampleResult()); err != nil || sb.String() != "It is 42.\n" {
+		t.Errorf("TextEncoder = %q, %v", sb.String(), err)
+	}
+
+	sb.Reset()
+	result := sampleResult()
+	result.Trace = append(result.Trace, Step{Error: errors.New("stopped")})
+	want := "Step 1: lookup {\"q\":\"answer\"}\n  -> 42\nStep 3 stopped: stopped\nIt is 42.\n"
+	if err := (TextEncoder{ShowTrace: true}).EncodeResult(&sb, result); err != nil || sb.String() != want {
+		t.Errorf("TextEncoder with trace = %q, %v; want %q", sb.String(), err, want)
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var sb strings.Builder
+	result := sampleResult()
+	result.Trace[1].Error = errors.New("boom")
+	if err := (JSONEncoder{}).EncodeResult(&sb, result); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"answer":"It is 42.","citations":[0],"low_confidence":false,"steps":[` +
+		`{"response":"Action: ...","action":{"name":"lookup","arguments":{"q":"answer"}},"observation":"42"},` +
+		`{"response":"Final Answer: It is 42. [obs:1]","error":"boom"}]}` + "\n"
+	if sb.String() != want {
+		t.Errorf("JSONEncoder =\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	sb.Reset()
+	(JSONEncoder{}).EncodeResult(&sb, &RunResult{})
+	if want := `{"answer":"","citations":[],"low_confidence":false,"steps":[]}` + "\n"; sb.String() != want {
+		t.Errorf("JSONEncoder of an empty result = %s, want %s", sb.String(), want)
+	}
+}
+
+func TestAgentResultEncoder(t *testing.T) {
+	agent := NewAgent("http://unused.invalid", "m")
+	var sb strings.Builder
+	agent.EncodeResult(&sb, sampleResult())
+	if sb.String() != "It is 42.\n" {
+		t.Errorf("default encoder = %q, want plain text", sb.String())
+	}
+
+	agent.ResultEncoder = ResultEncoderFunc(func(w io.Writer, result *RunResult) error {
+		_, err := fmt.Fprintf(w, "<answer steps=%d cites=%v>%s</answer>", len(result.Trace), result.Answer.Citations, result.Answer.Text)
+		return err
+	})
+	sb.Reset()
+	if err := agent.EncodeResult(&sb, sampleResult()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<answer steps=2 cites=[0]>It is 42.</answer>"; sb.String() != want {
+		t.Errorf("custom encoder = %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExportMarkdown renders a conversation as Markdown for sharing. Each entry gets
+// a heading, tool calls and observations are fenced as code, and the last
+// assistant message is highlighted as the final answer.
+func ExportMarkdown(history []HistoryEntry) string {
+	finalIdx := -1
+	for i, entry := range history {
+		if entry.Type == EntryAssistant {
+			finalIdx = i
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Conversation\n")
+	for i, entry := range history {
+		switch {
+		case i == finalIdx:
+			sb.WriteString("\n## Final Answer\n\n")
+			for _, line := range strings.Split(entry.Content, "\n") {
+				sb.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		case entry.Type == EntryToolCall:
+			sb.WriteString("\n## Tool Call\n\n")
+			writeFenced(&sb, "json", entry.Content)
+		case entry.Type == EntryObservation:
+			sb.WriteString("\n## Observation\n\n")
+			writeFenced(&sb, "", entry.Content)
+		default:
+			fmt.Fprintf(&sb, "\n## %s\n\n%s\n", entryHeading(entry.Type), entry.Content)
+		}
+	}
+	return sb.String()
+}
+
+// writeFenced writes content as a fenced code block, lengthening the fence if
+// the content itself contains backticks.
+func writeFenced(sb *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(sb, "%s%s\n%s\n%s\n", fence, lang, content, fence)
+}
+
+// entryHeading returns the heading used for entries of the given type.
+func entryHeading(entryType string) string {
+	if entryType == "" {
+		return "Message"
+	}
+	return strings.ToUpper(entryType[:1]) + entryType[1:]
+}
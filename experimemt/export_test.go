@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestExportMarkdown(t *testing.T) {
+	history := []HistoryEntry{
+		newHistoryEntry(EntryUser, "What is 2+3?"),
+		newHistoryEntry(EntryThought, "Use the calculator."),
+		newHistoryEntry(EntryToolCall, `{"name":"calculator","arguments":{"operation":"add","num1":2,"num2":3}}`),
+		newHistoryEntry(EntryObservation, "5"),
+		newHistoryEntry(EntryAssistant, "2 + 3 is 5.\n\nAnything else?"),
+	}
+	want := "# Conversation\n" +
+		"\n## User\n\nWhat is 2+3?\n" +
+		"\n## Thought\n\nUse the calculator.\n" +
+		"\n## Tool Call\n\n```json\n{\"name\":\"calculator\",\"arguments\":{\"operation\":\"add\",\"num1\":2,\"num2\":3}}\n```\n" +
+		"\n## Observation\n\n```\n5\n```\n" +
+		"\n## Final Answer\n\n> 2 + 3 is 5.\n>\n> Anything else?\n"
+	if got := ExportMarkdown(history); got != want {
+		t.Errorf("ExportMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportMarkdownHighlightsOnlyLastAnswer(t *testing.T) {
+	history := []HistoryEntry{
+		newHistoryEntry(EntryAssistant, "first"),
+		newHistoryEntry(EntryObservation, "has ``` fences"),
+		newHistoryEntry(EntryAssistant, "second"),
+		{Content: "untyped"},
+	}
+	want := "# Conversation\n" +
+		"\n## Assistant\n\nfirst\n" +
+		"\n## Observation\n\n````\nhas ``` fences\n````\n" +
+		"\n## Final Answer\n\n> second\n" +
+		"\n## Message\n\nuntyped\n"
+	if got := ExportMarkdown(history); got != want {
+		t.Errorf("ExportMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
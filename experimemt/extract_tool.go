@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// NewExtractTool returns a tool that pulls fields out of text using a regular
+// expression with named capture groups, e.g. (?P<year>\d{4}). The result is a
+// JSON object mapping each group name to the value it matched; when nothing
+// matches an empty object is returned.
+func NewExtractTool() Tool {
+	return Tool{
+		Name:        "extract",
+		Description: "A tool that extracts fields from text using a regular expression with named capture groups, e.g. (?P<year>\\d{4}). Returns a JSON object of group name to value.",
+		Args:        map[string]string{"input": "string", "pattern": "string (regular expression with named groups)"},
+		Function: func(args map[string]interface{}) (string, error) {
+			input, ok := args["input"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'input' argument")
+			}
+			pattern, ok := args["pattern"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'pattern' argument")
+			}
+
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", fmt.Errorf("invalid pattern %q: %v", pattern, err)
+			}
+
+			fields := make(map[string]string)
+			if match := re.FindStringSubmatch(input); match != nil {
+				for i, name := range re.SubexpNames() {
+					if i == 0 || name == "" {
+						continue
+					}
+					fields[name] = match[i]
+				}
+			}
+
+			out, err := json.Marshal(fields)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode extracted fields: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractTool(t *testing.T) {
+	tool := NewExtractTool()
+	tests := []struct {
+		input, pattern, want string
+	}{
+		{"Invoice INV-2041 dated 2024-03-15", `INV-(?P<id>\d+) dated (?P<year>\d{4})-(?P<month>\d{2})`, `{"id":"2041","month":"03","year":"2024"}`},
+		{"order 7", `order (\d+) ?(?P<note>x)?`, `{"note":""}`},
+		{"no digits here", `(?P<n>\d+)`, `{}`},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"input": tt.input, "pattern": tt.pattern})
+		if err != nil || got != tt.want {
+			t.Errorf("extract(%q, %q) = %s, %v; want %s", tt.input, tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestExtractToolErrors(t *testing.T) {
+	tool := NewExtractTool()
+	_, err := tool.Function(map[string]interface{}{"input": "x", "pattern": `(?P<bad`})
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("invalid pattern: got error %v", err)
+	}
+	if _, err := tool.Function(map[string]interface{}{"pattern": "x"}); err == nil || err.Error() != "missing 'input' argument" {
+		t.Errorf("missing input: got error %v", err)
+	}
+}
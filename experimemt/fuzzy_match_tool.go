@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultFuzzyThreshold is the similarity below which fuzzy_match reports no
+// good match.
+const defaultFuzzyThreshold = 0.6
+
+// FuzzyMatch is the result of the fuzzy_match tool.
+type FuzzyMatch struct {
+	Match string  `json:"match"`
+	Score float64 `json:"score"`
+}
+
+// NewFuzzyMatchTool returns a tool that finds the candidate most similar to a
+// query. Similarity is the Levenshtein ratio of the lowercased strings, from 0
+// (nothing in common) to 1 (identical). The result is a JSON object with the
+// match and its score, or "no good match" when the best score falls below the
+// threshold.
+func NewFuzzyMatchTool() Tool {
+	return Tool{
+		Name:        "fuzzy_match",
+		Description: "A tool that finds the candidate most similar to a query, e.g. for matching misspelled names, and reports a similarity score from 0 to 1.",
+		Args: map[string]string{
+			"query":      "string",
+			"candidates": "list of strings",
+			"threshold":  "number (minimum score from 0 to 1 to accept a match, default 0.6)",
+		},
+		OptionalArgs: []string{"threshold"},
+		Function: func(args map[string]interface{}) (string, error) {
+			query, ok := args["query"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'query' argument")
+			}
+			candidates, err := stringListArg(args, "candidates")
+			if err != nil {
+				return "", err
+			}
+			if len(candidates) == 0 {
+				return "", fmt.Errorf("'candidates' must not be empty")
+			}
+			threshold := defaultFuzzyThreshold
+			if t, ok := args["threshold"].(float64); ok {
+				if t < 0 || t > 1 {
+					return "", fmt.Errorf("'threshold' must be between 0 and 1")
+				}
+				threshold = t
+			}
+
+			best := FuzzyMatch{Score: -1}
+			for _, candidate := range candidates {
+				if score := levenshteinRatio(query, candidate); score > best.Score {
+					best = FuzzyMatch{Match: candidate, Score: score}
+				}
+			}
+			if best.Score < threshold {
+				return "no good match", nil
+			}
+			best.Score = float64(int(best.Score*1000+0.5)) / 1000
+			out, err := json.Marshal(best)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode match: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// levenshteinRatio returns 1 - distance/maxLen for the case-folded, trimmed
+// strings a and b, compared rune by rune.
+func levenshteinRatio(a, b string) float64 {
+	ra := []rune(strings.ToLower(strings.TrimSpace(a)))
+	rb := []rune(strings.ToLower(strings.TrimSpace(b)))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
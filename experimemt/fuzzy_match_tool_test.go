@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"café", "cafe", 1},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatchTool(t *testing.T) {
+	tool := NewFuzzyMatchTool()
+	tests := []struct {
+		query      string
+		candidates []interface{}
+		threshold  interface{}
+		want       string
+	}{
+		{"Jon Smith", []interface{}{"Jane Doe", "John Smith", "Joan Smythe"}, nil, `{"match":"John Smith","score":0.9}`},
+		{"  ACME corp ", []interface{}{"Acme Corp", "Apex Corp"}, nil, `{"match":"Acme Corp","score":1}`},
+		{"apple", []interface{}{"orange", "banana"}, nil, "no good match"},
+		{"apple", []interface{}{"orange", "banana"}, 0.0, `{"match":"orange","score":0.167}`},
+		{"Jon Smith", []interface{}{"John Smith"}, 0.95, "no good match"},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"query": tt.query, "candidates": tt.candidates}
+		if tt.threshold != nil {
+			args["threshold"] = tt.threshold
+		}
+		got, err := tool.Function(args)
+		if err != nil || got != tt.want {
+			t.Errorf("fuzzy_match(%q, %v) = %s, %v; want %s", tt.query, tt.candidates, got, err, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatchToolErrors(t *testing.T) {
+	tool := NewFuzzyMatchTool()
+	for _, args := range []map[string]interface{}{
+		{"query": "a", "candidates": []interface{}{}},
+		{"query": "a", "candidates": []interface{}{"b"}, "threshold": 1.5},
+		{"candidates": []interface{}{"b"}},
+	} {
+		if got, err := tool.Function(args); err == nil {
+			t.Errorf("fuzzy_match(%v) = %q, want an error", args, got)
+		}
+	}
+}
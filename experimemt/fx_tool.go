@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// frankfurterURL is the default exchange rate endpoint. It needs no API key.
+const frankfurterURL = "https://api.frankfurter.app/latest"
+
+// fxCacheTTL is how long fetched rates are reused before asking the API again.
+const fxCacheTTL = time.Hour
+
+// currencyCodePattern matches ISO 4217 currency codes.
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NewFXTool returns a tool that converts amounts between currencies using a
+// Frankfurter compatible endpoint, e.g. https://api.frankfurter.app/latest,
+// and the system clock.
+func NewFXTool(endpoint string) Tool {
+	return NewFXToolWithClock(endpoint, fxCacheTTL, SystemClock)
+}
+
+// NewFXToolWithClock is like NewFXTool but caches the rates for each base
+// currency for ttl as measured by clock. The endpoint is called as
+// endpoint?from=USD and must answer with {"base": "USD", "rates": {...}}.
+// Only the endpoint's host may be contacted, and only at a public address.
+func NewFXToolWithClock(endpoint string, ttl time.Duration, clock Clock) Tool {
+	return NewFXToolWithClient(endpoint, SafeHTTPClient([]string{urlHost(endpoint)}), ttl, clock)
+}
+
+// NewFXToolWithClient is like NewFXToolWithClock but fetches the rates using
+// client.
+func NewFXToolWithClient(endpoint string, client *http.Client, ttl time.Duration, clock Clock) Tool {
+
+	type cachedRates struct {
+		rates   map[string]float64
+		fetched time.Time
+	}
+	var mu sync.Mutex
+	cache := make(map[string]cachedRates)
+
+	ratesFor := func(base string) (map[string]float64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if c, ok := cache[base]; ok && clock.Now().Sub(c.fetched) < ttl {
+			return c.rates, nil
+		}
+
+		var resp struct {
+			Base  string             `json:"base"`
+			Rates map[string]float64 `json:"rates"`
+		}
+		query := url.Values{"from": {base}}
+		if err := getJSON(client, endpoint+"?"+query.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("fetching exchange rates for %s failed: %v", base, err)
+		}
+		if len(resp.Rates) == 0 {
+			return nil, fmt.Errorf("no exchange rates available for %s", base)
+		}
+		cache[base] = cachedRates{rates: resp.Rates, fetched: clock.Now()}
+		return resp.Rates, nil
+	}
+
+	return Tool{
+		Name:        "fx",
+		Description: "A tool that converts an amount of money between currencies at current exchange rates, using ISO 4217 codes such as 'USD' or 'EUR'.",
+		Args: map[string]string{
+			"amount": "number",
+			"from":   "string (currency code, e.g. 'USD')",
+			"to":     "string (currency code, e.g. 'EUR')",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			amount, ok := args["amount"].(float64)
+			if !ok {
+				return "", fmt.Errorf("missing or invalid 'amount' argument")
+			}
+			from, ok := args["from"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'from' argument")
+			}
+			to, ok := args["to"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'to' argument")
+			}
+			from, to = strings.ToUpper(strings.TrimSpace(from)), strings.ToUpper(strings.TrimSpace(to))
+			for _, code := range []string{from, to} {
+				if !currencyCodePattern.MatchString(code) {
+					return "", fmt.Errorf("invalid currency code %q: expected three letters such as 'USD'", code)
+				}
+			}
+			if from == to {
+				return fmt.Sprintf("%.2f %s = %.2f %s (rate 1)", amount, from, amount, to), nil
+			}
+
+			rates, err := ratesFor(from)
+			if err != nil {
+				return "", err
+			}
+			rate, ok := rates[to]
+			if !ok {
+				return "", fmt.Errorf("unknown currency code: %s", to)
+			}
+			return fmt.Sprintf("%.2f %s = %.2f %s (rate %g)", amount, from, amount*rate, to, rate), nil
+		},
+	}
+}
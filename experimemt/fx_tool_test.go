@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fxServer answers Frankfurter style requests with fixed rates and counts the
+// requests it receives.
+func fxServer(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		base := r.URL.Query().Get("from")
+		if base != "USD" {
+			fmt.Fprintf(w, `{"base": %q, "rates": {}}`, base)
+			return
+		}
+		fmt.Fprint(w, `{"base": "USD", "rates": {"EUR": 0.5, "GBP": 0.25}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFXToolConverts(t *testing.T) {
+	var requests int32
+	srv := fxServer(t, &requests)
+	tool := NewFXToolWithClient(srv.URL, srv.Client(), time.Hour, newFakeClock(time.Unix(0, 0)))
+
+	got, err := tool.Function(map[string]interface{}{"amount": 10.0, "from": "usd", "to": " eur "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "10.00 USD = 5.00 EUR (rate 0.5)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFXToolCachesRatesForTTL(t *testing.T) {
+	var requests int32
+	srv := fxServer(t, &requests)
+	clock := newFakeClock(time.Unix(0, 0))
+	tool := NewFXToolWithClient(srv.URL, srv.Client(), time.Hour, clock)
+	args := map[string]interface{}{"amount": 1.0, "from": "USD", "to": "GBP"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := tool.Function(args); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(20 * time.Minute)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("got %d requests within the TTL, want 1", n)
+	}
+
+	clock.Advance(time.Hour)
+	if _, err := tool.Function(args); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("got %d requests after the TTL, want 2", n)
+	}
+}
+
+func TestFXToolErrors(t *testing.T) {
+	var requests int32
+	srv := fxServer(t, &requests)
+	tool := NewFXToolWithClient(srv.URL, srv.Client(), time.Hour, newFakeClock(time.Unix(0, 0)))
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"from": "USD", "to": "EUR"}, "'amount'"},
+		{map[string]interface{}{"amount": 1.0, "from": "US", "to": "EUR"}, "invalid currency code"},
+		{map[string]interface{}{"amount": 1.0, "from": "USD", "to": "XYZ"}, "unknown currency code: XYZ"},
+		{map[string]interface{}{"amount": 1.0, "from": "EUR", "to": "USD"}, "no exchange rates available for EUR"},
+	}
+	for _, tt := range tests {
+		_, err := tool.Function(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got error %v, want one containing %q", tt.args, err, tt.want)
+		}
+	}
+
+	got, err := tool.Function(map[string]interface{}{"amount": 2.0, "from": "EUR", "to": "eur"})
+	if err != nil || got != "2.00 EUR = 2.00 EUR (rate 1)" {
+		t.Errorf("same currency: got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	earthRadiusKm = 6371.0088 // mean Earth radius
+	kmPerMile     = 1.609344
+)
+
+// NewGeoDistanceTool returns a tool that computes the great-circle distance
+// between two coordinates with the haversine formula, in kilometres or miles.
+func NewGeoDistanceTool() Tool {
+	return Tool{
+		Name:        "geo_distance",
+		Description: "A tool that computes the great-circle distance between two latitude/longitude points.",
+		Args: map[string]string{
+			"lat1": "number (degrees, -90 to 90)",
+			"lon1": "number (degrees, -180 to 180)",
+			"lat2": "number (degrees, -90 to 90)",
+			"lon2": "number (degrees, -180 to 180)",
+			"unit": "string (optional, 'km' or 'mi', default 'km')",
+		},
+		OptionalArgs: []string{"unit"},
+		Function: func(args map[string]interface{}) (string, error) {
+			var coords [4]float64
+			for i, key := range []string{"lat1", "lon1", "lat2", "lon2"} {
+				v, ok := args[key].(float64)
+				if !ok {
+					return "", fmt.Errorf("missing or invalid '%s' argument", key)
+				}
+				limit := 90.0
+				if i%2 == 1 {
+					limit = 180
+				}
+				if v < -limit || v > limit {
+					return "", fmt.Errorf("'%s' must be between -%g and %g, got %g", key, limit, limit, v)
+				}
+				coords[i] = v
+			}
+
+			unit, _ := args["unit"].(string)
+			if unit == "" {
+				unit = "km"
+			}
+			dist := haversineKm(coords[0], coords[1], coords[2], coords[3])
+			switch unit {
+			case "km":
+			case "mi":
+				dist /= kmPerMile
+			default:
+				return "", fmt.Errorf("unsupported unit: %s", unit)
+			}
+			return fmt.Sprintf("%.2f %s", dist, unit), nil
+		},
+	}
+}
+
+// haversineKm returns the great-circle distance in kilometres between two
+// points given in degrees.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestGeoDistanceTool(t *testing.T) {
+	tool := NewGeoDistanceTool()
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		unit                   string
+		want                   float64
+	}{
+		{"London-Paris", 51.5074, -0.1278, 48.8566, 2.3522, "", 343.5},
+		{"New York-Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, "km", 3936},
+		{"New York-Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, "mi", 2445},
+		{"Sydney-Tokyo", -33.8688, 151.2093, 35.6762, 139.6503, "km", 7823},
+		{"same point", 10, 20, 10, 20, "km", 0},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{
+			"lat1": tt.lat1, "lon1": tt.lon1, "lat2": tt.lat2, "lon2": tt.lon2, "unit": tt.unit,
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		unit := tt.unit
+		if unit == "" {
+			unit = "km"
+		}
+		var dist float64
+		var gotUnit string
+		if _, err := fmt.Sscanf(got, "%f %s", &dist, &gotUnit); err != nil || gotUnit != unit {
+			t.Errorf("%s: result %q is not a distance in %s", tt.name, got, unit)
+			continue
+		}
+		if math.Abs(dist-tt.want) > tt.want*0.01+0.01 {
+			t.Errorf("%s: distance = %s, want about %g %s", tt.name, got, tt.want, unit)
+		}
+	}
+}
+
+func TestGeoDistanceToolErrors(t *testing.T) {
+	tool := NewGeoDistanceTool()
+	valid := map[string]interface{}{"lat1": 0.0, "lon1": 0.0, "lat2": 1.0, "lon2": 1.0}
+	tests := []struct {
+		key   string
+		value interface{}
+	}{
+		{"lat1", 90.5},
+		{"lat2", -91.0},
+		{"lon1", 180.1},
+		{"lon2", "east"},
+		{"unit", "furlongs"},
+	}
+	for _, tt := range tests {
+		args := make(map[string]interface{})
+		for k, v := range valid {
+			args[k] = v
+		}
+		args[tt.key] = tt.value
+		if got, err := tool.Function(args); err == nil {
+			t.Errorf("%s=%v: got %q, want an error", tt.key, tt.value, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nominatimURL is the default geocode provider, OpenStreetMap's Nominatim.
+const nominatimURL = "https://nominatim.openstreetmap.org/search"
+
+// Nominatim's usage policy asks clients to identify themselves and to send at
+// most one request per second.
+const (
+	geocodeUserAgent   = "gemmalocalllm-agent/1.0"
+	geocodeMinInterval = time.Second
+)
+
+// NewGeocodeTool returns a tool that turns an address or place name into
+// coordinates through a Nominatim compatible search endpoint, e.g.
+// https://nominatim.openstreetmap.org/search. The endpoint is called with
+// q=<address>&format=jsonv2&limit=1 and must answer with an array of places
+// whose lat and lon are strings. Requests are spaced at least a second apart.
+func NewGeocodeTool(endpoint string) Tool {
+	return NewGeocodeToolWithClient(endpoint, SafeHTTPClient([]string{urlHost(endpoint)}))
+}
+
+// NewGeocodeToolWithClient is like NewGeocodeTool but calls the endpoint
+// using client.
+func NewGeocodeToolWithClient(endpoint string, client *http.Client) Tool {
+	var mu sync.Mutex
+	var last time.Time
+	return Tool{
+		Name:        "geocode",
+		Description: "A tool that finds the latitude and longitude of an address or place name, e.g. '10 Downing Street, London'.",
+		Args: map[string]string{
+			"address": "string (address or place name)",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			address, ok := args["address"].(string)
+			if !ok || strings.TrimSpace(address) == "" {
+				return "", fmt.Errorf("missing 'address' argument")
+			}
+			address = strings.TrimSpace(address)
+
+			query := url.Values{"q": {address}, "format": {"jsonv2"}, "limit": {"1"}}
+			req, err := http.NewRequest("GET", endpoint+"?"+query.Encode(), nil)
+			if err != nil {
+				return "", fmt.Errorf("geocode lookup failed: %v", err)
+			}
+			req.Header.Set("User-Agent", geocodeUserAgent)
+
+			var places []struct {
+				Lat         string `json:"lat"`
+				Lon         string `json:"lon"`
+				DisplayName string `json:"display_name"`
+			}
+			mu.Lock()
+			time.Sleep(geocodeMinInterval - time.Since(last))
+			err = doJSON(client, req, &places)
+			last = time.Now()
+			mu.Unlock()
+			if err != nil {
+				return "", fmt.Errorf("geocode lookup failed: %v", err)
+			}
+			if len(places) == 0 {
+				return "", fmt.Errorf("no location found for address %q", address)
+			}
+
+			lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+			lon, lonErr := strconv.ParseFloat(places[0].Lon, 64)
+			if latErr != nil || lonErr != nil {
+				return "", fmt.Errorf("geocode lookup failed: invalid coordinates %q, %q", places[0].Lat, places[0].Lon)
+			}
+			out, err := json.Marshal(map[string]interface{}{
+				"address":      address,
+				"lat":          lat,
+				"lon":          lon,
+				"display_name": places[0].DisplayName,
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
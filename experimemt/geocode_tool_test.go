@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeocodeTool(t *testing.T) {
+	var agents []string
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		times = append(times, time.Now())
+		q := r.URL.Query()
+		if q.Get("format") != "jsonv2" || q.Get("limit") != "1" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		if q.Get("q") == "Nowhere" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"lat": "51.5034", "lon": "-0.1276", "display_name": "10 Downing Street, London"}]`)
+	}))
+	defer srv.Close()
+	tool := NewGeocodeToolWithClient(srv.URL, srv.Client())
+
+	got, err := tool.Function(map[string]interface{}{"address": " 10 Downing Street "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"address":"10 Downing Street","display_name":"10 Downing Street, London","lat":51.5034,"lon":-0.1276}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	_, err = tool.Function(map[string]interface{}{"address": "Nowhere"})
+	if err == nil || !strings.Contains(err.Error(), `no location found for address "Nowhere"`) {
+		t.Errorf("got error %v, want no location found", err)
+	}
+
+	for _, ua := range agents {
+		if ua != geocodeUserAgent {
+			t.Errorf("got User-Agent %q, want %q", ua, geocodeUserAgent)
+		}
+	}
+	if len(times) == 2 && times[1].Sub(times[0]) < geocodeMinInterval {
+		t.Errorf("requests were %v apart, want at least %v", times[1].Sub(times[0]), geocodeMinInterval)
+	}
+}
+
+func TestGeocodeToolErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"lat": "north", "lon": "-0.1276"}]`)
+	}))
+	defer srv.Close()
+	tool := NewGeocodeToolWithClient(srv.URL, srv.Client())
+
+	if _, err := tool.Function(map[string]interface{}{"address": "  "}); err == nil || err.Error() != "missing 'address' argument" {
+		t.Errorf("blank address: got error %v", err)
+	}
+	if _, err := tool.Function(map[string]interface{}{"address": "London"}); err == nil || !strings.Contains(err.Error(), "invalid coordinates") {
+		t.Errorf("bad coordinates: got error %v", err)
+	}
+}
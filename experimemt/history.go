@@ -0,0 +1,195 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Roles identify who produced a history entry.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+	RoleSystem    = "system"
+)
+
+// Entry types classify history entries so the prompt can include or leave out
+// whole categories, e.g. the model's private thoughts.
+const (
+	EntryUser        = "user"
+	EntryAssistant   = "assistant"
+	EntryThought     = "thought"
+	EntryToolCall    = "tool_call"
+	EntryObservation = "observation"
+	EntrySummary     = "summary"
+)
+
+// transcriptLabel is a line prefix in the transcript history format together
+// with the role and type of the entry it starts.
+type transcriptLabel struct {
+	prefix    string
+	role      string
+	entryType string
+}
+
+// historyLabels are the default transcript labels. Agents may rename the user,
+// assistant and observation labels; see Agent.labels.
+var historyLabels = []transcriptLabel{
+	{"User:", RoleUser, EntryUser},
+	{"Assistant:", RoleAssistant, EntryAssistant},
+	{"Thought:", RoleAssistant, EntryThought},
+	{"Action:", RoleAssistant, EntryToolCall},
+	{"Observation:", RoleTool, EntryObservation},
+	{"Summary:", RoleSystem, EntrySummary},
+}
+
+// HistoryEntry is a single turn of the conversation history.
+type HistoryEntry struct {
+	Role    string `json:"role"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// ParseHistory splits the flat transcript stored by Run into structured
+// entries. A line starting with a known label ("User:", "Thought:",
+// "Observation:", ...) opens a new entry; any other line continues the entry
+// before it, so multi-line observations stay together.
+func ParseHistory(history string) []HistoryEntry {
+	return parseHistory(history, historyLabels)
+}
+
+// parseHistory is ParseHistory for the agent's own transcript labels.
+func (a *Agent) parseHistory(history string) []HistoryEntry {
+	return parseHistory(history, a.labels())
+}
+
+func parseHistory(history string, labels []transcriptLabel) []HistoryEntry {
+	var entries []HistoryEntry
+	for _, line := range strings.Split(history, "\n") {
+		if entry, ok := splitHistoryLabel(line, labels); ok {
+			entries = append(entries, entry)
+			continue
+		}
+		if len(entries) == 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			// Text before any label is treated as an assistant message.
+			entries = append(entries, HistoryEntry{Role: RoleAssistant, Type: EntryAssistant, Content: line})
+			continue
+		}
+		last := &entries[len(entries)-1]
+		last.Content += "\n" + line
+	}
+
+	for i := range entries {
+		entries[i].Content = strings.TrimSpace(entries[i].Content)
+	}
+	return entries
+}
+
+// RenderHistory is the inverse of ParseHistory: it writes entries back out in
+// the labelled transcript format used in prompts and history files.
+func RenderHistory(entries []HistoryEntry) string {
+	return renderHistory(entries, historyLabels)
+}
+
+// renderHistory is RenderHistory for the agent's own transcript labels.
+func (a *Agent) renderHistory(entries []HistoryEntry) string {
+	return renderHistory(entries, a.labels())
+}
+
+func renderHistory(entries []HistoryEntry, labels []transcriptLabel) string {
+	var sb strings.Builder
+	for _, entry := range entries {
+		sb.WriteString("\n" + historyLabel(labels, entry.Type) + " " + entry.Content)
+	}
+	return sb.String()
+}
+
+// AppendHistory appends entry to the transcript history. With DedupHistory
+// set, an entry identical in role and content to the one before it is
+// dropped, so retries cannot bloat the prompt with repeats.
+func (a *Agent) AppendHistory(history string, entry HistoryEntry) string {
+	if a.DedupHistory {
+		entries := a.parseHistory(history)
+		if n := len(entries); n > 0 && entries[n-1].Role == entry.Role && entries[n-1].Content == strings.TrimSpace(entry.Content) {
+			a.logf("--- Skipping duplicate %s history entry ---", entry.Type)
+			return history
+		}
+	}
+	return history + a.renderHistory([]HistoryEntry{entry})
+}
+
+// newHistoryEntry returns an entry of the given type with its matching role.
+func newHistoryEntry(entryType, content string) HistoryEntry {
+	for _, label := range historyLabels {
+		if label.entryType == entryType {
+			return HistoryEntry{Role: label.role, Type: entryType, Content: content}
+		}
+	}
+	return HistoryEntry{Role: RoleAssistant, Type: entryType, Content: content}
+}
+
+// FilterHistory returns the entries whose type is in types.
+func FilterHistory(entries []HistoryEntry, types []string) []HistoryEntry {
+	keep := make(map[string]bool, len(types))
+	for _, t := range types {
+		keep[t] = true
+	}
+	var filtered []HistoryEntry
+	for _, entry := range entries {
+		if keep[entry.Type] {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// splitHistoryLabel reports whether line starts with a history label and, if
+// so, returns the entry it opens.
+func splitHistoryLabel(line string, labels []transcriptLabel) (HistoryEntry, bool) {
+	for _, label := range labels {
+		if strings.HasPrefix(line, label.prefix) {
+			content := strings.TrimSpace(strings.TrimPrefix(line, label.prefix))
+			return HistoryEntry{Role: label.role, Type: label.entryType, Content: content}, true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// historyLabel returns the transcript label for an entry type.
+func historyLabel(labels []transcriptLabel, entryType string) string {
+	for _, label := range labels {
+		if label.entryType == entryType {
+			return label.prefix
+		}
+	}
+	return "Assistant:"
+}
+
+// labels returns the transcript labels with the agent's AssistantLabel,
+// UserLabel and ObservationLabel in place of the defaults.
+func (a *Agent) labels() []transcriptLabel {
+	labels := slices.Clone(historyLabels)
+	for i := range labels {
+		var custom string
+		switch labels[i].entryType {
+		case EntryUser:
+			custom = a.UserLabel
+		case EntryAssistant:
+			custom = a.AssistantLabel
+		case EntryObservation:
+			custom = a.ObservationLabel
+		}
+		if custom = strings.TrimSpace(custom); custom != "" {
+			labels[i].prefix = custom
+		}
+	}
+	return labels
+}
+
+// label returns the agent's transcript label for an entry type.
+func (a *Agent) label(entryType string) string {
+	return historyLabel(a.labels(), entryType)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// HistoryStore persists the conversation history of an agent. Set
+// Agent.HistoryStore to keep history somewhere other than the history file
+// passed to Run.
+type HistoryStore interface {
+	Load() ([]HistoryEntry, error)
+	Save(entries []HistoryEntry) error
+}
+
+// NewHistoryStore returns the HistoryStore described by dsn:
+//
+//   - file:///path/to/history.json, or file://history.json for a relative
+//     path: a JSON file, as written by SaveHistory
+//   - memory://: entries kept in memory and lost on exit
+//   - sqlite:///path/to/history.db: a table in an SQLite database, in
+//     builds with cgo enabled
+func NewHistoryStore(dsn string) (HistoryStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history store DSN %q: %v", dsn, err)
+	}
+	path := u.Host + u.Path
+	switch u.Scheme {
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("invalid history store DSN %q: missing file path", dsn)
+		}
+		return &FileHistoryStore{Path: path}, nil
+	case "memory":
+		return &MemoryHistoryStore{}, nil
+	case "sqlite":
+		if path == "" {
+			return nil, fmt.Errorf("invalid history store DSN %q: missing database path", dsn)
+		}
+		return OpenSQLiteHistoryStore(path)
+	case "redis":
+		return nil, fmt.Errorf("history store scheme %q is not supported yet; use file, memory or sqlite", u.Scheme)
+	default:
+		return nil, fmt.Errorf("unknown history store scheme %q in %q: expected file, memory or sqlite", u.Scheme, dsn)
+	}
+}
+
+// FileHistoryStore keeps history in a JSON file. A file in the older labelled
+// transcript format is read as well and is rewritten as JSON on Save.
+type FileHistoryStore struct {
+	Path string
+}
+
+// Load implements HistoryStore.
+func (s *FileHistoryStore) Load() ([]HistoryEntry, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation history file: %v", err)
+	}
+	if !isJSONHistory(data) {
+		return ParseHistory(normalizeTranscript(data)), nil
+	}
+	return LoadHistory(s.Path)
+}
+
+// Save implements HistoryStore.
+func (s *FileHistoryStore) Save(entries []HistoryEntry) error {
+	return SaveHistory(s.Path, entries)
+}
+
+// MemoryHistoryStore keeps history in memory, e.g. for tests or throwaway
+// sessions. The zero value is an empty store.
+type MemoryHistoryStore struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+}
+
+// Load implements HistoryStore.
+func (s *MemoryHistoryStore) Load() ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]HistoryEntry(nil), s.entries...), nil
+}
+
+// Save implements HistoryStore.
+func (s *MemoryHistoryStore) Save(entries []HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append([]HistoryEntry(nil), entries...)
+	return nil
+}
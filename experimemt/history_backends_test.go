@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleHistory covers every entry type, with content that is awkward to
+// store: several lines, quotes and non-ASCII text.
+func sampleHistory() []HistoryEntry {
+	return []HistoryEntry{
+		newHistoryEntry(EntryUser, "What's 2+2?\nAnd \"why\"?"),
+		newHistoryEntry(EntryThought, "I should add."),
+		newHistoryEntry(EntryToolCall, `{"name":"calculator","arguments":{"operation":"add"}}`),
+		newHistoryEntry(EntryObservation, "4"),
+		newHistoryEntry(EntryAssistant, "It is 4 — naïvely."),
+		newHistoryEntry(EntrySummary, "Earlier: arithmetic."),
+	}
+}
+
+// testHistoryStoreRoundTrip checks that store returns what was last saved.
+func testHistoryStoreRoundTrip(t *testing.T, store HistoryStore) {
+	t.Helper()
+	if got, err := store.Load(); err != nil || len(got) != 0 {
+		t.Fatalf("Load of a new store = %v, %v; want no entries", got, err)
+	}
+
+	want := sampleHistory()
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Load(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %v, %v; want %v", got, err, want)
+	}
+
+	// Saving replaces the stored history rather than appending to it.
+	want = want[:2]
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Load(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load after a shorter Save = %v, %v; want %v", got, err, want)
+	}
+
+	if err := store.Save(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Load(); err != nil || len(got) != 0 {
+		t.Fatalf("Load after saving nothing = %v, %v; want no entries", got, err)
+	}
+}
+
+func TestFileHistoryStoreRoundTrip(t *testing.T) {
+	testHistoryStoreRoundTrip(t, &FileHistoryStore{Path: filepath.Join(t.TempDir(), "history.json")})
+}
+
+func TestMemoryHistoryStoreRoundTrip(t *testing.T) {
+	testHistoryStoreRoundTrip(t, &MemoryHistoryStore{})
+}
+
+func TestMemoryHistoryStoreCopies(t *testing.T) {
+	store := &MemoryHistoryStore{}
+	entries := sampleHistory()
+	store.Save(entries)
+	entries[0].Content = "changed"
+	got, _ := store.Load()
+	got[1].Content = "changed too"
+	if again, _ := store.Load(); !reflect.DeepEqual(again, sampleHistory()) {
+		t.Errorf("store shares its entries with callers: %v", again)
+	}
+}
+
+func TestFileHistoryStoreReadsTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.txt")
+	if err := os.WriteFile(path, []byte("User: hi\r\nAssistant: hello\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := &FileHistoryStore{Path: path}
+	want := []HistoryEntry{newHistoryEntry(EntryUser, "hi"), newHistoryEntry(EntryAssistant, "hello")}
+	if got, err := store.Load(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %v, %v; want %v", got, err, want)
+	}
+
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !isJSONHistory(data) {
+		t.Errorf("Save wrote %q, want the JSON format", data)
+	}
+}
+
+func TestNewHistoryStore(t *testing.T) {
+	store, err := NewHistoryStore("file://history.json")
+	if fs, ok := store.(*FileHistoryStore); err != nil || !ok || fs.Path != "history.json" {
+		t.Errorf("file://history.json = %#v, %v; want a relative file store", store, err)
+	}
+	store, err = NewHistoryStore("file:///tmp/history.json")
+	if fs, ok := store.(*FileHistoryStore); err != nil || !ok || fs.Path != "/tmp/history.json" {
+		t.Errorf("file:///tmp/history.json = %#v, %v; want an absolute file store", store, err)
+	}
+	if store, err := NewHistoryStore("memory://"); err != nil {
+		t.Errorf("memory:// = %v", err)
+	} else if _, ok := store.(*MemoryHistoryStore); !ok {
+		t.Errorf("memory:// = %#v, want a memory store", store)
+	}
+
+	for dsn, want := range map[string]string{
+		"file://":         "missing file path",
+		"sqlite://":       "missing database path",
+		"redis://host":    "not supported yet",
+		"postgres://host": "unknown history store scheme",
+		"%zz":             "invalid history store DSN",
+	} {
+		if _, err := NewHistoryStore(dsn); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("NewHistoryStore(%q) error = %v, want one containing %q", dsn, err, want)
+		}
+	}
+}
+
+func TestRunUsesHistoryStore(t *testing.T) {
+	agent := ScriptedAgent([]string{"Final Answer: hello"})
+	store := &MemoryHistoryStore{}
+	agent.HistoryStore = store
+	path := historyPath(t)
+
+	if _, err := agent.Run(context.Background(), path, "hi", nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := store.Load()
+	if len(entries) == 0 || entries[len(entries)-1] != newHistoryEntry(EntryAssistant, "hello") {
+		t.Errorf("store holds %v, want the run's history ending with its answer", entries)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("history file was written although a store is set: %v", err)
+	}
+}
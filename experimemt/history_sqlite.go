@@ -0,0 +1,89 @@
+//go:build cgo
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteHistoryStore keeps history in the history_entries table of an SQLite
+// database, one row per entry.
+type SQLiteHistoryStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteHistoryStore opens, creating if needed, the SQLite database at
+// path and its history table.
+func OpenSQLiteHistoryStore(path string) (*SQLiteHistoryStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %v", path, err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS history_entries (
+		seq     INTEGER PRIMARY KEY,
+		role    TEXT NOT NULL,
+		type    TEXT NOT NULL,
+		content TEXT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set up history database %s: %v", path, err)
+	}
+	return &SQLiteHistoryStore{db: db}, nil
+}
+
+// Load implements HistoryStore.
+func (s *SQLiteHistoryStore) Load() ([]HistoryEntry, error) {
+	rows, err := s.db.Query(`SELECT role, type, content FROM history_entries ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation history: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.Role, &e.Type, &e.Content); err != nil {
+			return nil, fmt.Errorf("failed to read conversation history: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conversation history: %v", err)
+	}
+	return entries, nil
+}
+
+// Save implements HistoryStore. The table is replaced in one transaction.
+func (s *SQLiteHistoryStore) Save(entries []HistoryEntry) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save conversation history: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.Exec(`DELETE FROM history_entries`); err != nil {
+		return fmt.Errorf("failed to save conversation history: %v", err)
+	}
+	for i, e := range entries {
+		if _, err := tx.Exec(`INSERT INTO history_entries (seq, role, type, content) VALUES (?, ?, ?, ?)`, i, e.Role, e.Type, e.Content); err != nil {
+			return fmt.Errorf("failed to save conversation history: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save conversation history: %v", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *SQLiteHistoryStore) Close() error {
+	return s.db.Close()
+}
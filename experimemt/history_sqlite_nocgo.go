@@ -0,0 +1,26 @@
+//go:build !cgo
+
+package main
+
+import "errors"
+
+// errNoSQLite is returned by the SQLite history store in builds without cgo,
+// which the SQLite driver needs.
+var errNoSQLite = errors.New("the sqlite history store needs a build with cgo enabled; use file or memory instead")
+
+// SQLiteHistoryStore is unavailable without cgo; see history_sqlite.go.
+type SQLiteHistoryStore struct{}
+
+// OpenSQLiteHistoryStore returns errNoSQLite.
+func OpenSQLiteHistoryStore(path string) (*SQLiteHistoryStore, error) {
+	return nil, errNoSQLite
+}
+
+// Load implements HistoryStore.
+func (s *SQLiteHistoryStore) Load() ([]HistoryEntry, error) { return nil, errNoSQLite }
+
+// Save implements HistoryStore.
+func (s *SQLiteHistoryStore) Save(entries []HistoryEntry) error { return errNoSQLite }
+
+// Close implements io.Closer.
+func (s *SQLiteHistoryStore) Close() error { return nil }
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSQLiteHistoryStoreNeedsCgo(t *testing.T) {
+	if _, err := NewHistoryStore("sqlite:///tmp/history.db"); !errors.Is(err, errNoSQLite) {
+		t.Errorf("NewHistoryStore(sqlite) without cgo = %v, want errNoSQLite", err)
+	}
+}
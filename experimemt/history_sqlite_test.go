@@ -0,0 +1,39 @@
+//go:build cgo
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteHistoryStoreRoundTrip(t *testing.T) {
+	store, err := OpenSQLiteHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	testHistoryStoreRoundTrip(t, store)
+}
+
+func TestSQLiteHistoryStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := NewHistoryStore("sqlite://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(sampleHistory()); err != nil {
+		t.Fatal(err)
+	}
+	store.(*SQLiteHistoryStore).Close()
+
+	reopened, err := OpenSQLiteHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Load(); err != nil || !reflect.DeepEqual(got, sampleHistory()) {
+		t.Fatalf("Load after reopening = %v, %v; want %v", got, err, sampleHistory())
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// corruptSuffix is appended to the name of a history file that could not be
+// parsed when it is moved aside.
+const corruptSuffix = ".corrupt"
+
+// LoadHistory reads history entries stored as a JSON array by SaveHistory. A
+// missing file is an empty history. A file that is not valid JSON, e.g. one
+// truncated by a crash mid-write, is moved to <path>.corrupt with a warning
+// and an empty history is returned, so one bad file doesn't break every run.
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation history file: %v", err)
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\ufeff")), &entries); err != nil {
+		backup := path + corruptSuffix
+		if renameErr := os.Rename(path, backup); renameErr != nil {
+			return nil, fmt.Errorf("conversation history %s is corrupt (%v) and could not be moved aside: %v", path, err, renameErr)
+		}
+		log.Printf("Warning: conversation history %s is corrupt (%v); moved it to %s and starting afresh\n", path, err, backup)
+		return nil, nil
+	}
+	return entries, nil
+}
+
+// SaveHistory writes entries to path as a JSON array. The file is replaced
+// atomically, so a crash never leaves a half-written history behind.
+func SaveHistory(path string, entries []HistoryEntry) error {
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+	raw, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation history: %v", err)
+	}
+	if err := writeFileAtomic(path, raw); err != nil {
+		return fmt.Errorf("failed to save conversation history to file: %v", err)
+	}
+	return nil
+}
+
+// isJSONHistory reports whether data holds structured JSON history rather than
+// the labelled transcript format.
+func isJSONHistory(data []byte) bool {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
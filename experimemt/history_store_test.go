@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadHistoryRoundTrip(t *testing.T) {
+	path := historyPath(t)
+	if entries, err := LoadHistory(path); err != nil || entries != nil {
+		t.Fatalf("LoadHistory of a missing file = %v, %v; want an empty history", entries, err)
+	}
+	want := []HistoryEntry{newHistoryEntry(EntryUser, "hi"), newHistoryEntry(EntryAssistant, "hello")}
+	if err := SaveHistory(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadHistory(path)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadHistory = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestLoadHistoryRecoversFromCorruptFile(t *testing.T) {
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	path := historyPath(t)
+	truncated := `[{"Role": "user", "Type": "user", "Content": "hi"}, {"Role": "assis`
+	os.WriteFile(path, []byte(truncated), 0o644)
+
+	entries, err := LoadHistory(path)
+	if err != nil || entries != nil {
+		t.Fatalf("LoadHistory = %v, %v; want an empty history", entries, err)
+	}
+	if backup, err := os.ReadFile(path + corruptSuffix); err != nil || string(backup) != truncated {
+		t.Errorf("backup = %q, %v; want the corrupt file", backup, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("corrupt file still in place: %v", err)
+	}
+	if !strings.Contains(logs.String(), "Warning: conversation history "+path+" is corrupt") {
+		t.Errorf("no warning logged: %q", logs.String())
+	}
+}
+
+func TestRunSurvivesCorruptJSONHistory(t *testing.T) {
+	log.SetOutput(new(strings.Builder))
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	path := historyPath(t)
+	os.WriteFile(path, []byte(`[{"Role": "user", "Content": "earl`), 0o644)
+	agent := ScriptedAgent([]string{"Final Answer: fresh start"})
+	if got, err := agent.Run(context.Background(), path, "q", nil); err != nil || got != "fresh start" {
+		t.Fatalf("Run = %q, %v; want it to start with an empty history", got, err)
+	}
+	if _, err := os.Stat(path + corruptSuffix); err != nil {
+		t.Errorf("no backup of the corrupt history: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAndRenderHistory(t *testing.T) {
+	transcript := "\nUser: hi\nThought: greet back\nAction: {\"name\":\"t\"}\nObservation: ok\nAssistant: hello\nsecond line\nSummary: earlier chat"
+	entries := ParseHistory(transcript)
+	want := []HistoryEntry{
+		{Role: RoleUser, Type: EntryUser, Content: "hi"},
+		{Role: RoleAssistant, Type: EntryThought, Content: "greet back"},
+		{Role: RoleAssistant, Type: EntryToolCall, Content: `{"name":"t"}`},
+		{Role: RoleTool, Type: EntryObservation, Content: "ok"},
+		{Role: RoleAssistant, Type: EntryAssistant, Content: "hello\nsecond line"},
+		{Role: RoleSystem, Type: EntrySummary, Content: "earlier chat"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("ParseHistory = %+v, want %+v", entries, want)
+	}
+	if got := RenderHistory(entries); got != transcript {
+		t.Errorf("RenderHistory = %q, want the original transcript", got)
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	entries := ParseHistory("\nUser: q\nThought: hmm\nAssistant: a\nThought: again")
+	got := FilterHistory(entries, []string{EntryUser, EntryAssistant})
+	if len(got) != 2 || got[0].Content != "q" || got[1].Content != "a" {
+		t.Errorf("FilterHistory = %+v, want the user and assistant entries", got)
+	}
+	if got := FilterHistory(entries, nil); len(got) != 0 {
+		t.Errorf("FilterHistory with no types = %+v, want nothing", got)
+	}
+}
+
+func TestPromptIncludeTypesHidesThoughts(t *testing.T) {
+	path := historyPath(t)
+	os.WriteFile(path, []byte("\nUser: earlier question\nThought: secret plan\nAssistant: earlier answer"), 0644)
+
+	agent := ScriptedAgent([]string{"Final Answer: ok"})
+	agent.PromptIncludeTypes = []string{EntryUser, EntryAssistant, EntryToolCall, EntryObservation}
+	if _, err := agent.Run(context.Background(), path, "next", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := agent.Client.(*ScriptedClient).Prompts()[0]
+	if strings.Contains(prompt, "secret plan") || !strings.Contains(prompt, "User: earlier question") || !strings.Contains(prompt, "Assistant: earlier answer") {
+		t.Errorf("prompt should show all but the thought:\n%s", prompt)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), "Thought: secret plan") {
+		t.Errorf("history file lost the thought: %q", saved)
+	}
+}
+
+func TestRunNormalizesObservations(t *testing.T) {
+	const noisy = "  line one  \r\n\n\n\nline two\t\n\n"
+	if !NewAgent("http://unused.invalid", "m").NormalizeObservations {
+		t.Error("NormalizeObservations is off by default")
+	}
+	for _, normalize := range []bool{true, false} {
+		path := historyPath(t)
+		agent := ScriptedAgent([]string{`Action: {"name": "noisy", "arguments": {}}`, "Final Answer: done"})
+		agent.AddTool(ScriptedTool("noisy", noisy))
+		agent.NormalizeObservations = normalize
+		result, err := agent.RunWithTrace(context.Background(), path, "q", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := noisy
+		if normalize {
+			want = "line one\n\nline two"
+		}
+		if got := result.Trace[0].Observation; got != want {
+			t.Errorf("normalize=%v: observation = %q, want %q", normalize, got, want)
+		}
+		history, err := agent.GetConversationHistory(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if normalize && !strings.Contains(history, "\nObservation: line one\n\nline two\n") {
+			t.Errorf("history does not hold the normalized observation: %q", history)
+		}
+	}
+}
+
+func TestAppendHistoryDedup(t *testing.T) {
+	agent := NewAgent("http://unused.invalid", "m")
+	agent.DedupHistory = true
+	history := ""
+	for _, e := range []HistoryEntry{
+		newHistoryEntry(EntryObservation, "42"),
+		newHistoryEntry(EntryObservation, "42 "), // a retry repeating the result
+		newHistoryEntry(EntryUser, "42"),         // same text, different role
+		newHistoryEntry(EntryObservation, "42"),  // not consecutive
+	} {
+		history = agent.AppendHistory(history, e)
+	}
+	want := "\nObservation: 42\nUser: 42\nObservation: 42"
+	if history != want {
+		t.Errorf("deduplicated history = %q, want %q", history, want)
+	}
+
+	agent.DedupHistory = false
+	history = agent.AppendHistory(agent.AppendHistory("", newHistoryEntry(EntryObservation, "42")), newHistoryEntry(EntryObservation, "42"))
+	if strings.Count(history, "Observation: 42") != 2 {
+		t.Errorf("history without DedupHistory = %q, want both entries", history)
+	}
+}
+
+func TestCustomTranscriptLabels(t *testing.T) {
+	path := historyPath(t)
+	os.WriteFile(path, []byte("\nHuman: hello\nAI: hi there"), 0644)
+	agent := ScriptedAgent([]string{
+		`{"name": "lookup", "arguments": {}}`,
+		"Final Answer: Paris",
+		"Final Answer: about 2.1 million",
+	})
+	agent.AssistantLabel, agent.UserLabel, agent.ObservationLabel = "AI:", " Human: ", "Result:"
+	agent.AddTool(ScriptedTool("lookup", "capital=Paris"))
+
+	for _, q := range []string{"capital of France?", "population?"} {
+		if _, err := agent.Run(context.Background(), path, q, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved, _ := os.ReadFile(path)
+	want := []HistoryEntry{
+		{Role: RoleUser, Type: EntryUser, Content: "hello"},
+		{Role: RoleAssistant, Type: EntryAssistant, Content: "hi there"},
+		{Role: RoleUser, Type: EntryUser, Content: "capital of France?"},
+		{Role: RoleAssistant, Type: EntryToolCall, Content: `{"name":"lookup","arguments":{}}`},
+		{Role: RoleTool, Type: EntryObservation, Content: "capital=Paris"},
+		{Role: RoleAssistant, Type: EntryAssistant, Content: "Paris"},
+		{Role: RoleUser, Type: EntryUser, Content: "population?"},
+		{Role: RoleAssistant, Type: EntryAssistant, Content: "about 2.1 million"},
+	}
+	if got := agent.parseHistory(string(saved)); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved history = %+v, want %+v", got, want)
+	}
+	for _, label := range []string{"User:", "Assistant:", "Observation:"} {
+		if strings.Contains(string(saved), label) {
+			t.Errorf("saved history uses the default label %s:\n%s", label, saved)
+		}
+	}
+
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	for _, want := range []string{
+		"Result: The result of the tool's action.",
+		"your earlier answers are labelled 'AI:' and the user's messages 'Human:'",
+		"\nHuman: hello\nAI: hi there",
+		"\nHuman: capital of France?",
+	} {
+		if !strings.Contains(prompts[0], want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompts[0])
+		}
+	}
+	if !strings.Contains(prompts[1], "\nResult: capital=Paris") {
+		t.Errorf("prompt does not label the tool result as instructed:\n%s", prompts[1])
+	}
+	if !strings.Contains(prompts[2], "\nAI: Paris") {
+		t.Errorf("prompt does not label the earlier answer as instructed:\n%s", prompts[2])
+	}
+	if asked, answered := strings.Index(prompts[2], "\nHuman: capital of France?"), strings.Index(prompts[2], "\nAI: Paris"); asked < 0 || asked > answered {
+		t.Errorf("later prompt does not show the earlier question before its answer:\n%s", prompts[2])
+	}
+}
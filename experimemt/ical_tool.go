@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// iCalendar date and date-time layouts (RFC 5545).
+const (
+	icalDate        = "20060102"
+	icalDateTime    = "20060102T150405"
+	icalDateTimeUTC = "20060102T150405Z"
+)
+
+// icalInputLayouts are the time formats the ical tool accepts for start and
+// end, besides RFC 3339.
+var icalInputLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// ICalEvent is an event as read or written by the ical tool. Start and End
+// are RFC 3339 times, times without a zone ("floating" times), or dates for
+// all-day events.
+type ICalEvent struct {
+	UID         string `json:"uid,omitempty"`
+	Summary     string `json:"summary"`
+	Start       string `json:"start"`
+	End         string `json:"end,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// NewICalTool returns a tool that creates and parses iCalendar (.ics) events,
+// stamping created events with the system clock.
+func NewICalTool() Tool {
+	return NewICalToolWithClock(SystemClock)
+}
+
+// NewICalToolWithClock is like NewICalTool but reads the DTSTAMP of created
+// events from clock. 'create' returns a VCALENDAR holding one VEVENT; 'parse'
+// returns the VEVENTs of an .ics document as a JSON array of ICalEvent.
+func NewICalToolWithClock(clock Clock) Tool {
+	return Tool{
+		Name:        "ical",
+		Description: "A tool that creates an iCalendar (.ics) event from its details, or lists the events in .ics text.",
+		Args: map[string]string{
+			"operation":   "string (e.g., 'create', 'parse')",
+			"summary":     "string (event title, for 'create')",
+			"start":       "string (e.g. '2024-05-01T14:00:00Z', '2024-05-01 14:00' or '2024-05-01' for all day, for 'create')",
+			"end":         "string (optional, same formats as 'start', for 'create')",
+			"location":    "string (optional, for 'create')",
+			"description": "string (optional, for 'create')",
+			"input":       "string (.ics text, for 'parse')",
+		},
+		OptionalArgs: []string{"summary", "start", "end", "location", "description", "input"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+
+			switch op {
+			case "create":
+				var ev ICalEvent
+				ev.Summary, _ = args["summary"].(string)
+				ev.Start, _ = args["start"].(string)
+				ev.End, _ = args["end"].(string)
+				ev.Location, _ = args["location"].(string)
+				ev.Description, _ = args["description"].(string)
+				if strings.TrimSpace(ev.Summary) == "" {
+					return "", fmt.Errorf("missing 'summary' argument")
+				}
+				if ev.Start == "" {
+					return "", fmt.Errorf("missing 'start' argument")
+				}
+				return createICalEvent(ev, clock.Now())
+			case "parse":
+				input, ok := args["input"].(string)
+				if !ok || strings.TrimSpace(input) == "" {
+					return "", fmt.Errorf("missing 'input' argument")
+				}
+				events, err := parseICal(input)
+				if err != nil {
+					return "", err
+				}
+				out, err := json.Marshal(events)
+				if err != nil {
+					return "", fmt.Errorf("failed to encode result: %v", err)
+				}
+				return string(out), nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// icalTime is a parsed start or end time.
+type icalTime struct {
+	t       time.Time
+	allDay  bool // a date without a time
+	hasZone bool // false for floating times
+}
+
+// parseEventTime reads a start or end argument.
+func parseEventTime(name, s string) (icalTime, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return icalTime{t: t, hasZone: true}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return icalTime{t: t, allDay: true}, nil
+	}
+	for _, layout := range icalInputLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return icalTime{t: t}, nil
+		}
+	}
+	return icalTime{}, fmt.Errorf("invalid '%s' time %q: expected e.g. '2024-05-01T14:00:00Z', '2024-05-01 14:00' or '2024-05-01'", name, s)
+}
+
+// property renders the time as an iCalendar property such as DTSTART.
+func (it icalTime) property(name string) string {
+	switch {
+	case it.allDay:
+		return name + ";VALUE=DATE:" + it.t.Format(icalDate)
+	case it.hasZone:
+		return name + ":" + it.t.UTC().Format(icalDateTimeUTC)
+	default:
+		return name + ":" + it.t.Format(icalDateTime)
+	}
+}
+
+// createICalEvent renders ev as a VCALENDAR with a single VEVENT.
+func createICalEvent(ev ICalEvent, now time.Time) (string, error) {
+	start, err := parseEventTime("start", ev.Start)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//gemmalocalllm//ical tool//EN",
+		"BEGIN:VEVENT",
+	}
+	// The UID only needs to be unique; deriving it from the event keeps
+	// repeated calls for the same event from creating duplicates.
+	sum := sha1.Sum([]byte(ev.Summary + "\x00" + ev.Start + "\x00" + ev.End + "\x00" + ev.Location))
+	lines = append(lines,
+		"UID:"+hex.EncodeToString(sum[:8])+"@gemmalocalllm",
+		"DTSTAMP:"+now.UTC().Format(icalDateTimeUTC),
+		start.property("DTSTART"),
+	)
+	if ev.End != "" {
+		end, err := parseEventTime("end", ev.End)
+		if err != nil {
+			return "", err
+		}
+		if end.allDay != start.allDay || end.hasZone != start.hasZone {
+			return "", fmt.Errorf("'start' and 'end' must use the same kind of time (both dates, both with a time zone, or both without)")
+		}
+		if !end.t.After(start.t) {
+			return "", fmt.Errorf("'end' (%s) must be after 'start' (%s)", ev.End, ev.Start)
+		}
+		lines = append(lines, end.property("DTEND"))
+	}
+	lines = append(lines, "SUMMARY:"+escapeICalText(ev.Summary))
+	if ev.Location != "" {
+		lines = append(lines, "LOCATION:"+escapeICalText(ev.Location))
+	}
+	if ev.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeICalText(ev.Description))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(foldICalLine(line))
+		sb.WriteString("\r\n")
+	}
+	return sb.String(), nil
+}
+
+// parseICal returns the VEVENTs of an iCalendar document.
+func parseICal(input string) ([]ICalEvent, error) {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.ReplaceAll(input, "\n ", "")  // unfold continuation lines
+	input = strings.ReplaceAll(input, "\n\t", "") // ... however they are indented
+
+	events := []ICalEvent{}
+	var ev *ICalEvent
+	for n, line := range strings.Split(input, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		nameParams, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid iCalendar line %d: %q", n+1, line)
+		}
+		name, params, _ := strings.Cut(nameParams, ";")
+		name = strings.ToUpper(name)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			ev = &ICalEvent{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if ev == nil {
+				return nil, fmt.Errorf("invalid iCalendar line %d: END:VEVENT without BEGIN:VEVENT", n+1)
+			}
+			if ev.Start == "" {
+				return nil, fmt.Errorf("event %q has no DTSTART", ev.Summary)
+			}
+			events = append(events, *ev)
+			ev = nil
+		case ev == nil:
+			// Calendar-level property or another component; not needed.
+		case name == "UID":
+			ev.UID = value
+		case name == "SUMMARY":
+			ev.Summary = unescapeICalText(value)
+		case name == "LOCATION":
+			ev.Location = unescapeICalText(value)
+		case name == "DESCRIPTION":
+			ev.Description = unescapeICalText(value)
+		case name == "DTSTART", name == "DTEND":
+			t, err := parseICalTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %v", name, value, err)
+			}
+			if name == "DTSTART" {
+				ev.Start = t
+			} else {
+				ev.End = t
+			}
+		}
+	}
+	if ev != nil {
+		return nil, fmt.Errorf("event %q is missing END:VEVENT", ev.Summary)
+	}
+	return events, nil
+}
+
+// parseICalTime converts a DTSTART or DTEND value, with its parameters such
+// as VALUE=DATE or TZID=Europe/Paris, to the ICalEvent format.
+func parseICalTime(params, value string) (string, error) {
+	var loc *time.Location // nil for floating times
+	for _, param := range strings.Split(params, ";") {
+		key, v, _ := strings.Cut(param, "=")
+		switch strings.ToUpper(key) {
+		case "VALUE":
+			if strings.EqualFold(v, "DATE") {
+				t, err := time.Parse(icalDate, value)
+				if err != nil {
+					return "", fmt.Errorf("expected a date such as 20240501")
+				}
+				return t.Format("2006-01-02"), nil
+			}
+		case "TZID":
+			l, err := time.LoadLocation(strings.Trim(v, `"`))
+			if err != nil {
+				return "", fmt.Errorf("unknown time zone %s", v)
+			}
+			loc = l
+		}
+	}
+	if t, err := time.Parse(icalDateTimeUTC, value); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	in := loc
+	if in == nil {
+		in = time.UTC
+	}
+	t, err := time.ParseInLocation(icalDateTime, value, in)
+	if err != nil {
+		if t, err := time.Parse(icalDate, value); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+		return "", fmt.Errorf("expected a date-time such as 20240501T140000Z")
+	}
+	if loc == nil {
+		return t.Format("2006-01-02T15:04:05"), nil
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// icalEscaper escapes TEXT values as RFC 5545 requires.
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeICalText escapes s for use as a TEXT property value.
+func escapeICalText(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+// unescapeICalText reverses escapeICalText.
+func unescapeICalText(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				sb.WriteByte('\n')
+			} else {
+				sb.WriteByte(s[i])
+			}
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// foldICalLine splits lines longer than 75 octets into continuation lines,
+// without breaking UTF-8 sequences.
+func foldICalLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var sb strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1 // the leading space counts
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
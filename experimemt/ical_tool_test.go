@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func icalTool() Tool {
+	return NewICalToolWithClock(newFakeClock(time.Date(2024, 4, 20, 8, 0, 0, 0, time.UTC)))
+}
+
+func TestICalToolCreate(t *testing.T) {
+	got, err := icalTool().Function(map[string]interface{}{
+		"operation": "create", "summary": "Team sync; weekly", "start": "2024-05-01T14:00:00+02:00", "end": "2024-05-01T15:00:00+02:00",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n", "BEGIN:VEVENT\r\n", "DTSTAMP:20240420T080000Z\r\n",
+		"DTSTART:20240501T120000Z\r\n", "DTEND:20240501T130000Z\r\n", "SUMMARY:Team sync\\; weekly\r\n", "END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("created event lacks %q:\n%s", want, got)
+		}
+	}
+
+	got, err = icalTool().Function(map[string]interface{}{"operation": "create", "summary": "Holiday", "start": "2024-08-01", "end": "2024-08-15"})
+	if err != nil || !strings.Contains(got, "DTSTART;VALUE=DATE:20240801\r\nDTEND;VALUE=DATE:20240815\r\n") {
+		t.Errorf("all-day event = %q, %v", got, err)
+	}
+}
+
+func TestICalToolRoundTrip(t *testing.T) {
+	tool := icalTool()
+	description := "Agenda: budget, hiring; and a very long list of other topics that will not fit on one line\nBring laptops."
+	ics, err := tool.Function(map[string]interface{}{
+		"operation": "create", "summary": "Planning – Q3", "start": "2024-05-01 09:30", "end": "2024-05-01 11:00",
+		"location": "Room 4, HQ", "description": description,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	out, err := tool.Function(map[string]interface{}{"operation": "parse", "input": ics})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []ICalEvent
+	if err := json.Unmarshal([]byte(out), &events); err != nil || len(events) != 1 {
+		t.Fatalf("parse = %s, %v; want one event", out, err)
+	}
+	ev := events[0]
+	want := ICalEvent{UID: ev.UID, Summary: "Planning – Q3", Start: "2024-05-01T09:30:00", End: "2024-05-01T11:00:00", Location: "Room 4, HQ", Description: description}
+	if ev != want || !strings.HasSuffix(ev.UID, "@gemmalocalllm") {
+		t.Errorf("round-tripped event = %+v, want %+v", ev, want)
+	}
+}
+
+func TestICalToolParse(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Call\nDTSTART;TZID=Europe/Paris:20240101T090000\nDTEND:20240101T090000Z\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Off\nDTSTART;VALUE=DATE:20240102\nEND:VEVENT\nEND:VCALENDAR\n"
+	out, err := icalTool().Function(map[string]interface{}{"operation": "parse", "input": ics})
+	want := `[{"summary":"Call","start":"2024-01-01T09:00:00+01:00","end":"2024-01-01T09:00:00Z"},{"summary":"Off","start":"2024-01-02"}]`
+	if err != nil || out != want {
+		t.Errorf("parse = %s, %v; want %s", out, err, want)
+	}
+}
+
+func TestICalToolErrors(t *testing.T) {
+	tool := icalTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "create", "start": "2024-05-01"}, "missing 'summary' argument"},
+		{map[string]interface{}{"operation": "create", "summary": "x"}, "missing 'start' argument"},
+		{map[string]interface{}{"operation": "create", "summary": "x", "start": "next tuesday"}, `invalid 'start' time "next tuesday"`},
+		{map[string]interface{}{"operation": "create", "summary": "x", "start": "2024-05-01 10:00", "end": "2024-05-01 09:00"}, "'end' (2024-05-01 09:00) must be after 'start'"},
+		{map[string]interface{}{"operation": "create", "summary": "x", "start": "2024-05-01", "end": "2024-05-01 09:00"}, "must use the same kind of time"},
+		{map[string]interface{}{"operation": "parse", "input": "BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT"}, `event "x" has no DTSTART`},
+		{map[string]interface{}{"operation": "parse", "input": "BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT"}, `invalid DTSTART "tomorrow"`},
+		{map[string]interface{}{"operation": "parse", "input": "BEGIN:VEVENT\nSUMMARY:x\nDTSTART:20240101"}, "missing END:VEVENT"},
+		{map[string]interface{}{"operation": "parse", "input": "garbage"}, "invalid iCalendar line 1"},
+		{map[string]interface{}{"operation": "delete"}, "unsupported operation: delete"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// irregularPlurals maps singular nouns to plurals that don't follow the rules.
+var irregularPlurals = map[string]string{
+	"child":      "children",
+	"person":     "people",
+	"man":        "men",
+	"woman":      "women",
+	"mouse":      "mice",
+	"goose":      "geese",
+	"foot":       "feet",
+	"tooth":      "teeth",
+	"ox":         "oxen",
+	"cactus":     "cacti",
+	"fungus":     "fungi",
+	"criterion":  "criteria",
+	"phenomenon": "phenomena",
+	"analysis":   "analyses",
+	"crisis":     "crises",
+	"thesis":     "theses",
+	"leaf":       "leaves",
+	"half":       "halves",
+	"wolf":       "wolves",
+	"calf":       "calves",
+	"shelf":      "shelves",
+	"loaf":       "loaves",
+	"thief":      "thieves",
+	"knife":      "knives",
+	"wife":       "wives",
+	"life":       "lives",
+	"hero":       "heroes",
+	"potato":     "potatoes",
+	"tomato":     "tomatoes",
+	"echo":       "echoes",
+	"veto":       "vetoes",
+}
+
+// irregularSingulars is the reverse of irregularPlurals.
+var irregularSingulars = func() map[string]string {
+	m := make(map[string]string, len(irregularPlurals))
+	for singular, plural := range irregularPlurals {
+		m[plural] = singular
+	}
+	return m
+}()
+
+// uncountableNouns have the same singular and plural form.
+var uncountableNouns = map[string]bool{
+	"sheep": true, "fish": true, "deer": true, "series": true, "species": true,
+	"information": true, "rice": true, "money": true, "equipment": true, "news": true,
+}
+
+// NewInflectTool returns a tool that inflects English words: pluralize,
+// singularize, capitalize and title. Pluralization uses the regular English
+// suffix rules plus a table of common irregular and uncountable nouns.
+func NewInflectTool() Tool {
+	return Tool{
+		Name:        "inflect",
+		Description: "A tool that pluralizes, singularizes, capitalizes or title-cases English words.",
+		Args: map[string]string{
+			"word":      "string",
+			"operation": "string (e.g., 'pluralize', 'singularize', 'capitalize', 'title')",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			word, ok := args["word"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'word' argument")
+			}
+			op, ok := args["operation"].(string)
+			if !ok {
+				return word, fmt.Errorf("missing 'operation' argument")
+			}
+
+			switch op {
+			case "pluralize":
+				return matchCase(word, pluralize(strings.ToLower(word))), nil
+			case "singularize":
+				return matchCase(word, singularize(strings.ToLower(word))), nil
+			case "capitalize":
+				return capitalize(word), nil
+			case "title":
+				return titleCase(word), nil
+			default:
+				return word, fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// pluralize returns the plural of a lowercase English noun.
+func pluralize(word string) string {
+	if word == "" || uncountableNouns[word] {
+		return word
+	}
+	if plural, ok := irregularPlurals[word]; ok {
+		return plural
+	}
+	switch {
+	case hasAnySuffix(word, "s", "x", "z", "ch", "sh"):
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !isVowel(rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	}
+	return word + "s"
+}
+
+// singularize returns the singular of a lowercase English noun.
+func singularize(word string) string {
+	if word == "" || uncountableNouns[word] {
+		return word
+	}
+	if singular, ok := irregularSingulars[word]; ok {
+		return singular
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case hasAnySuffix(word, "sses", "xes", "zes", "ches", "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// matchCase applies the capitalization style of original to word.
+func matchCase(original, word string) string {
+	switch {
+	case original != "" && original == strings.ToUpper(original) && original != strings.ToLower(original):
+		return strings.ToUpper(word)
+	case original != "" && unicode.IsUpper([]rune(original)[0]):
+		return capitalize(word)
+	}
+	return word
+}
+
+// capitalize upper-cases the first letter of s, leaving the rest untouched.
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// titleCase capitalizes every word of s, lower-casing the remaining letters.
+// Words are separated by spaces or hyphens.
+func titleCase(s string) string {
+	r := []rune(strings.ToLower(s))
+	start := true
+	for i, c := range r {
+		if start && unicode.IsLetter(c) {
+			r[i] = unicode.ToUpper(c)
+		}
+		start = c == ' ' || c == '-'
+	}
+	return string(r)
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}
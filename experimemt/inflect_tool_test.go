@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestInflectTool(t *testing.T) {
+	tool := NewInflectTool()
+	tests := []struct {
+		op, word, want string
+	}{
+		{"pluralize", "cat", "cats"},
+		{"pluralize", "box", "boxes"},
+		{"pluralize", "church", "churches"},
+		{"pluralize", "city", "cities"},
+		{"pluralize", "day", "days"},
+		{"pluralize", "child", "children"},
+		{"pluralize", "Person", "People"},
+		{"pluralize", "KNIFE", "KNIVES"},
+		{"pluralize", "sheep", "sheep"},
+		{"singularize", "cats", "cat"},
+		{"singularize", "boxes", "box"},
+		{"singularize", "classes", "class"},
+		{"singularize", "cities", "city"},
+		{"singularize", "children", "child"},
+		{"singularize", "Mice", "Mouse"},
+		{"singularize", "analyses", "analysis"},
+		{"singularize", "status", "status"},
+		{"singularize", "glass", "glass"},
+		{"singularize", "news", "news"},
+		{"capitalize", "hello world", "Hello world"},
+		{"capitalize", "éclair", "Éclair"},
+		{"title", "the LORD of the-rings", "The Lord Of The-Rings"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"word": tt.word, "operation": tt.op})
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %q, %v; want %q", tt.op, tt.word, got, err, tt.want)
+		}
+	}
+}
+
+func TestInflectToolUnknownOperation(t *testing.T) {
+	got, err := NewInflectTool().Function(map[string]interface{}{"word": "Cat", "operation": "reverse"})
+	if err == nil || err.Error() != "unsupported operation: reverse" || got != "Cat" {
+		t.Errorf("got %q, %v; want the word unchanged with an error", got, err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "strings"
+
+// InjectInstruction queues guidance from the user for a run in progress. It
+// is safe to call from another goroutine. Before its next model call the run
+// prepends the instruction to the prompt, and keeps it there for the rest of
+// the run, as "Updated instruction from user: ...". Instructions injected
+// while no run is in progress are picked up by the next one.
+func (a *Agent) InjectInstruction(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	a.instructionsMu.Lock()
+	defer a.instructionsMu.Unlock()
+	a.instructions = append(a.instructions, text)
+}
+
+// takeInstructions returns and clears the queued instructions.
+func (a *Agent) takeInstructions() []string {
+	a.instructionsMu.Lock()
+	defer a.instructionsMu.Unlock()
+	taken := a.instructions
+	a.instructions = nil
+	return taken
+}
+
+// withInstructions prepends the user's injected instructions to prompt.
+func withInstructions(prompt string, instructions []string) string {
+	if len(instructions) == 0 {
+		return prompt
+	}
+	var sb strings.Builder
+	for _, text := range instructions {
+		sb.WriteString("Updated instruction from user: " + text + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(prompt)
+	return sb.String()
+}
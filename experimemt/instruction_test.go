@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInjectInstructionRedirectsRun(t *testing.T) {
+	const instruction = "Updated instruction from user: use metric units"
+	var prompts []string
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		switch {
+		case strings.Contains(prompt, "Observation: 10 km"):
+			return "Final Answer: 10 km", nil
+		case strings.Contains(prompt, instruction):
+			return `Action: {"name": "metric", "arguments": {}}`, nil
+		default:
+			return `Action: {"name": "imperial", "arguments": {}}`, nil
+		}
+	})
+	var called []string
+	agent.AddTool(Tool{Name: "imperial", Function: func(map[string]interface{}) (string, error) {
+		called = append(called, "imperial")
+		agent.InjectInstruction("  use metric units \n") // the user steps in mid-run
+		return "6.2 miles", nil
+	}})
+	agent.AddTool(Tool{Name: "metric", Function: func(map[string]interface{}) (string, error) {
+		called = append(called, "metric")
+		return "10 km", nil
+	}})
+
+	got, err := agent.Run(context.Background(), historyPath(t), "how far is it?", nil)
+	if err != nil || got != "10 km" {
+		t.Fatalf("Run = %q, %v; want 10 km", got, err)
+	}
+	if strings.Join(called, ",") != "imperial,metric" {
+		t.Errorf("tools called = %v, want the instruction to switch to metric", called)
+	}
+	if strings.Contains(prompts[0], instruction) {
+		t.Error("first prompt has the instruction before it was given")
+	}
+	for i, prompt := range prompts[1:] {
+		if !strings.HasPrefix(prompt, instruction+"\n\n") {
+			t.Errorf("prompt %d does not start with the instruction:\n%s", i+1, prompt)
+		}
+	}
+}
+
+func TestInjectInstructionQueuing(t *testing.T) {
+	agent := ScriptedAgent([]string{"Final Answer: ok"})
+	agent.InjectInstruction("   ")
+	agent.InjectInstruction("be brief")
+	agent.InjectInstruction("answer in French")
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+	prompt := agent.Client.(*ScriptedClient).Prompts()[0]
+	want := "Updated instruction from user: be brief\nUpdated instruction from user: answer in French\n\n"
+	if !strings.HasPrefix(prompt, want) {
+		t.Errorf("prompt does not start with the queued instructions:\n%s", prompt)
+	}
+	if got := agent.takeInstructions(); len(got) != 0 {
+		t.Errorf("instructions left after the run: %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ipAPIURL is the default ip_info provider. It needs no API key.
+const ipAPIURL = "http://ip-api.com/json"
+
+// domainPattern matches a fully qualified domain name such as "example.com".
+var domainPattern = regexp.MustCompile(`(?i)^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// NewIPInfoTool returns a tool that looks up the location and network owner
+// of an IP address or domain through an ip-api.com compatible endpoint, e.g.
+// http://ip-api.com/json. The endpoint is called as endpoint/<ip or domain>
+// and must answer with {"status": "success" | "fail", "message", ...}. Only
+// the endpoint's host may be contacted, and only at a public address.
+func NewIPInfoTool(endpoint string) Tool {
+	return NewIPInfoToolWithClient(endpoint, SafeHTTPClient([]string{urlHost(endpoint)}))
+}
+
+// NewIPInfoToolWithClient is like NewIPInfoTool but calls the endpoint using
+// client.
+func NewIPInfoToolWithClient(endpoint string, client *http.Client) Tool {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return Tool{
+		Name:        "ip_info",
+		Description: "A tool that looks up the geolocation and network owner (ISP, organization, AS number) of a public IP address or domain.",
+		Args: map[string]string{
+			"ip":     "string (an IPv4 or IPv6 address)",
+			"domain": "string (a domain name, used when 'ip' is not given)",
+		},
+		OptionalArgs: []string{"ip", "domain"},
+		Function: func(args map[string]interface{}) (string, error) {
+			ip, _ := args["ip"].(string)
+			domain, _ := args["domain"].(string)
+			ip, domain = strings.TrimSpace(ip), strings.TrimSpace(strings.TrimSuffix(domain, "."))
+
+			var query string
+			switch {
+			case ip != "":
+				parsed := net.ParseIP(ip)
+				if parsed == nil {
+					return "", fmt.Errorf("invalid IP address %q", ip)
+				}
+				if !isPublicIP(parsed) {
+					return "", fmt.Errorf("%s is not a public IP address and has no public record", ip)
+				}
+				query = parsed.String()
+			case domain != "":
+				if !domainPattern.MatchString(domain) {
+					return "", fmt.Errorf("invalid domain name %q", domain)
+				}
+				query = strings.ToLower(domain)
+			default:
+				return "", fmt.Errorf("missing 'ip' or 'domain' argument")
+			}
+
+			var info struct {
+				Status     string  `json:"status,omitempty"`
+				Message    string  `json:"message,omitempty"`
+				Query      string  `json:"query"`
+				Country    string  `json:"country,omitempty"`
+				RegionName string  `json:"regionName,omitempty"`
+				City       string  `json:"city,omitempty"`
+				Lat        float64 `json:"lat,omitempty"`
+				Lon        float64 `json:"lon,omitempty"`
+				Timezone   string  `json:"timezone,omitempty"`
+				ISP        string  `json:"isp,omitempty"`
+				Org        string  `json:"org,omitempty"`
+				AS         string  `json:"as,omitempty"`
+			}
+			if err := getJSON(client, endpoint+"/"+url.PathEscape(query), &info); err != nil {
+				return "", fmt.Errorf("ip_info lookup failed: %v", err)
+			}
+			if info.Status != "success" {
+				if info.Message == "" {
+					info.Message = "no details given"
+				}
+				return "", fmt.Errorf("ip_info lookup for %s failed: %s", query, info.Message)
+			}
+
+			info.Status, info.Message = "", ""
+			out, err := json.Marshal(info)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIPInfoTool(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		query := strings.TrimPrefix(r.URL.Path, "/json/")
+		if query == "192.0.2.1" || query == "1.1.1.1" {
+			fmt.Fprintf(w, `{"status": "fail", "message": "reserved range", "query": %q}`, query)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "success", "query": %q, "country": "United States", "city": "Mountain View", "isp": "Google LLC", "as": "AS15169"}`, query)
+	}))
+	defer srv.Close()
+	tool := NewIPInfoToolWithClient(srv.URL+"/json/", srv.Client())
+
+	got, err := tool.Function(map[string]interface{}{"ip": " 8.8.8.8 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"query":"8.8.8.8","country":"United States","city":"Mountain View","isp":"Google LLC","as":"AS15169"}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := tool.Function(map[string]interface{}{"domain": "Example.COM."}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/json/example.com"; paths[len(paths)-1] != want {
+		t.Errorf("domain lookup requested %q, want %q", paths[len(paths)-1], want)
+	}
+
+	_, err = tool.Function(map[string]interface{}{"ip": "1.1.1.1"})
+	if err == nil || !strings.Contains(err.Error(), "reserved range") {
+		t.Errorf("failed lookup: got error %v, want the provider's message", err)
+	}
+}
+
+func TestIPInfoToolRejectsBadInput(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+	defer srv.Close()
+	tool := NewIPInfoToolWithClient(srv.URL, srv.Client())
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "missing 'ip' or 'domain' argument"},
+		{map[string]interface{}{"ip": "999.1.1.1"}, "invalid IP address"},
+		{map[string]interface{}{"ip": "10.0.0.1"}, "not a public IP address"},
+		{map[string]interface{}{"ip": "::1"}, "not a public IP address"},
+		{map[string]interface{}{"domain": "localhost"}, "invalid domain name"},
+		{map[string]interface{}{"domain": "a/b.com"}, "invalid domain name"},
+	}
+	for _, tt := range tests {
+		_, err := tool.Function(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got error %v, want one containing %q", tt.args, err, tt.want)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("invalid input reached the endpoint %d times", calls)
+	}
+}
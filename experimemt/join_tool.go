@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// maxJoinRows caps the rows returned by the join tool so a many-to-many join
+// cannot flood the prompt.
+const maxJoinRows = 200
+
+// NewJoinTool returns a tool that joins two lists of JSON objects on a key.
+// Key values are compared by their text, so 1 and "1" match; rows whose key
+// is missing or is not a string, number or boolean never match anything. Left
+// fields win name clashes, and clashing right fields are kept as "right_<name>".
+func NewJoinTool() Tool {
+	return Tool{
+		Name:        "join",
+		Description: "A tool that joins two lists of objects on a shared key, like a SQL join.",
+		Args: map[string]string{
+			"left":  "list of objects",
+			"right": "list of objects",
+			"key":   "string (the field to join on)",
+			"type":  "string (e.g., 'inner', 'left', 'outer'; defaults to 'inner')",
+		},
+		OptionalArgs: []string{"type"},
+		Function: func(args map[string]interface{}) (string, error) {
+			left, err := objectListArg(args, "left")
+			if err != nil {
+				return "", err
+			}
+			right, err := objectListArg(args, "right")
+			if err != nil {
+				return "", err
+			}
+			key, ok := args["key"].(string)
+			if !ok || key == "" {
+				return "", fmt.Errorf("missing 'key' argument")
+			}
+			joinType, ok := args["type"].(string)
+			if !ok || joinType == "" {
+				joinType = "inner"
+			}
+			if joinType != "inner" && joinType != "left" && joinType != "outer" {
+				return "", fmt.Errorf("unsupported join type: %s", joinType)
+			}
+
+			byKey := make(map[string][]int)
+			for i, row := range right {
+				if k, ok := joinKey(row, key); ok {
+					byKey[k] = append(byKey[k], i)
+				}
+			}
+
+			var rows []map[string]interface{}
+			total := 0
+			add := func(row map[string]interface{}) {
+				total++
+				if len(rows) < maxJoinRows {
+					rows = append(rows, row)
+				}
+			}
+			matched := make([]bool, len(right))
+			for _, l := range left {
+				var matches []int
+				if k, ok := joinKey(l, key); ok {
+					matches = byKey[k]
+				}
+				for _, i := range matches {
+					matched[i] = true
+					add(mergeRows(l, right[i], key))
+				}
+				if len(matches) == 0 && joinType != "inner" {
+					add(mergeRows(l, nil, key))
+				}
+			}
+			if joinType == "outer" {
+				for i, r := range right {
+					if !matched[i] {
+						add(mergeRows(nil, r, key))
+					}
+				}
+			}
+
+			if rows == nil {
+				rows = []map[string]interface{}{}
+			}
+			result := struct {
+				Rows      []map[string]interface{} `json:"rows"`
+				Count     int                      `json:"count"`
+				Truncated bool                     `json:"truncated,omitempty"`
+			}{rows, total, total > len(rows)}
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// objectListArg reads a list of JSON objects, sent either as an array or as a
+// string holding one.
+func objectListArg(args map[string]interface{}, key string) ([]map[string]interface{}, error) {
+	v := args[key]
+	if s, ok := v.(string); ok {
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("invalid '%s' argument: %v", key, err)
+		}
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid '%s' argument, expected a list of objects", key)
+	}
+	rows := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("item %d of '%s' is not an object", i, key)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// joinKey returns the text of row[key] if it is a scalar that can be joined on.
+func joinKey(row map[string]interface{}, key string) (string, bool) {
+	switch v := row[key].(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// mergeRows combines a left and right row, either of which may be nil.
+func mergeRows(left, right map[string]interface{}, key string) map[string]interface{} {
+	row := make(map[string]interface{}, len(left)+len(right))
+	for k, v := range left {
+		row[k] = v
+	}
+	for k, v := range right {
+		if _, clash := row[k]; clash {
+			if k == key {
+				continue
+			}
+			k = "right_" + k
+		}
+		row[k] = v
+	}
+	return row
+}
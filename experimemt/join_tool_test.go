@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJoinTool(t *testing.T) {
+	users := []interface{}{
+		map[string]interface{}{"id": 1.0, "name": "Ann"},
+		map[string]interface{}{"id": "2", "name": "Bob"},
+		map[string]interface{}{"name": "Cy"}, // no key: never matches
+		map[string]interface{}{"id": []interface{}{3.0}, "name": "Di"},
+	}
+	orders := `[{"id": "1", "name": "book", "total": 12}, {"id": 2, "name": "pen", "total": 3}, {"id": 1, "name": "lamp", "total": 40}, {"id": 9, "name": "mug", "total": 5}]`
+
+	tests := []struct {
+		joinType string
+		want     string
+	}{
+		{"", `{"rows":[` +
+			`{"id":1,"name":"Ann","right_name":"book","total":12},` +
+			`{"id":1,"name":"Ann","right_name":"lamp","total":40},` +
+			`{"id":"2","name":"Bob","right_name":"pen","total":3}],"count":3}`},
+		{"left", `{"rows":[` +
+			`{"id":1,"name":"Ann","right_name":"book","total":12},` +
+			`{"id":1,"name":"Ann","right_name":"lamp","total":40},` +
+			`{"id":"2","name":"Bob","right_name":"pen","total":3},` +
+			`{"name":"Cy"},` +
+			`{"id":[3],"name":"Di"}],"count":5}`},
+		{"outer", `{"rows":[` +
+			`{"id":1,"name":"Ann","right_name":"book","total":12},` +
+			`{"id":1,"name":"Ann","right_name":"lamp","total":40},` +
+			`{"id":"2","name":"Bob","right_name":"pen","total":3},` +
+			`{"name":"Cy"},` +
+			`{"id":[3],"name":"Di"},` +
+			`{"id":9,"name":"mug","total":5}],"count":6}`},
+	}
+	for _, tt := range tests {
+		got, err := NewJoinTool().Function(map[string]interface{}{"left": users, "right": orders, "key": "id", "type": tt.joinType})
+		if err != nil || got != tt.want {
+			t.Errorf("%q join = %s, %v\nwant %s", tt.joinType, got, err, tt.want)
+		}
+	}
+}
+
+func TestJoinToolCapsRows(t *testing.T) {
+	var left, right []interface{}
+	for i := 0; i < 15; i++ {
+		left = append(left, map[string]interface{}{"k": "x", "l": float64(i)})
+		right = append(right, map[string]interface{}{"k": "x", "r": float64(i)})
+	}
+	got, err := NewJoinTool().Function(map[string]interface{}{"left": left, "right": right, "key": "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Rows      []map[string]interface{} `json:"rows"`
+		Count     int                      `json:"count"`
+		Truncated bool                     `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(got), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != maxJoinRows || result.Count != 225 || !result.Truncated {
+		t.Errorf("got %d rows of %d, truncated %v; want %d of 225, truncated", len(result.Rows), result.Count, result.Truncated, maxJoinRows)
+	}
+}
+
+func TestJoinToolErrors(t *testing.T) {
+	rows := []interface{}{map[string]interface{}{"id": 1.0}}
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"left": rows, "right": rows}, "missing 'key'"},
+		{map[string]interface{}{"left": rows, "right": rows, "key": "id", "type": "cross"}, "unsupported join type"},
+		{map[string]interface{}{"left": "[{", "right": rows, "key": "id"}, "invalid 'left' argument"},
+		{map[string]interface{}{"left": rows, "right": []interface{}{1.0}, "key": "id"}, "item 0 of 'right' is not an object"},
+		{map[string]interface{}{"left": rows, "key": "id"}, "missing or invalid 'right'"},
+	}
+	for _, tt := range tests {
+		if _, err := NewJoinTool().Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("join(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// Caps that keep a jq expression from running away.
+const (
+	jqTimeout    = 5 * time.Second
+	maxJQResults = 1000
+)
+
+// NewJQTool returns a tool that runs a jq expression, e.g. ".items[] | .name",
+// over JSON input. The outputs are returned as a JSON array, which is empty
+// when nothing matches.
+func NewJQTool() Tool {
+	return Tool{
+		Name:        "jq",
+		Description: "A tool that queries or transforms JSON with a jq expression, e.g. '.users[] | select(.age > 30) | .name'. Returns a JSON array of the results.",
+		Args: map[string]string{
+			"input": "string (JSON document)",
+			"expr":  "string (jq expression)",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			var input interface{}
+			switch v := args["input"].(type) {
+			case string:
+				if err := json.Unmarshal([]byte(v), &input); err != nil {
+					return "", fmt.Errorf("invalid JSON input: %v", err)
+				}
+			case nil:
+				return "", fmt.Errorf("missing 'input' argument")
+			default:
+				input = v // already decoded by the tool call
+			}
+			expr, ok := args["expr"].(string)
+			if !ok || strings.TrimSpace(expr) == "" {
+				return "", fmt.Errorf("missing 'expr' argument")
+			}
+
+			query, err := gojq.Parse(expr)
+			if err != nil {
+				return "", fmt.Errorf("invalid jq expression: %v", err)
+			}
+			code, err := gojq.Compile(query)
+			if err != nil {
+				return "", fmt.Errorf("invalid jq expression: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), jqTimeout)
+			defer cancel()
+			results := []interface{}{}
+			iter := code.RunWithContext(ctx, input)
+			for {
+				v, ok := iter.Next()
+				if !ok {
+					break
+				}
+				if err, ok := v.(error); ok {
+					if ctx.Err() != nil {
+						return "", fmt.Errorf("jq expression took longer than %v", jqTimeout)
+					}
+					return "", fmt.Errorf("jq error: %v", err)
+				}
+				if len(results) == maxJQResults {
+					return "", fmt.Errorf("jq expression produced more than %d results", maxJQResults)
+				}
+				results = append(results, v)
+			}
+
+			out, err := json.Marshal(results)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const usersJSON = `{"users": [{"name": "Ann", "age": 34}, {"name": "Bob", "age": 27}, {"name": "Cy", "age": 41}]}`
+
+func TestJQTool(t *testing.T) {
+	tool := NewJQTool()
+	tests := []struct {
+		input interface{}
+		expr  string
+		want  string
+	}{
+		{usersJSON, ".users[0].name", `["Ann"]`},
+		{usersJSON, ".users[] | .name", `["Ann","Bob","Cy"]`},
+		{usersJSON, "[.users[] | select(.age > 30) | .name]", `[["Ann","Cy"]]`},
+		{usersJSON, ".users | map(.age) | add", `[102]`},
+		{usersJSON, ".users[] | select(.age > 90)", `[]`},
+		{usersJSON, ".missing", `[null]`},
+		{map[string]interface{}{"n": 2.0}, ".n * 3", `[6]`}, // already decoded by the tool call
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"input": tt.input, "expr": tt.expr})
+		if err != nil || got != tt.want {
+			t.Errorf("jq %s = %s, %v; want %s", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestJQToolErrors(t *testing.T) {
+	tool := NewJQTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"input": usersJSON, "expr": ".users[] |"}, "invalid jq expression"},
+		{map[string]interface{}{"input": usersJSON, "expr": "nosuchfunc(1)"}, "invalid jq expression"},
+		{map[string]interface{}{"input": usersJSON, "expr": ".users + 1"}, "jq error"},
+		{map[string]interface{}{"input": "{not json", "expr": "."}, "invalid JSON input"},
+		{map[string]interface{}{"input": usersJSON, "expr": " "}, "missing 'expr' argument"},
+		{map[string]interface{}{"input": "0", "expr": "range(2000)"}, "more than 1000 results"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("jq %v: error = %v, want %q", tt.args["expr"], err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// jwtHashes maps the supported HMAC algorithms to their hash functions.
+var jwtHashes = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// DecodedJWT is the result of the jwt tool.
+type DecodedJWT struct {
+	Header map[string]interface{} `json:"header"`
+	Claims map[string]interface{} `json:"claims"`
+	Valid  *bool                  `json:"valid,omitempty"`
+}
+
+// NewJWTTool returns a tool that inspects JSON Web Tokens. It supports two
+// operations:
+//
+//   - decode: returns the header and claims without checking the signature
+//   - verify: checks an HS256, HS384 or HS512 signature against secret and
+//     returns the header and claims if it matches
+//
+// Results are JSON objects; malformed tokens and bad signatures are errors.
+func NewJWTTool() Tool {
+	return Tool{
+		Name:        "jwt",
+		Description: "A tool that decodes JSON Web Tokens and verifies their HMAC (HS256/HS384/HS512) signatures.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'decode', 'verify')",
+			"token":     "string (the JWT)",
+			"secret":    "string (shared HMAC secret, only for 'verify')",
+		},
+		OptionalArgs: []string{"secret"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			token, ok := args["token"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'token' argument")
+			}
+			decoded, signingInput, signature, err := decodeJWT(strings.TrimSpace(token))
+			if err != nil {
+				return "", err
+			}
+
+			switch op {
+			case "decode":
+			case "verify":
+				secret, ok := args["secret"].(string)
+				if !ok {
+					return "", fmt.Errorf("missing 'secret' argument")
+				}
+				alg, _ := decoded.Header["alg"].(string)
+				newHash, ok := jwtHashes[alg]
+				if !ok {
+					return "", fmt.Errorf("unsupported signing algorithm %q: only HS256, HS384 and HS512 can be verified", alg)
+				}
+				mac := hmac.New(newHash, []byte(secret))
+				mac.Write([]byte(signingInput))
+				if !hmac.Equal(mac.Sum(nil), signature) {
+					return "", fmt.Errorf("invalid signature: the token was not signed with this secret or has been tampered with")
+				}
+				valid := true
+				decoded.Valid = &valid
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+
+			out, err := json.Marshal(decoded)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode token: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// decodeJWT splits a compact JWT into its decoded header and claims, the
+// signing input the signature covers and the raw signature.
+func decodeJWT(token string) (DecodedJWT, string, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return DecodedJWT{}, "", nil, fmt.Errorf("malformed token: expected 3 dot-separated parts, found %d", len(parts))
+	}
+
+	var decoded DecodedJWT
+	if err := decodeJWTSegment(parts[0], &decoded.Header); err != nil {
+		return DecodedJWT{}, "", nil, fmt.Errorf("malformed token header: %v", err)
+	}
+	if err := decodeJWTSegment(parts[1], &decoded.Claims); err != nil {
+		return DecodedJWT{}, "", nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return DecodedJWT{}, "", nil, fmt.Errorf("malformed token signature: %v", err)
+	}
+	return decoded, parts[0] + "." + parts[1], signature, nil
+}
+
+// decodeJWTSegment decodes a base64url JSON segment into v.
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,18 @@
+package main
+
+import "context"
+
+// LLMClient produces a model response for a prompt. Agent.Client accepts any
+// implementation in place of the Ollama server.
+type LLMClient interface {
+	Generate(ctx context.Context, prompt string) (string, error)
+}
+
+// LLMClientFunc adapts a function, such as Agent.CallOllamaContext, to the
+// LLMClient interface.
+type LLMClientFunc func(ctx context.Context, prompt string) (string, error)
+
+// Generate calls f(ctx, prompt).
+func (f LLMClientFunc) Generate(ctx context.Context, prompt string) (string, error) {
+	return f(ctx, prompt)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Caps that keep log_parse responsive on large files.
+const (
+	logParseTimeout   = 10 * time.Second
+	maxLogLineBytes   = 1 << 20
+	maxLogParseGroups = 100
+)
+
+// NewLogParseTool returns a tool that matches the lines of a log file under
+// root against a regular expression and counts the matches, optionally per
+// distinct value of some of its named groups, e.g. errors per hour with
+// `^(?P<hour>\S+T\d\d):.*ERROR` grouped by "hour". The file is read line by
+// line, and scanning stops after logParseTimeout, reporting the counts so far.
+func NewLogParseTool(root string) Tool {
+	return Tool{
+		Name:        "log_parse",
+		Description: "A tool that counts the lines of a log file matching a regular expression, optionally grouped by the values of named groups such as (?P<hour>...). Paths are relative to the log folder.",
+		Args: map[string]string{
+			"path":     "string (path to the log file)",
+			"pattern":  "string (Go regular expression, e.g. '(?P<level>ERROR|WARN)')",
+			"group_by": "list of strings (optional, named groups of the pattern to count by)",
+		},
+		OptionalArgs: []string{"group_by"},
+		Function: func(args map[string]interface{}) (string, error) {
+			path, _ := args["path"].(string)
+			full, err := sandboxPath(root, path)
+			if err != nil {
+				return "", err
+			}
+			pattern, ok := args["pattern"].(string)
+			if !ok || pattern == "" {
+				return "", fmt.Errorf("missing 'pattern' argument")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", fmt.Errorf("invalid pattern: %v", err)
+			}
+			var groupBy []string
+			if _, ok := args["group_by"]; ok {
+				if groupBy, err = stringListArg(args, "group_by"); err != nil {
+					return "", err
+				}
+			}
+			indexes := make([]int, 0, len(groupBy))
+			for _, name := range groupBy {
+				i := re.SubexpIndex(name)
+				if name == "" || i < 0 {
+					return "", fmt.Errorf("pattern has no group named %q", name)
+				}
+				indexes = append(indexes, i)
+			}
+
+			f, err := os.Open(full)
+			if err != nil {
+				return "", fmt.Errorf("cannot open log %s: %v", path, err)
+			}
+			defer f.Close()
+
+			var lines, matched int
+			counts := make(map[string]int)
+			timedOut := false
+			deadline := time.Now().Add(logParseTimeout)
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineBytes)
+			for scanner.Scan() {
+				lines++
+				if lines%1000 == 0 && time.Now().After(deadline) {
+					timedOut = true
+					break
+				}
+				m := re.FindStringSubmatch(scanner.Text())
+				if m == nil {
+					continue
+				}
+				matched++
+				if len(indexes) > 0 {
+					values := make([]string, len(indexes))
+					for j, i := range indexes {
+						values[j] = m[i]
+					}
+					counts[strings.Join(values, "\x00")]++
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("cannot read log %s: %v", path, err)
+			}
+
+			type group struct {
+				Key   map[string]string `json:"key"`
+				Count int               `json:"count"`
+			}
+			keys := make([]string, 0, len(counts))
+			for key := range counts {
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				if counts[keys[i]] != counts[keys[j]] {
+					return counts[keys[i]] > counts[keys[j]]
+				}
+				return keys[i] < keys[j]
+			})
+			groups := make([]group, len(keys))
+			for i, key := range keys {
+				values := strings.Split(key, "\x00")
+				groups[i] = group{Key: make(map[string]string, len(groupBy)), Count: counts[key]}
+				for j, name := range groupBy {
+					groups[i].Key[name] = values[j]
+				}
+			}
+
+			result := struct {
+				Lines     int     `json:"lines"`
+				Matched   int     `json:"matched"`
+				Groups    []group `json:"groups,omitempty"`
+				Truncated bool    `json:"truncated,omitempty"`
+				TimedOut  bool    `json:"timed_out,omitempty"`
+			}{Lines: lines, Matched: matched, Groups: groups, TimedOut: timedOut}
+			if len(groups) > maxLogParseGroups {
+				result.Groups, result.Truncated = groups[:maxLogParseGroups], true
+			}
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleLog = `2024-05-01T09:12:01 INFO  started
+2024-05-01T09:15:42 ERROR disk full
+2024-05-01T09:59:59 WARN  slow request
+2024-05-01T10:01:13 ERROR disk full
+2024-05-01T10:30:00 ERROR timeout
+2024-05-01T11:00:00 INFO  stopped
+`
+
+// logParseTool returns a log_parse tool rooted at a new directory holding
+// sampleLog as app.log, and the directory.
+func logParseTool(t *testing.T) (Tool, string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "app.log"), []byte(sampleLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return NewLogParseTool(root), root
+}
+
+func TestLogParseTool(t *testing.T) {
+	tool, _ := logParseTool(t)
+	tests := []struct {
+		pattern string
+		groupBy []interface{}
+		want    string
+	}{
+		{`ERROR`, nil, `{"lines":6,"matched":3}`},
+		{`^(?P<hour>\S+T\d\d):.*ERROR`, []interface{}{"hour"},
+			`{"lines":6,"matched":3,"groups":[{"key":{"hour":"2024-05-01T10"},"count":2},{"key":{"hour":"2024-05-01T09"},"count":1}]}`},
+		{`T(?P<hour>\d\d):\S+ (?P<level>\w+)`, []interface{}{"level", "hour"},
+			`{"lines":6,"matched":6,"groups":[{"key":{"hour":"10","level":"ERROR"},"count":2},{"key":{"hour":"09","level":"ERROR"},"count":1},` +
+				`{"key":{"hour":"09","level":"INFO"},"count":1},{"key":{"hour":"11","level":"INFO"},"count":1},{"key":{"hour":"09","level":"WARN"},"count":1}]}`},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"path": "app.log", "pattern": tt.pattern}
+		if tt.groupBy != nil {
+			args["group_by"] = tt.groupBy
+		}
+		got, err := tool.Function(args)
+		if err != nil || got != tt.want {
+			t.Errorf("log_parse %s by %v =\n%s, %v\nwant\n%s", tt.pattern, tt.groupBy, got, err, tt.want)
+		}
+	}
+}
+
+func TestLogParseToolErrors(t *testing.T) {
+	tool, root := logParseTool(t)
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.log"), []byte("ERROR\n"), 0o644)
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"path": "app.log", "pattern": "(ERROR"}, "invalid pattern"},
+		{map[string]interface{}{"path": "app.log", "pattern": "(?P<level>ERROR)", "group_by": []interface{}{"hour"}}, `pattern has no group named "hour"`},
+		{map[string]interface{}{"path": "escape/secret.log", "pattern": "ERROR"}, "outside the allowed directory"},
+		{map[string]interface{}{"path": "missing.log", "pattern": "ERROR"}, "cannot open log missing.log"},
+		{map[string]interface{}{"path": "app.log"}, "missing 'pattern' argument"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
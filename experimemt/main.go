@@ -1,16 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Tool represents a function or capability the agent can use.
@@ -19,6 +29,92 @@ type Tool struct {
 	Description string
 	Function    func(args map[string]interface{}) (string, error)
 	Args        map[string]string // Maps argument names to their descriptions
+
+	// OptionalArgs names the entries of Args that may be left out, e.g. ones
+	// only some operations use. ValidateArgs requires all the others.
+	OptionalArgs []string
+
+	// Available reports whether the tool may be offered right now. Tools for
+	// which it returns false are left out of the prompt and refused if called.
+	// A nil Available means the tool is always available.
+	Available func() bool
+
+	// Timeout overrides Agent.ToolTimeout for this tool when positive.
+	Timeout time.Duration
+
+	// State, if set, describes what the tool currently holds, e.g. a TODO
+	// list. It is shown in the prompt when Agent.ShowToolState is set.
+	State func() string
+
+	// RequiresConfirmation marks tools with side effects, such as sending a
+	// message. Run only calls them once Agent.ConfirmToolCall approves.
+	RequiresConfirmation bool
+
+	// FormatObservation, if set, rewrites a successful result before it is
+	// shown to the model, e.g. rendering rows as a table or search hits as a
+	// numbered list.
+	FormatObservation func(result string) string
+
+	// MaxCallsPerRun caps how many times the tool may be called within one
+	// run; further calls are refused with an observation. Zero means no cap.
+	MaxCallsPerRun int
+
+	// CacheTTL, when positive, makes the agent reuse a successful result for
+	// the same arguments until it is this old, across runs. Suited to tools
+	// fetching slow-changing external data.
+	CacheTTL time.Duration
+
+	// Configure, if set, is called instead of Function. It lets a tool
+	// reconfigure the agent by returning a SystemUpdate along with its
+	// observation; see Agent.AllowSystemUpdates.
+	Configure func(args map[string]interface{}) (ToolResult, error)
+}
+
+// ToolResult is the outcome of a Configure tool call.
+type ToolResult struct {
+	Observation string
+
+	// SystemUpdate, if non-empty, replaces the agent's system prompt for the
+	// rest of the run and for later runs.
+	SystemUpdate string
+}
+
+// call runs the tool, using Configure when it is set.
+func (t Tool) call(args map[string]interface{}) (ToolResult, error) {
+	if t.Configure != nil {
+		return t.Configure(args)
+	}
+	obs, err := t.Function(args)
+	return ToolResult{Observation: obs}, err
+}
+
+// formatObservation applies FormatObservation, if set, to result.
+func (t Tool) formatObservation(result string) string {
+	if t.FormatObservation == nil {
+		return result
+	}
+	return t.FormatObservation(result)
+}
+
+// ValidateArgs checks that args holds every argument the tool declares in Args
+// other than its OptionalArgs, reporting all the missing ones at once.
+func (t Tool) ValidateArgs(args map[string]interface{}) error {
+	var missing []string
+	for name := range t.Args {
+		if args[name] == nil && !slices.Contains(t.OptionalArgs, name) {
+			missing = append(missing, "'"+name+"'")
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("missing required arguments for %s: %s", t.Name, strings.Join(missing, ", "))
+}
+
+// IsAvailable reports whether the tool is currently available.
+func (t Tool) IsAvailable() bool {
+	return t.Available == nil || t.Available()
 }
 
 // ToolInvocation represents the data extracted from the LLM's response
@@ -30,9 +126,14 @@ type ToolInvocation struct {
 
 // OllamaRequest is the structure for a prompt sent to the Ollama API.
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+
+	// KeepAlive is how long Ollama keeps the model loaded afterwards, e.g.
+	// "10m". Empty means the server default.
+	KeepAlive string `json:"keep_alive,omitempty"`
 }
 
 // OllamaResponse is the structure for the response from the Ollama API.
@@ -41,13 +142,331 @@ type OllamaResponse struct {
 	CreatedAt string `json:"created_at"`
 	Response  string `json:"response"`
 	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+
+	// DoneReason says why generation ended, e.g. "stop", or "length" when
+	// num_predict cut it off.
+	DoneReason string `json:"done_reason,omitempty"`
+
+	// Token usage, reported with the final response.
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
 }
 
+// defaultMaxSteps is how many model calls a single Run may make before giving
+// up, unless Agent.MaxSteps says otherwise.
+const defaultMaxSteps = 5
+
+// partialResponseMarker starts the history entry holding a response that was
+// cut short when its run was cancelled mid-stream.
+const partialResponseMarker = "[partial response, interrupted]"
+
+// ollamaCallTimeout bounds a whole CallOllama, CallOllamaStream or
+// CallOllamaContext request, so a stalled server cannot hang the caller.
+var ollamaCallTimeout = 60 * time.Second
+
+// maxTruncationContinues is how many times generate asks the model to carry
+// on from a response that Agent.MaxTokens cut off.
+const maxTruncationContinues = 2
+
+// ErrResponseTruncated is returned, along with the text so far, by model calls
+// whose response was cut off by Agent.MaxTokens.
+var ErrResponseTruncated = errors.New("model response truncated by the token limit")
+
+// maxContextRetries is how many times Run trims the history and retries when
+// the prompt no longer fits in the model's context window.
+const maxContextRetries = 2
+
+// OllamaError is returned by CallOllama when the server answers with a
+// non-200 status code.
+type OllamaError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *OllamaError) Error() string {
+	return fmt.Sprintf("Ollama request failed with status code %d: %s", e.StatusCode, e.Body)
+}
+
+// isContextLengthError reports whether err indicates that the prompt exceeded
+// the model's context window.
+func isContextLengthError(err error) bool {
+	var ollamaErr *OllamaError
+	if !errors.As(err, &ollamaErr) {
+		return false
+	}
+	body := strings.ToLower(ollamaErr.Body)
+	for _, hint := range []string{"context length", "context window", "prompt too long", "too many tokens"} {
+		if strings.Contains(body, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimHistory drops roughly the oldest half of history, cutting on a line
+// boundary so that no entry is split.
+func trimHistory(history string) string {
+	cut := len(history) / 2
+	if i := strings.Index(history[cut:], "\n"); i >= 0 {
+		return history[cut+i:]
+	}
+	return ""
+}
+
+// ErrRunStopped is returned by Run when the caller's stop channel fires before
+// the agent reaches a final answer.
+var ErrRunStopped = errors.New("agent run stopped before reaching a final answer")
+
+// ErrRetryBudgetExceeded is returned by Run when it uses up
+// Agent.MaxRetriesPerRun corrective retries.
+var ErrRetryBudgetExceeded = errors.New("agent run exceeded its retry budget")
+
+// ErrRunTimeout is returned by Run when it exceeds Agent.MaxRunDuration.
+var ErrRunTimeout = errors.New("agent run exceeded its maximum duration")
+
+// Ollama API endpoint paths, relative to the agent's base URL.
+const (
+	generatePath = "/api/generate"
+	chatPath     = "/api/chat"
+	tagsPath     = "/api/tags"
+	showPath     = "/api/show"
+)
+
 // Agent represents our agentic system.
 type Agent struct {
-	OllamaURL string
+	OllamaURL string // Base URL of the Ollama server, e.g. http://localhost:11434
 	Model     string
 	Tools     map[string]Tool
+
+	// MaxSteps is how many model calls a single Run may make before giving
+	// up. NewAgent sets it to 5; values below 1 also mean 5.
+	MaxSteps int
+
+	// VerifyToolUsage makes Run check that a final answer reflects the most
+	// recent tool observation, re-prompting once with a nudge if it doesn't.
+	VerifyToolUsage bool
+
+	// MaxRunDuration bounds the total wall-clock time of a single Run,
+	// regardless of how many steps it takes. Zero means no limit.
+	MaxRunDuration time.Duration
+
+	// ExplainFailures makes Run ask the model to explain a failed run instead
+	// of returning only a raw error.
+	ExplainFailures bool
+
+	// MaxRetriesPerRun caps the total number of corrective retries in one Run:
+	// context-length retries, re-prompts for malformed responses, failed tool
+	// calls and tool-usage nudges all count. Zero means no cap.
+	MaxRetriesPerRun int
+
+	// PromptIncludeTypes limits which history entry types (see EntryThought
+	// and friends) are shown to the model. The history file always keeps every
+	// entry. Empty means include all types.
+	PromptIncludeTypes []string
+
+	// MixedResponsePrecedence decides between a final answer and a tool call
+	// when a response contains both: PreferAuto (the default), PreferToolCall
+	// or PreferFinalAnswer.
+	MixedResponsePrecedence string
+
+	// NormalizeObservations trims tool results and collapses runs of blank
+	// lines before they are added to the history. NewAgent turns it on.
+	NormalizeObservations bool
+
+	// BeforeToolCall, if set, is called before every tool execution. When it
+	// returns an error the tool is skipped and the error becomes the
+	// observation, which makes it a single place for auditing and policy.
+	BeforeToolCall func(name string, args map[string]interface{}) error
+
+	// ConfirmToolCall is asked before every call to a tool with
+	// RequiresConfirmation set; the call is blocked unless it returns true.
+	// A nil ConfirmToolCall blocks all such calls.
+	ConfirmToolCall func(name string, args map[string]interface{}) bool
+
+	// Verbose logs every prompt, model response and tool call made by Run.
+	// It is off by default because those logs contain user content.
+	Verbose bool
+
+	// ToolTimeout bounds how long Run waits for a tool to return; a tool's own
+	// Timeout takes precedence. Zero means wait indefinitely.
+	ToolTimeout time.Duration
+
+	// AnswerFilters post-process the final answer, in order, before it is
+	// returned and saved to the history, e.g. to redact PII or strip a
+	// "Sure! Here's..." preamble.
+	AnswerFilters []func(string) string
+
+	// CanonicalizeArgs tidies tool arguments before dispatch: string values
+	// are trimmed and keys are matched case-insensitively to the tool's
+	// declared argument names.
+	CanonicalizeArgs bool
+
+	// FinalAnswerPrefix is the marker the model is told to start its final
+	// answer with, e.g. "FINAL:". It is matched case-insensitively. Empty
+	// means "Final Answer:".
+	FinalAnswerPrefix string
+
+	// AssistantLabel, UserLabel and ObservationLabel label the model's
+	// answers, the user's messages and tool results in the transcript, in
+	// both the prompt and saved history, e.g. "Human:". Empty means
+	// "Assistant:", "User:" and "Observation:".
+	AssistantLabel   string
+	UserLabel        string
+	ObservationLabel string
+
+	// ConfidenceFunc reports whether a finished run's answer deserves less
+	// trust, which sets RunResult.LowConfidence. Nil means LowConfidence.
+	ConfidenceFunc func(question string, result *RunResult) bool
+
+	// LowConfidenceDisclaimer, if set, is appended to answers flagged as low
+	// confidence so that callers using Run see the warning too.
+	LowConfidenceDisclaimer string
+
+	// SystemPrompt opens every prompt. Empty means defaultSystemPrompt. Set
+	// it before starting runs: a run reads it once when it starts, and
+	// updates from tools are written under systemPromptMu.
+	SystemPrompt string
+
+	// AllowSystemUpdates lets tools replace SystemPrompt through
+	// ToolResult.SystemUpdate. It is off by default so that a tool, or a model
+	// steering one, cannot rewrite the agent's instructions unless asked to.
+	AllowSystemUpdates bool
+
+	// ShowBudgetInPrompt tells the model how many tool calls it has left
+	// before Run gives up, nudging it to converge on an answer.
+	ShowBudgetInPrompt bool
+
+	// ShowToolState includes the State of every available tool that has one
+	// in the prompt, so the model can reason over e.g. its remaining TODOs.
+	ShowToolState bool
+
+	// MaxPromptTools caps how many tools are described in the prompt. When
+	// more are available, those whose name or description best overlaps the
+	// user input are chosen. Zero means describe them all.
+	MaxPromptTools int
+
+	// Seed, when set, is passed to the model as the generation seed on every
+	// call in the loop. With temperature 0 it makes a Run reproducible.
+	Seed *int64
+
+	// MaxTokens caps how many tokens the model may generate per call, as
+	// Ollama's num_predict. A response cut off by the cap is continued where
+	// it stopped, up to maxTruncationContinues times. Zero means no cap.
+	MaxTokens int
+
+	// Tracer, if set, receives an OpenTelemetry span for every run, with a
+	// child span for each model call and tool call. Nil means no tracing.
+	Tracer trace.Tracer
+
+	// HistoryStore, if set, holds the conversation history in place of the
+	// history file passed to Run; see NewHistoryStore.
+	HistoryStore HistoryStore
+
+	// RequiredAnswerSchema, if set, is a JSON Schema document the final
+	// answer must satisfy, e.g. {"type": "object", "required": ["city"]}. An
+	// answer that doesn't is sent back to the model once to be reformatted;
+	// if that fails too, Run returns ErrAnswerSchemaMismatch.
+	RequiredAnswerSchema string
+
+	// AuditLogPath, if set, is a JSONL file to which every tool call is
+	// appended as an AuditEntry, with sensitive-looking arguments redacted.
+	// The "session_id" run metadata, or else a random ID, identifies the run.
+	AuditLogPath string
+
+	// AllowBareAnswer accepts a plain conversational reply, with neither a
+	// tool call nor the final answer marker, as the final answer instead of
+	// asking the model to retry. Replies that look like a broken tool call
+	// or like planning are still retried.
+	AllowBareAnswer bool
+
+	// StrictMode demands that every response be a tool call or a final
+	// answer: the prompt says so, and any other text is rejected and retried
+	// even if AllowBareAnswer is set.
+	StrictMode bool
+
+	// KeepAlive asks Ollama to keep the model loaded for this long after each
+	// request, including Warmup's. Zero leaves it to the server.
+	KeepAlive time.Duration
+
+	// InjectionDetector, if set, is asked about every successful tool result.
+	// Results it flags are fenced off behind a warning telling the model not
+	// to follow instructions inside them. DetectInjection is a ready-made
+	// heuristic; nil disables the check.
+	InjectionDetector func(observation string) bool
+
+	// ResultEncoder formats results for EncodeResult. Nil means TextEncoder.
+	ResultEncoder ResultEncoder
+
+	// StreamActions streams model responses in Run and dispatches a tool call
+	// as soon as its JSON object is complete, instead of waiting for the
+	// model to finish. A stream that breaks partway is resumed as in
+	// CallOllamaResumable. It has no effect when Client is set.
+	StreamActions bool
+
+	// DedupHistory makes AppendHistory skip an entry that repeats the one
+	// before it in role and content.
+	DedupHistory bool
+
+	// Observer, if set, is told about every step of a run as it happens.
+	// Events carry the RunMetadata from the run's context, which lets
+	// concurrent runs be told apart.
+	Observer func(RunEvent)
+
+	// UseChat makes Run talk to the chat endpoint instead of the generate
+	// endpoint, streaming each chunk of the reply to the Observer.
+	UseChat bool
+
+	// Client, if set, answers prompts in place of the Ollama server, e.g. a
+	// test double that replays canned responses.
+	Client LLMClient
+
+	// MaxConcurrentRuns limits how many runs may execute at once; further
+	// runs wait for a slot. Zero means no limit.
+	MaxConcurrentRuns int
+
+	// MaxQueuedRuns limits how many runs may wait for a slot when
+	// MaxConcurrentRuns is reached; runs beyond it fail with ErrTooBusy. Zero
+	// means no limit.
+	MaxQueuedRuns int
+
+	runSlotsMu sync.Mutex
+	runSlots   chan struct{} // one token per running run
+	queuedRuns atomic.Int32  // runs waiting for a slot
+
+	systemPromptMu sync.Mutex // guards SystemPrompt while runs are in progress
+
+	instructionsMu sync.Mutex
+	instructions   []string // queued by InjectInstruction
+
+	auditMu sync.Mutex // serializes writes to AuditLogPath
+
+	// CacheClock times the expiry of cached tool results. Nil means
+	// SystemClock.
+	CacheClock Clock
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedResult // keyed by toolCacheKey
+}
+
+// defaultSystemPrompt is used when Agent.SystemPrompt is empty.
+const defaultSystemPrompt = "You are a helpful assistant."
+
+// systemPrompt returns the system prompt new runs start with.
+func (a *Agent) systemPrompt() string {
+	a.systemPromptMu.Lock()
+	defer a.systemPromptMu.Unlock()
+	if a.SystemPrompt == "" {
+		return defaultSystemPrompt
+	}
+	return a.SystemPrompt
+}
+
+// setSystemPrompt makes prompt the system prompt of runs started from now on.
+func (a *Agent) setSystemPrompt(prompt string) {
+	a.systemPromptMu.Lock()
+	defer a.systemPromptMu.Unlock()
+	a.SystemPrompt = prompt
 }
 
 // NewAgent initializes a new Agent with the given configuration.
@@ -56,11 +475,40 @@ func NewAgent(ollamaURL, model string) *Agent {
 		OllamaURL: ollamaURL,
 		Model:     model,
 		Tools:     make(map[string]Tool),
+		MaxSteps:  defaultMaxSteps,
+
+		NormalizeObservations: true,
 	}
 }
 
-// GetConversationHistory fetches the conversation history from a local file.
+// SetMaxSteps sets MaxSteps, refusing limits below one step.
+func (a *Agent) SetMaxSteps(n int) error {
+	if n < 1 {
+		return fmt.Errorf("max steps must be at least 1, got %d", n)
+	}
+	a.MaxSteps = n
+	return nil
+}
+
+// maxSteps returns the step limit for a run.
+func (a *Agent) maxSteps() int {
+	if a.MaxSteps < 1 {
+		return defaultMaxSteps
+	}
+	return a.MaxSteps
+}
+
+// GetConversationHistory fetches the conversation history from a local file,
+// or from HistoryStore when one is set, in which case filePath is ignored.
 func (a *Agent) GetConversationHistory(filePath string) (string, error) {
+	if a.HistoryStore != nil {
+		entries, err := a.HistoryStore.Load()
+		if err != nil {
+			return "", err
+		}
+		return a.renderHistory(entries), nil
+	}
+
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		// File does not exist, return an empty history
 		return "", nil
@@ -70,12 +518,32 @@ func (a *Agent) GetConversationHistory(filePath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read conversation history file: %v", err)
 	}
+	if isJSONHistory(data) {
+		entries, err := LoadHistory(filePath)
+		if err != nil {
+			return "", err
+		}
+		return a.renderHistory(entries), nil
+	}
+
+	return normalizeTranscript(data), nil
+}
 
-	return string(data), nil
+// normalizeTranscript returns a transcript history file's contents as text.
+// Files edited on Windows may carry a UTF-8 BOM and CRLF line endings, which
+// would otherwise leak into the prompt.
+func normalizeTranscript(data []byte) string {
+	history := strings.TrimPrefix(string(data), "\ufeff")
+	history = strings.ReplaceAll(history, "\r\n", "\n")
+	return strings.ReplaceAll(history, "\r", "\n")
 }
 
-// SaveConversationHistory saves the conversation history to a local file.
+// SaveConversationHistory saves the conversation history to a local file, or
+// to HistoryStore when one is set, in which case filePath is ignored.
 func (a *Agent) SaveConversationHistory(filePath, history string) error {
+	if a.HistoryStore != nil {
+		return a.HistoryStore.Save(a.parseHistory(history))
+	}
 	err := os.WriteFile(filePath, []byte(history), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to save conversation history to file: %v", err)
@@ -90,9 +558,29 @@ func (a *Agent) AddTool(tool Tool) {
 
 // GetToolsPrompt generates a string description of all available tools for the LLM.
 func (a *Agent) GetToolsPrompt() string {
+	return a.toolsPromptFor("")
+}
+
+// toolsPromptFor is GetToolsPrompt for a particular user input. When more than
+// MaxPromptTools tools are available, only the ones most relevant to input are
+// described.
+func (a *Agent) toolsPromptFor(input string) string {
+	var tools []Tool
+	if a.MaxPromptTools > 0 {
+		tools = a.selectRelevantTools(input, a.MaxPromptTools)
+	} else {
+		for _, tool := range a.Tools {
+			if tool.IsAvailable() {
+				tools = append(tools, tool)
+			}
+		}
+		// Map order is random; keep the prompt identical from run to run.
+		sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	}
+
 	var sb strings.Builder
 	sb.WriteString("AVAILABLE TOOLS:\n")
-	for _, tool := range a.Tools {
+	for _, tool := range tools {
 		sb.WriteString(fmt.Sprintf("Name: %s\n", tool.Name))
 		sb.WriteString(fmt.Sprintf("Description: %s\n", tool.Description))
 		sb.WriteString(fmt.Sprintf("Arguments: %v\n\n", tool.Args))
@@ -102,97 +590,605 @@ func (a *Agent) GetToolsPrompt() string {
 
 // GeneratePrompt crafts the full prompt for the LLM, including user input, tool descriptions, and instructions.
 func (a *Agent) GeneratePrompt(history, userInput string) string {
-	toolsPrompt := a.GetToolsPrompt()
+	return a.promptForStep(a.systemPrompt(), history, userInput, 0)
+}
+
+// promptForStep is GeneratePrompt for the given zero-based step of a run,
+// which only matters when ShowBudgetInPrompt is set, opening with the run's
+// systemPrompt.
+func (a *Agent) promptForStep(systemPrompt, history, userInput string, step int) string {
+	toolsPrompt := a.toolsPromptFor(userInput)
+	if len(a.PromptIncludeTypes) > 0 {
+		history = a.renderHistory(FilterHistory(a.parseHistory(history), a.PromptIncludeTypes))
+	}
+	var state string
+	if a.ShowToolState {
+		state = a.toolStatePrompt()
+	}
+	var budget string
+	if a.ShowBudgetInPrompt {
+		// A tool call on the last step leaves no step to answer in.
+		budget = fmt.Sprintf("You have %d tool calls remaining; reach a %s before they run out.\n",
+			max(a.maxSteps()-step-1, 0), strings.TrimSuffix(a.finalAnswerPrefix(), ":"))
+	}
+	var strict string
+	if a.StrictMode {
+		strict = fmt.Sprintf("Every response MUST contain either a tool call in the JSON format below or your final answer starting with '%s'. Any other response is rejected.\n",
+			a.finalAnswerPrefix())
+	}
 	return fmt.Sprintf(`
-You are a helpful assistant. You have access to the following tools:
+%s You have access to the following tools:
 
 %s
-
+%s
 The user has given you a task. You should think step-by-step and then decide to either use one of the tools or respond with the final answer.
-Your final response should start with 'Final Answer:'.
-
+Your final response should start with '%s'.
+You may cite the observations that support your answer as [obs:N], where N counts this task's observations starting at 1.
+%s%s
 Thought: You should always think about what to do first, before using a tool.
 Action: To use a tool, you must use the following JSON format:
 { "name": "tool_name", "arguments": { "arg1": "value1", "arg2": "value2" } }
-Observation: The result of the tool's action.
+%s The result of the tool's action.
+
+In the history below, your earlier answers are labelled '%s' and the user's messages '%s'. Do not write these labels yourself.
 
 Current conversation history:
 %s
-User: %s`, toolsPrompt, history, userInput)
+%s %s`, systemPrompt, toolsPrompt, state, a.finalAnswerPrefix(), budget, strict, a.label(EntryObservation),
+		a.label(EntryAssistant), a.label(EntryUser), history, a.label(EntryUser), userInput)
 }
 
-// Run executes the agentic loop for a given user input.
-func (a *Agent) Run(historyFilePath, userInput string) (string, error) {
-	// Load the history for this user
+// toolStatePrompt renders the State of the available tools in name order, or
+// returns "" if no tool has state to show.
+func (a *Agent) toolStatePrompt() string {
+	names := make([]string, 0, len(a.Tools))
+	for name, tool := range a.Tools {
+		if tool.State != nil && tool.IsAvailable() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("CURRENT TOOL STATE:\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("%s:\n%s\n", name, a.Tools[name].State()))
+	}
+	return sb.String()
+}
+
+// logf logs a trace message from the agent loop when Verbose is set.
+func (a *Agent) logf(format string, args ...interface{}) {
+	if a.Verbose {
+		log.Printf(format, args...)
+	}
+}
+
+// BuildPrompt returns the exact prompt Run would send to the model for the
+// given history and user input. It makes no network calls.
+func (a *Agent) BuildPrompt(history, userInput string) string {
+	return a.GeneratePrompt(history, userInput)
+}
+
+// Run executes the agentic loop for a given user input and returns the final
+// answer. See RunWithTrace for how stopping, timeouts and failures behave.
+func (a *Agent) Run(ctx context.Context, historyFilePath, userInput string, stop <-chan struct{}) (string, error) {
+	result, err := a.RunWithTrace(ctx, historyFilePath, userInput, stop)
+	return result.Answer.Text, err
+}
+
+// RunWithTrace executes the agentic loop and returns the final answer along
+// with the trace of every step taken. The result is never nil, so a partial
+// trace is available even when an error is returned.
+//
+// If stop fires, the loop ends after the current step and the answer holds the
+// latest model response, with ErrRunStopped. A nil stop channel never fires.
+// When MaxRunDuration elapses, the latest model response is likewise returned
+// with ErrRunTimeout, and when ctx is cancelled, with ctx.Err(). In all three
+// cases the trace ends with a step whose Error records why the run stopped.
+//
+// When MaxConcurrentRuns is set, the run first waits for a free slot; it fails
+// with ErrTooBusy if MaxQueuedRuns runs are already waiting, or with ctx.Err()
+// if ctx ends first.
+//
+// With ExplainFailures set, any other failure triggers one more model call that
+// turns the error into a best-effort answer or a user-friendly explanation.
+// That text is returned as the answer alongside the original error.
+func (a *Agent) RunWithTrace(ctx context.Context, historyFilePath, userInput string, stop <-chan struct{}) (*RunResult, error) {
+	result := &RunResult{}
+	release, err := a.acquireRun(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer release()
+
+	ctx, span := a.tracer().Start(ctx, "agent.run", trace.WithAttributes(attrModel.String(a.Model)))
+	defer func() { endSpan(span, err) }()
+
+	a.emit(ctx, RunEvent{Kind: EventRunStart, Step: -1, Text: userInput})
+	call, direct := parseDirectCommand(userInput)
+	if direct {
+		// "!tool arg=value" calls the tool straight away, without the model.
+		err = a.runDirectCommand(ctx, historyFilePath, call, result)
+	} else {
+		err = a.runLoop(ctx, historyFilePath, userInput, stop, result)
+	}
+	defer func() { a.emit(ctx, RunEvent{Kind: EventRunEnd, Step: -1, Text: result.Answer.Text, Err: err}) }()
+	if err == nil || direct || !a.ExplainFailures || errors.Is(err, ErrRunStopped) || errors.Is(err, ErrRunTimeout) || ctx.Err() != nil {
+		return result, err
+	}
+
+	explanation, explainErr := a.explainFailure(historyFilePath, userInput, err)
+	if explainErr != nil {
+		log.Printf("Failed to explain run failure: %v\n", explainErr)
+		return result, err
+	}
+	result.Answer = FinalAnswer{Text: explanation}
+	return result, err
+}
+
+// explainFailure asks the model to make sense of a failed run for the user.
+func (a *Agent) explainFailure(historyFilePath, userInput string, runErr error) (string, error) {
 	history, err := a.GetConversationHistory(historyFilePath)
 	if err != nil {
 		return "", err
 	}
+	prompt := fmt.Sprintf(`
+You are a helpful assistant. You were working on the user's task below but could not complete it.
 
-	for i := 0; i < 5; i++ { // Limit the number of steps to prevent infinite loops
-		// 1. Plan: Get the LLM's next action
-		prompt := a.GeneratePrompt(history, userInput)
-		log.Println("--- Sending prompt to LLM ---")
-		log.Println(prompt)
-		response, err := a.CallOllama(prompt)
-		if err != nil {
-			return "", err
+Task: %s
+What went wrong: %v
+
+Conversation so far:
+%s
+
+Do not use any tools. Either give your best-effort answer based on what you learned so far, or explain in plain, friendly language why the task could not be completed.`, userInput, runErr, history)
+
+	response, err := a.CallOllama(prompt)
+	if err != nil && !errors.Is(err, ErrResponseTruncated) {
+		return "", err
+	}
+	return a.trimAnswerPrefix(response), nil
+}
+
+// runLoop is the agentic loop behind RunWithTrace. It records each step and
+// the final answer in result.
+func (a *Agent) runLoop(ctx context.Context, historyFilePath, userInput string, stop <-chan struct{}, result *RunResult) error {
+	parent := ctx
+	if a.MaxRunDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.MaxRunDuration)
+		defer cancel()
+	}
+
+	logf := a.runLogger(ctx)
+
+	// Load the history for this user
+	history, err := a.GetConversationHistory(historyFilePath)
+	if err != nil {
+		return err
+	}
+	// Prompts carry the user's message after the history, so it is only
+	// added to the transcript that is saved.
+	transcript := a.AppendHistory(history, newHistoryEntry(EntryUser, userInput))
+	record := func(entry HistoryEntry) {
+		history = a.AppendHistory(history, entry)
+		transcript = a.AppendHistory(transcript, entry)
+	}
+
+	systemPrompt := a.systemPrompt() // updated by tools during the run
+	var lastObservation string       // most recent successful tool result
+	var partial string               // most recent model response
+	nudged := false
+	var instructions []string     // injected by the user during this run
+	calls := make(map[string]int) // tool calls made during this run, by tool
+	session := sessionID(ctx)
+
+	// cancelled records why the run was cut short, on the current step if one
+	// is in progress or as a step of its own, and returns the error to report:
+	// the caller's context error, or ErrRunTimeout if MaxRunDuration ran out.
+	cancelled := func(step *Step) error {
+		err := parent.Err()
+		if err == nil {
+			err = ErrRunTimeout
 		}
-		log.Println("--- Received response from LLM ---")
-		log.Println(response)
+		if step != nil {
+			step.Error = err
+		} else {
+			result.Trace = append(result.Trace, Step{Error: err})
+		}
+		result.Answer.Text = partial
+		return err
+	}
 
-		// 2. Act: Parse the response and execute the tool or provide the final answer.
-		if strings.HasPrefix(response, "Final Answer:") {
-			finalAnswer := strings.TrimSpace(strings.TrimPrefix(response, "Final Answer:"))
-			history += "\nAssistant: " + finalAnswer
-			a.SaveConversationHistory(historyFilePath, history)
-			return finalAnswer, nil
+	// Every corrective retry, whatever its cause, draws from one budget.
+	retries := 0
+	spendRetry := func() error {
+		retries++
+		if a.MaxRetriesPerRun > 0 && retries > a.MaxRetriesPerRun {
+			return ErrRetryBudgetExceeded
 		}
+		return nil
+	}
 
-		// Use a regular expression to extract the JSON action
-		re := regexp.MustCompile(`(?s)\{ "name": ".*?" \}`)
-		matches := re.FindStringSubmatch(response)
-		if len(matches) == 0 {
-			return "", fmt.Errorf("could not find a valid tool action in the LLM's response")
+	for i := 0; i < a.maxSteps(); i++ { // Limit the number of steps to prevent infinite loops
+		if ctx.Err() != nil {
+			return cancelled(nil)
 		}
 
-		var toolCall ToolInvocation
-		// The JSON is likely part of a larger string, so we'll try to find the complete JSON object
-		jsonString := matches[0]
-		err = json.Unmarshal([]byte(jsonString), &toolCall)
+		// 1. Plan: Get the LLM's next action
+		instructions = append(instructions, a.takeInstructions()...)
+		prompt := withInstructions(a.promptForStep(systemPrompt, history, userInput, i), instructions)
+		logf("--- Sending prompt to LLM ---")
+		logf("%s", prompt)
+		response, err := a.generate(ctx, prompt)
+
+		// If the prompt overflowed the context window, drop older history and
+		// try again. The trimmed history is kept so later steps fit as well.
+		for attempt := 0; isContextLengthError(err) && attempt < maxContextRetries && history != ""; attempt++ {
+			if err := spendRetry(); err != nil {
+				return err
+			}
+			logf("--- Prompt exceeded the context window, retrying with trimmed history ---")
+			history = trimHistory(history)
+			prompt = withInstructions(a.promptForStep(systemPrompt, history, userInput, i), instructions)
+			response, err = a.generate(ctx, prompt)
+		}
 		if err != nil {
-			return "", fmt.Errorf("failed to unmarshal tool invocation JSON: %v", err)
+			if ctx.Err() != nil {
+				if strings.TrimSpace(response) != "" {
+					// Keep what was streamed before the cancellation so that
+					// the next run knows where this one left off.
+					partial = response
+					record(newHistoryEntry(EntryAssistant, partialResponseMarker+" "+response))
+					a.SaveConversationHistory(historyFilePath, transcript)
+				}
+				return cancelled(nil)
+			}
+			return err
 		}
+		partial = response
+		logf("--- Received response from LLM ---")
+		logf("%s", response)
+		result.Trace = append(result.Trace, Step{Response: response})
+		step := &result.Trace[len(result.Trace)-1]
+		a.emit(ctx, RunEvent{Kind: EventModelResponse, Step: i, Text: response})
 
-		tool, ok := a.Tools[toolCall.Name]
-		if !ok {
-			return "", fmt.Errorf("unknown tool: %s", toolCall.Name)
+		// 2. Act: Parse the response and execute the tool or provide the final answer.
+		if kind, answer := a.classifyResponse(response); kind == responseFinalAnswer {
+			finalAnswer := answer
+			if a.VerifyToolUsage && !nudged && lastObservation != "" && !answerUsesObservation(finalAnswer, lastObservation) {
+				if err := spendRetry(); err != nil {
+					return err
+				}
+				logf("--- Final answer ignores the last tool result, re-prompting ---")
+				nudged = true
+				record(newHistoryEntry(EntryObservation, toolUsageNudge))
+				continue
+			}
+			for _, filter := range a.AnswerFilters {
+				finalAnswer = filter(finalAnswer)
+			}
+			if a.RequiredAnswerSchema != "" {
+				if mismatch := checkAnswerSchema(a.RequiredAnswerSchema, finalAnswer); mismatch != nil {
+					if err := spendRetry(); err != nil {
+						return err
+					}
+					logf("--- Final answer does not match the required schema (%v), asking for a reformat ---", mismatch)
+					reformatted, err := a.reformatAnswer(ctx, userInput, finalAnswer, mismatch)
+					if err != nil {
+						result.Answer.Text = finalAnswer
+						if ctx.Err() != nil {
+							return cancelled(step)
+						}
+						return err
+					}
+					finalAnswer = reformatted
+				}
+			}
+			result.Answer = parseFinalAnswer(finalAnswer, len(result.Observations()))
+			confidenceFunc := a.ConfidenceFunc
+			if confidenceFunc == nil {
+				confidenceFunc = LowConfidence
+			}
+			if result.LowConfidence = confidenceFunc(userInput, result); result.LowConfidence {
+				logf("--- Answer flagged as low confidence ---")
+				if a.LowConfidenceDisclaimer != "" {
+					result.Answer.Text += "\n\n" + a.LowConfidenceDisclaimer
+				}
+			}
+			record(newHistoryEntry(EntryAssistant, finalAnswer))
+			a.SaveConversationHistory(historyFilePath, transcript)
+			a.emit(ctx, RunEvent{Kind: EventFinalAnswer, Step: i, Text: result.Answer.Text})
+			return nil
+		}
+
+		toolCall, err := parseToolInvocation(response)
+		if err == nil {
+			// Keep the model's reasoning and call in the history for the trace.
+			if thought := extractThought(response); thought != "" {
+				record(newHistoryEntry(EntryThought, thought))
+			}
+			if callJSON, err := json.Marshal(toolCall); err == nil {
+				record(newHistoryEntry(EntryToolCall, string(callJSON)))
+			}
+		}
+
+		if ctx.Err() != nil {
+			if err == nil {
+				step.Action = &toolCall
+			}
+			return cancelled(step)
 		}
 
 		// 3. Reflect & Observe: Execute the tool and add the observation to the history.
-		log.Printf("--- Calling tool: %s with arguments: %v ---\n", tool.Name, toolCall.Args)
-		toolResult, err := tool.Function(toolCall.Args)
 		if err != nil {
-			log.Printf("Tool execution failed: %v\n", err)
-			history += fmt.Sprintf("\nObservation: Tool execution failed with error: %v", err)
+			// Malformed responses are fed back so the model can correct itself.
+			if err := spendRetry(); err != nil {
+				return err
+			}
+			logf("--- Invalid response, asking the model to retry: %v ---", err)
+			step.Observation = fmt.Sprintf("Invalid response: %v. Respond with a tool call in the JSON format above or with your final answer starting with '%s'.", err, a.finalAnswerPrefix())
+		} else if tool, refused := a.checkToolCall(ctx, &toolCall, calls); refused != nil {
+			if refused.Corrective {
+				if err := spendRetry(); err != nil {
+					return err
+				}
+			}
+			step.Action = &toolCall
+			step.Observation = refused.Observation
 		} else {
-			log.Printf("--- Tool result: %s ---\n", toolResult)
-			history += fmt.Sprintf("\nObservation: %s", toolResult)
+			step.Action = &toolCall
+			toolResult, err := a.dispatchToolCall(ctx, session, i, tool, toolCall.Args, calls)
+			if err != nil {
+				if err := spendRetry(); err != nil {
+					return err
+				}
+				logf("Tool execution failed: %v", err)
+				step.Observation = fmt.Sprintf("Tool execution failed with error: %v", err)
+			} else {
+				logf("--- Tool result: %s ---", toolResult.Observation)
+				step.Observation = a.guardObservation(tool.formatObservation(toolResult.Observation))
+				step.Succeeded = true
+				lastObservation = step.Observation
+				if toolResult.SystemUpdate != "" {
+					if a.AllowSystemUpdates {
+						logf("--- Tool %s updated the system prompt ---", tool.Name)
+						systemPrompt = toolResult.SystemUpdate
+						a.setSystemPrompt(systemPrompt)
+					} else {
+						log.Printf("Ignoring system prompt update from tool %s: system updates are disabled\n", tool.Name)
+					}
+				}
+			}
 		}
+		if a.NormalizeObservations {
+			step.Observation = normalizeObservation(step.Observation)
+		}
+		record(newHistoryEntry(EntryObservation, step.Observation))
+		a.emit(ctx, RunEvent{Kind: EventObservation, Step: i, Tool: toolCall.Name, Text: step.Observation})
 
 		// Save the updated history for the next loop iteration or next run
-		a.SaveConversationHistory(historyFilePath, history)
+		a.SaveConversationHistory(historyFilePath, transcript)
+
+		// Bail out between steps if the user asked us to stop.
+		select {
+		case <-stop:
+			result.Trace = append(result.Trace, Step{Error: ErrRunStopped})
+			result.Answer.Text = response
+			return ErrRunStopped
+		default:
+		}
+	}
+
+	return fmt.Errorf("agent failed to find a final answer within %d steps", a.maxSteps())
+}
+
+// canonicalizeArgs returns a copy of args with string values trimmed and each
+// key renamed to the declared argument it matches once trimmed and compared
+// case-insensitively. Keys matching no declared argument are kept as sent.
+func canonicalizeArgs(args map[string]interface{}, declared map[string]string) map[string]interface{} {
+	byLower := make(map[string]string, len(declared))
+	for name := range declared {
+		byLower[strings.ToLower(name)] = name
+	}
+
+	canonical := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if name, ok := byLower[strings.ToLower(strings.TrimSpace(key))]; ok {
+			key = name
+		}
+		if s, ok := value.(string); ok {
+			value = strings.TrimSpace(s)
+		}
+		canonical[key] = value
+	}
+	return canonical
+}
+
+// callTool runs tool with args, serving the result from the cache when the
+// tool has a CacheTTL.
+func (a *Agent) callTool(tool Tool, args map[string]interface{}) (ToolResult, error) {
+	if tool.CacheTTL <= 0 {
+		return a.runTool(tool, args)
+	}
+	key := toolCacheKey(tool.Name, args)
+	if result, ok := a.cachedResult(key); ok {
+		a.logf("--- Serving %s result from cache ---", tool.Name)
+		return result, nil
+	}
+	result, err := a.runTool(tool, args)
+	if err == nil {
+		a.cacheResult(key, result, tool.CacheTTL)
+	}
+	return result, err
+}
+
+// runTool runs tool with args, giving up once the tool's Timeout, or failing
+// that the agent's ToolTimeout, has elapsed. Tools cannot be interrupted, so a
+// timed-out tool keeps running in the background until it returns.
+func (a *Agent) runTool(tool Tool, args map[string]interface{}) (ToolResult, error) {
+	timeout := a.ToolTimeout
+	if tool.Timeout > 0 {
+		timeout = tool.Timeout
+	}
+	if timeout <= 0 {
+		return tool.call(args)
+	}
+
+	type toolOutput struct {
+		result ToolResult
+		err    error
+	}
+	done := make(chan toolOutput, 1)
+	go func() {
+		result, err := tool.call(args)
+		done <- toolOutput{result, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-timer.C:
+		return ToolResult{}, fmt.Errorf("tool %s timed out after %s", tool.Name, timeout)
+	}
+}
+
+// parseToolInvocation extracts the JSON tool call from a model response.
+func parseToolInvocation(response string) (ToolInvocation, error) {
+	// The JSON is likely part of a larger string, so find the first complete
+	// object that looks like a tool call.
+	callJSON, at := findToolCallJSON(response)
+	if at < 0 {
+		return ToolInvocation{}, fmt.Errorf("could not find a valid tool action in the LLM's response")
+	}
+
+	var toolCall ToolInvocation
+	if err := json.Unmarshal([]byte(callJSON), &toolCall); err != nil {
+		return ToolInvocation{}, fmt.Errorf("failed to unmarshal tool invocation JSON: %v", err)
+	}
+	return toolCall, nil
+}
+
+// blankLineRuns matches two or more consecutive blank lines.
+var blankLineRuns = regexp.MustCompile(`\n{3,}`)
+
+// normalizeObservation cleans up a tool result for the history: line endings
+// become \n, trailing whitespace is removed from every line, runs of blank
+// lines collapse to one, and the result is trimmed.
+func normalizeObservation(obs string) string {
+	obs = strings.ReplaceAll(obs, "\r\n", "\n")
+	lines := strings.Split(obs, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
 	}
+	obs = blankLineRuns.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(obs)
+}
+
+// extractThought returns the reasoning the model wrote before its JSON action,
+// without the "Thought:" and "Action:" labels.
+func extractThought(response string) string {
+	thought := response
+	if i := strings.Index(thought, "{"); i >= 0 {
+		thought = thought[:i]
+	}
+	thought = strings.TrimSpace(thought)
+	thought = strings.TrimSpace(strings.TrimSuffix(thought, "Action:"))
+	return strings.TrimSpace(strings.TrimPrefix(thought, "Thought:"))
+}
+
+// endpoint returns the full URL for an Ollama API path. For backward
+// compatibility, OllamaURL may still point at a specific endpoint such as
+// http://host:11434/api/generate; that path is stripped before joining.
+func (a *Agent) endpoint(path string) string {
+	base := strings.TrimRight(a.OllamaURL, "/")
+	for _, p := range []string{generatePath, chatPath, tagsPath, showPath} {
+		if strings.HasSuffix(base, p) {
+			base = strings.TrimSuffix(base, p)
+			break
+		}
+	}
+	return base + path
+}
+
+// generate returns the model's response to prompt for the agent loop.
+func (a *Agent) generate(ctx context.Context, prompt string) (response string, err error) {
+	ctx, span := a.tracer().Start(ctx, "llm.generate", trace.WithAttributes(attrModel.String(a.Model)))
+	defer func() { endSpan(span, err) }()
+
+	response, err = a.generateOnce(ctx, prompt)
+	// A response cut off mid-thought is useless to the loop; have the model
+	// pick up where it stopped. Whatever is left truncated after that is
+	// classified as is.
+	for n := 0; errors.Is(err, ErrResponseTruncated); n++ {
+		if n == maxTruncationContinues {
+			a.logf("--- Response still truncated after %d continuations ---", n)
+			return response, nil
+		}
+		a.logf("--- Response hit the %d token limit, asking the model to continue ---", a.MaxTokens)
+		var more string
+		more, err = a.generateOnce(ctx, prompt+response)
+		response += more
+	}
+	return response, err
+}
 
-	return "", fmt.Errorf("agent failed to find a final answer within the maximum number of steps")
+// generateOnce makes a single model call for generate.
+func (a *Agent) generateOnce(ctx context.Context, prompt string) (string, error) {
+	if a.UseChat && a.Client == nil {
+		return a.generateChat(ctx, prompt)
+	}
+	if a.StreamActions && a.Client == nil {
+		return a.resumeStream(ctx, prompt, a.generateUntilAction)
+	}
+	return a.CallOllamaContext(ctx, prompt)
+}
+
+// modelOptions returns the Ollama options for a model call: extra plus the
+// agent-wide settings such as Seed and MaxTokens. It returns nil when there
+// are none.
+func (a *Agent) modelOptions(extra map[string]interface{}) map[string]interface{} {
+	if a.Seed == nil && a.MaxTokens <= 0 {
+		return extra
+	}
+	options := map[string]interface{}{}
+	if a.Seed != nil {
+		options["seed"] = *a.Seed
+	}
+	if a.MaxTokens > 0 {
+		options["num_predict"] = a.MaxTokens
+	}
+	for k, v := range extra {
+		options[k] = v
+	}
+	return options
 }
 
 // CallOllama sends a request to the Ollama server and returns the full response string.
 func (a *Agent) CallOllama(prompt string) (string, error) {
+	return a.CallOllamaStream(prompt, func(string) error { return nil })
+}
+
+// CallOllamaContext is like CallOllama but aborts the request when ctx is done.
+// When Client is set, the prompt goes to it instead of the Ollama server. A
+// response cut off by MaxTokens is returned with ErrResponseTruncated.
+func (a *Agent) CallOllamaContext(ctx context.Context, prompt string) (string, error) {
+	if a.Client != nil {
+		return a.Client.Generate(ctx, prompt)
+	}
+
 	reqData := OllamaRequest{
-		Model:  a.Model,
-		Prompt: prompt,
-		Stream: false, // For simplicity, we get the full response at once
+		Model:     a.Model,
+		Prompt:    prompt,
+		Stream:    false, // For simplicity, we get the full response at once
+		Options:   a.modelOptions(nil),
+		KeepAlive: a.keepAlive(),
 	}
 
 	jsonData, err := json.Marshal(reqData)
@@ -200,13 +1196,13 @@ func (a *Agent) CallOllama(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request data: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", a.OllamaURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint(generatePath), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create HTTP request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{Timeout: ollamaCallTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request to Ollama: %v", err)
@@ -215,7 +1211,7 @@ func (a *Agent) CallOllama(prompt string) (string, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Ollama request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return "", &OllamaError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var ollamaResp OllamaResponse
@@ -223,18 +1219,105 @@ func (a *Agent) CallOllama(prompt string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to decode Ollama response: %v", err)
 	}
+	recordTokenCounts(ctx, ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+	if ollamaResp.DoneReason == "length" {
+		return ollamaResp.Response, ErrResponseTruncated
+	}
 
 	return ollamaResp.Response, nil
 }
 
+// watchForStop closes stop once a line reading "stop" arrives on r, letting the
+// user interrupt a runaway agent loop from the terminal. Any other non-empty
+// line is passed to steer, if set, so the user can redirect the run.
+func watchForStop(r io.Reader, stop chan<- struct{}, steer func(string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "stop":
+			close(stop)
+			return
+		case line != "" && steer != nil:
+			steer(line)
+		}
+	}
+}
+
+// confirmOnTerminal asks the user on the controlling terminal whether a tool
+// call may go ahead. Stdin is left alone since watchForStop is reading it.
+func confirmOnTerminal(name string, args map[string]interface{}) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		log.Printf("Cannot ask for confirmation of %s: %v\n", name, err)
+		return false
+	}
+	defer tty.Close()
+
+	argsJSON, _ := json.Marshal(args)
+	fmt.Fprintf(tty, "Allow %s %s? [y/N] ", name, argsJSON)
+	answer, _ := bufio.NewReader(tty).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // Main function to run the agent.
 func main() {
+	exportPath := flag.String("export", "", "write the conversation history as Markdown to this file")
+	manifestPath := flag.String("tools", "", "load additional shell-command tools from a JSON or YAML manifest")
+	toolsAllow := flag.String("tools-allow", "", "comma-separated programs that -tools commands may run; empty allows all but destructive ones")
+	enableCodeExec := flag.String("enable-code-exec", "", "register a run_<lang> tool that executes model-written code (python, go or bash); dangerous")
+	dictionaryPath := flag.String("dictionary", "", "enable the define tool backed by this dictionary file")
+	logDir := flag.String("log-dir", "", "enable the log_parse tool for log files under this directory")
+	pdfDir := flag.String("pdf-dir", "", "enable the read_pdf tool for PDF files under this directory")
+	notifyWebhook := flag.String("notify-webhook", "", "enable the notify tool, posting notifications to this webhook URL")
+	translateEndpoint := flag.String("translate-endpoint", "", "enable the translate tool backed by this LibreTranslate compatible endpoint")
+	verbose := flag.Bool("v", false, "log prompts, model responses and tool calls")
+	printPrompt := flag.Bool("print-prompt", false, "print the prompt that would be sent to the model and exit")
+	useChat := flag.Bool("chat", false, "talk to the model through the chat endpoint instead of generate")
+	streamActions := flag.Bool("stream-actions", false, "stream responses and run a tool as soon as its call is complete")
+	jsonOutput := flag.Bool("json", false, "print the result, including the trace, as JSON")
+	historyDSN := flag.String("history-dsn", "", "keep the conversation history in this store, e.g. file://history.json, memory:// or sqlite://history.db")
+	auditLog := flag.String("audit-log", "", "append a JSON line for every tool call to this file")
+	warmup := flag.Bool("warmup", false, "load the model into memory before running the agent")
+	maxSteps := flag.Int("max-steps", defaultMaxSteps, "how many model calls a run may make before giving up")
+	maxTokens := flag.Int("max-tokens", 0, "cap the tokens the model may generate per call; 0 means no cap")
+	seed := flag.Int64("seed", -1, "generation seed for every model call, for reproducible runs; -1 leaves it random")
+	allowSystemUpdates := flag.Bool("allow-system-updates", false, "register the set_mode tool and let tools change the system prompt")
+	flag.Parse()
+
 	// Set up the agent
-	ollamaURL := "http://ollama.localhost:11434/api/generate"
+	ollamaURL := "http://ollama.localhost:11434"
 	model := "gemma:2b"
 	historyFilePath := "conversation_history.json"
+	memoryFilePath := "agent_memory.json"
 
 	agent := NewAgent(ollamaURL, model)
+	if *historyDSN != "" {
+		store, err := NewHistoryStore(*historyDSN)
+		if err != nil {
+			log.Fatalf("Failed to open history store: %v", err)
+		}
+		agent.HistoryStore = store
+	}
+	agent.Verbose = *verbose
+	agent.AllowSystemUpdates = *allowSystemUpdates
+	agent.StreamActions = *streamActions
+	agent.UseChat = *useChat
+	agent.ShowToolState = true
+	agent.AuditLogPath = *auditLog
+	agent.InjectionDetector = DetectInjection
+	if *seed >= 0 {
+		agent.Seed = seed
+	}
+	agent.MaxTokens = *maxTokens
+	if err := agent.SetMaxSteps(*maxSteps); err != nil {
+		log.Fatalf("Invalid -max-steps: %v", err)
+	}
+	if *jsonOutput {
+		agent.ResultEncoder = JSONEncoder{Indent: "  "}
+	}
+	agent.LowConfidenceDisclaimer = "(This answer was not checked with any tool and may be inaccurate.)"
 
 	// Add the "calculator" tool
 	agent.AddTool(Tool{
@@ -280,6 +1363,8 @@ func main() {
 		Name:        "web_search",
 		Description: "A tool that can search the internet for information.",
 		Args:        map[string]string{"query": "string"},
+		// Search APIs are rate limited; don't let one task burn through them.
+		MaxCallsPerRun: 3,
 		Function: func(args map[string]interface{}) (string, error) {
 			query, ok := args["query"].(string)
 			if !ok {
@@ -291,19 +1376,160 @@ func main() {
 		},
 	})
 
-	// Get user input from command line
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: go run main.go \"Your question here\"")
+	agent.AddTool(NewExtractTool())
+	agent.AddTool(NewDurationTool())
+	agent.AddTool(NewSetOpsTool())
+	agent.AddTool(NewValidateTool())
+	agent.AddTool(NewTZConvertTool())
+	agent.AddTool(NewMemoryTool(memoryFilePath))
+	agent.AddTool(NewTOTPTool())
+	agent.AddTool(NewInflectTool())
+	agent.AddTool(NewURLTool())
+	agent.AddTool(NewGeoDistanceTool())
+	agent.AddTool(NewQRCodeTool())
+	agent.AddTool(NewWeatherTool())
+	agent.AddTool(NewCronTool())
+	agent.AddTool(NewFuzzyMatchTool())
+	agent.AddTool(NewJWTTool())
+	agent.AddTool(NewNumberTheoryTool())
+	agent.AddTool(NewFXTool(frankfurterURL))
+	agent.AddTool(NewTodoTool())
+	agent.AddTool(NewColorTool())
+	agent.AddTool(NewJoinTool())
+	agent.AddTool(NewIPInfoTool(ipAPIURL))
+	agent.AddTool(NewYAMLTool())
+	agent.AddTool(NewSummarizeTool(LLMClientFunc(agent.CallOllamaContext)))
+	agent.AddTool(NewCaseTool())
+	agent.AddTool(NewICalTool())
+	agent.AddTool(NewJQTool())
+	agent.AddTool(NewMatrixTool())
+	agent.AddTool(NewDNSTool())
+	agent.AddTool(NewGeocodeTool(nominatimURL))
+	if *allowSystemUpdates {
+		agent.AddTool(NewSetModeTool())
 	}
-	userInput := strings.Join(os.Args[1:], " ")
+
+	if *dictionaryPath != "" {
+		agent.AddTool(NewDictionaryTool(*dictionaryPath))
+	}
+
+	if *logDir != "" {
+		agent.AddTool(NewLogParseTool(*logDir))
+	}
+	if *pdfDir != "" {
+		agent.AddTool(NewReadPDFTool(*pdfDir, maxPDFText))
+	}
+
+	// The operator chose these endpoints, so unlike URLs the model picks they
+	// may be on localhost or the local network.
+	if *notifyWebhook != "" {
+		agent.AddTool(NewNotifyToolWithClient(NotifyConfig{WebhookURL: *notifyWebhook}, &http.Client{Timeout: notifyTimeout}))
+		agent.ConfirmToolCall = confirmOnTerminal
+	}
+
+	if *translateEndpoint != "" {
+		agent.AddTool(NewTranslateToolWithClient(*translateEndpoint, &http.Client{Timeout: translateTimeout}))
+	}
+
+	if *enableCodeExec != "" {
+		if !SupportedCodeLanguage(*enableCodeExec) {
+			log.Fatalf("Invalid -enable-code-exec: unsupported language %q (want python, go or bash)", *enableCodeExec)
+		}
+		agent.AddTool(NewCodeRunTool(*enableCodeExec, 10*time.Second))
+	}
+
+	if *manifestPath != "" {
+		policy := DefaultCommandPolicy
+		if *toolsAllow != "" {
+			for _, program := range strings.Split(*toolsAllow, ",") {
+				policy.Allow = append(policy.Allow, strings.TrimSpace(program))
+			}
+		}
+		tools, err := LoadToolsFromManifestWithPolicy(*manifestPath, policy)
+		if err != nil {
+			log.Fatalf("Failed to load tools: %v", err)
+		}
+		for _, tool := range tools {
+			agent.AddTool(tool)
+		}
+	}
+
+	// Get user input from command line. With -export alone, just export the
+	// existing history without running the agent.
+	if flag.NArg() == 0 {
+		if *exportPath != "" {
+			exportHistory(agent, historyFilePath, *exportPath)
+			return
+		}
+		log.Fatalf("Usage: go run . [-v] [-export out.md] [-tools manifest.yaml] [-print-prompt] \"Your question here\"")
+	}
+	userInput := strings.Join(flag.Args(), " ")
+
+	if *printPrompt {
+		history, err := agent.GetConversationHistory(historyFilePath)
+		if err != nil {
+			log.Fatalf("Failed to load history: %v", err)
+		}
+		fmt.Println(agent.BuildPrompt(history, userInput))
+		return
+	}
+
+	if *warmup {
+		if err := agent.Warmup(context.Background()); err != nil {
+			log.Printf("Warmup failed, the first response may be slow: %v\n", err)
+		}
+	}
+
+	// Let the user interrupt the loop by typing "stop", or steer it by typing
+	// anything else
+	stop := make(chan struct{})
+	go watchForStop(os.Stdin, stop, agent.InjectInstruction)
 
 	// Run the agent
-	log.Printf("Starting agent with prompt: %s\n", userInput)
-	finalAnswer, err := agent.Run(historyFilePath, userInput)
+	agent.logf("Starting agent with prompt: %s\n", userInput)
+	log.Println("Type 'stop' and press Enter to interrupt the agent, or type new instructions to steer it.")
+	if *jsonOutput {
+		result, err := agent.RunWithTrace(context.Background(), historyFilePath, userInput, stop)
+		if err != nil && !errors.Is(err, ErrRunStopped) {
+			log.Fatalf("Agent failed with error: %v", err)
+		}
+		if err := agent.EncodeResult(os.Stdout, result); err != nil {
+			log.Fatalf("Failed to encode result: %v", err)
+		}
+		if *exportPath != "" {
+			exportHistory(agent, historyFilePath, *exportPath)
+		}
+		return
+	}
+
+	finalAnswer, err := agent.Run(context.Background(), historyFilePath, userInput, stop)
+	if errors.Is(err, ErrRunStopped) {
+		fmt.Println("\n--- Stopped (partial response) ---")
+		fmt.Println(finalAnswer)
+		return
+	}
 	if err != nil {
 		log.Fatalf("Agent failed with error: %v", err)
 	}
 
 	fmt.Println("\n--- Final Answer ---")
 	fmt.Println(finalAnswer)
+
+	if *exportPath != "" {
+		exportHistory(agent, historyFilePath, *exportPath)
+	}
+}
+
+// exportHistory writes the conversation stored at historyFilePath to outPath as
+// Markdown.
+func exportHistory(agent *Agent, historyFilePath, outPath string) {
+	history, err := agent.GetConversationHistory(historyFilePath)
+	if err != nil {
+		log.Fatalf("Failed to load history for export: %v", err)
+	}
+	markdown := ExportMarkdown(agent.parseHistory(history))
+	if err := os.WriteFile(outPath, []byte(markdown), 0644); err != nil {
+		log.Fatalf("Failed to write export file: %v", err)
+	}
+	log.Printf("Exported conversation to %s\n", outPath)
 }
@@ -0,0 +1,714 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunStopsMidRun(t *testing.T) {
+	stop := make(chan struct{})
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "halt", "arguments": {}}`,
+		`Final Answer: should not be reached`,
+	})
+	agent.AddTool(Tool{
+		Name: "halt",
+		Args: map[string]string{},
+		Function: func(map[string]interface{}) (string, error) {
+			close(stop) // the user types "stop" while the tool runs
+			return "halted", nil
+		},
+	})
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "go", stop)
+	if !errors.Is(err, ErrRunStopped) {
+		t.Fatalf("RunWithTrace error = %v, want ErrRunStopped", err)
+	}
+	if want := `Action: {"name": "halt", "arguments": {}}`; result.Answer.Text != want {
+		t.Errorf("partial answer = %q, want the latest model response", result.Answer.Text)
+	}
+	if len(result.Trace) != 2 || result.Trace[0].Observation != "halted" || !errors.Is(result.Trace[1].Error, ErrRunStopped) {
+		t.Errorf("trace = %+v, want the tool step then the stop", result.Trace)
+	}
+	if n := len(agent.Client.(*ScriptedClient).Prompts()); n != 1 {
+		t.Errorf("model called %d times, want the loop to stop after the current step", n)
+	}
+}
+
+func TestRunWithoutStopChannel(t *testing.T) {
+	agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`, "Final Answer: done"})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	if got, err := agent.Run(context.Background(), historyPath(t), "go", nil); err != nil || got != "done" {
+		t.Fatalf("Run = %q, %v; want done", got, err)
+	}
+}
+
+func TestWatchForStop(t *testing.T) {
+	stop := make(chan struct{})
+	var steered []string
+	watchForStop(strings.NewReader("use metric units\n\n  stop  \nignored\n"), stop, func(s string) {
+		steered = append(steered, s)
+	})
+	select {
+	case <-stop:
+	case <-time.After(time.Second):
+		t.Fatal("stop channel not closed")
+	}
+	if strings.Join(steered, "|") != "use metric units" {
+		t.Errorf("steered with %q, want only the line before stop", steered)
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&OllamaError{StatusCode: 400, Body: `{"error":"prompt too long; exceeded max context length"}`}, true},
+		{fmt.Errorf("generate: %w", &OllamaError{StatusCode: 500, Body: "input exceeds the Context Window"}), true},
+		{&OllamaError{StatusCode: 404, Body: "model not found"}, false},
+		{errors.New("context length exceeded"), false}, // not from Ollama
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isContextLengthError(tt.err); got != tt.want {
+			t.Errorf("isContextLengthError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	history := "\nUser: one\nAssistant: two\nUser: three\nAssistant: four"
+	got := trimHistory(history)
+	if !strings.HasPrefix(got, "\n") || !strings.HasSuffix(history, got) || len(got) >= len(history) {
+		t.Errorf("trimHistory = %q, want a shorter suffix starting at a line break", got)
+	}
+	if strings.Contains(got, "one") || !strings.Contains(got, "four") {
+		t.Errorf("trimHistory = %q, want the oldest entries dropped and the newest kept", got)
+	}
+	if got := trimHistory("no line breaks"); got != "" {
+		t.Errorf("trimHistory of one line = %q, want empty", got)
+	}
+}
+
+func TestRunRetriesWithTrimmedHistory(t *testing.T) {
+	path := historyPath(t)
+	var old strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&old, "\nUser: old question %d\nAssistant: old answer %d", i, i)
+	}
+	if err := os.WriteFile(path, []byte(old.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var prompts []string
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			return "", &OllamaError{StatusCode: 400, Body: "prompt exceeds context length"}
+		}
+		return "Final Answer: fits now", nil
+	})
+
+	got, err := agent.Run(context.Background(), path, "new question", nil)
+	if err != nil || got != "fits now" {
+		t.Fatalf("Run = %q, %v; want the retried answer", got, err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("model called %d times, want 2", len(prompts))
+	}
+	if len(prompts[1]) >= len(prompts[0]) || strings.Contains(prompts[1], "old question 0\n") || !strings.Contains(prompts[1], "old answer 19") {
+		t.Errorf("retry prompt did not drop the oldest history:\n%s", prompts[1])
+	}
+	if !strings.Contains(prompts[1], "new question") {
+		t.Error("retry prompt lost the user's question")
+	}
+}
+
+func TestRunGivesUpOnContextLengthWithoutHistory(t *testing.T) {
+	calls := 0
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return "", &OllamaError{StatusCode: 400, Body: "context length exceeded"}
+	})
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); !isContextLengthError(err) {
+		t.Fatalf("Run error = %v, want the context length error", err)
+	}
+	if calls != 1 {
+		t.Errorf("model called %d times with nothing to trim, want 1", calls)
+	}
+}
+
+func TestAgentEndpoint(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"http://localhost:11434", generatePath, "http://localhost:11434/api/generate"},
+		{"http://localhost:11434/", chatPath, "http://localhost:11434/api/chat"},
+		{"http://localhost:11434/api/generate", tagsPath, "http://localhost:11434/api/tags"},
+		{"http://localhost:11434/api/chat/", showPath, "http://localhost:11434/api/show"},
+		{"https://proxy.example/ollama", generatePath, "https://proxy.example/ollama/api/generate"},
+		{"https://proxy.example/ollama/api/generate", chatPath, "https://proxy.example/ollama/api/chat"},
+	}
+	for _, tt := range tests {
+		if got := NewAgent(tt.base, "m").endpoint(tt.path); got != tt.want {
+			t.Errorf("endpoint(%q, %q) = %q, want %q", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCallOllamaUsesGenerateEndpoint(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fmt.Fprintln(w, `{"response":"ok","done":true}`)
+	}))
+	defer srv.Close()
+
+	for _, base := range []string{srv.URL, srv.URL + "/api/generate"} {
+		if _, err := NewAgent(base, "m").CallOllamaContext(context.Background(), "p"); err != nil {
+			t.Fatalf("CallOllamaContext via %s: %v", base, err)
+		}
+	}
+	if strings.Join(paths, " ") != "/api/generate /api/generate" {
+		t.Errorf("requested paths %q, want /api/generate for both the base and the legacy full URL", paths)
+	}
+}
+
+func TestGetConversationHistoryNormalizesWindowsFiles(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+
+	path := historyPath(t)
+	os.WriteFile(path, []byte("\ufeffUser: hi\r\nAssistant: hello\r\nUser: old mac\rAssistant: ok\r\n"), 0644)
+	got, err := agent.GetConversationHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "User: hi\nAssistant: hello\nUser: old mac\nAssistant: ok\n"; got != want {
+		t.Errorf("transcript history = %q, want %q", got, want)
+	}
+
+	jsonPath := historyPath(t)
+	os.WriteFile(jsonPath, []byte("\ufeff[\r\n  {\"role\": \"user\", \"type\": \"user\", \"content\": \"hi\"}\r\n]\r\n"), 0644)
+	got, err = agent.GetConversationHistory(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(got, "\ufeff\r") || !strings.Contains(got, "User: hi") {
+		t.Errorf("JSON history = %q, want clean content", got)
+	}
+}
+
+func TestPromptHasNoBOM(t *testing.T) {
+	path := historyPath(t)
+	os.WriteFile(path, []byte("\ufeffUser: earlier\r\n"), 0644)
+	agent := ScriptedAgent([]string{"Final Answer: ok"})
+	if _, err := agent.Run(context.Background(), path, "now", nil); err != nil {
+		t.Fatal(err)
+	}
+	prompt := agent.Client.(*ScriptedClient).Prompts()[0]
+	if strings.ContainsAny(prompt, "\ufeff\r") || !strings.Contains(prompt, "User: earlier\n") {
+		t.Errorf("prompt carries the BOM or CRLFs:\n%q", prompt)
+	}
+}
+
+func TestBuildPromptMatchesRun(t *testing.T) {
+	path := historyPath(t)
+	os.WriteFile(path, []byte("\nUser: earlier\nAssistant: reply"), 0644)
+
+	agent := ScriptedAgent([]string{"Final Answer: ok"})
+	agent.AddTool(ScriptedTool("lookup"))
+	history, err := agent.GetConversationHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built := agent.BuildPrompt(history, "what now?")
+	if built != agent.GeneratePrompt(history, "what now?") {
+		t.Error("BuildPrompt differs from GeneratePrompt")
+	}
+	for _, want := range []string{"lookup", "User: earlier", "what now?"} {
+		if !strings.Contains(built, want) {
+			t.Errorf("prompt is missing %q:\n%s", want, built)
+		}
+	}
+
+	if _, err := agent.Run(context.Background(), path, "what now?", nil); err != nil {
+		t.Fatal(err)
+	}
+	if sent := agent.Client.(*ScriptedClient).Prompts()[0]; sent != built {
+		t.Errorf("Run sent\n%s\nbut BuildPrompt gave\n%s", sent, built)
+	}
+}
+
+// slowAfterClient answers with the first response, then blocks every later
+// call until its context ends.
+func slowAfterClient(first string) LLMClient {
+	calls := 0
+	return LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		if calls == 1 {
+			return first, nil
+		}
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+}
+
+func TestRunMaxRunDuration(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = slowAfterClient(`Action: {"name": "t", "arguments": {}}`)
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.MaxRunDuration = 50 * time.Millisecond
+
+	start := time.Now()
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if !errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("RunWithTrace error = %v, want ErrRunTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("run took %v, want it cut off near MaxRunDuration", elapsed)
+	}
+	if result.Answer.Text != `Action: {"name": "t", "arguments": {}}` {
+		t.Errorf("partial answer = %q, want the last model response", result.Answer.Text)
+	}
+	if last := result.Trace[len(result.Trace)-1]; !errors.Is(last.Error, ErrRunTimeout) {
+		t.Errorf("last step error = %v, want ErrRunTimeout", last.Error)
+	}
+}
+
+func TestRunCallerCancellationIsNotATimeout(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = slowAfterClient(`Action: {"name": "t", "arguments": {}}`)
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.MaxRunDuration = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := agent.Run(ctx, historyPath(t), "q", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run error = %v, want the caller's context error", err)
+	}
+}
+
+func TestRunWithTraceRecordsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agent := ScriptedAgent([]string{
+		"Thought: look it up\nAction: {\"name\": \"t\", \"arguments\": {}}",
+		"Final Answer: never reached",
+	})
+	agent.AddTool(Tool{Name: "t", Function: func(map[string]interface{}) (string, error) {
+		cancel()
+		return "looked up", nil
+	}})
+
+	result, err := agent.RunWithTrace(ctx, historyPath(t), "q", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunWithTrace error = %v, want context.Canceled", err)
+	}
+	if len(result.Trace) != 2 {
+		t.Fatalf("trace has %d steps, want the tool step and the cancellation step: %+v", len(result.Trace), result.Trace)
+	}
+	first := result.Trace[0]
+	if first.Action == nil || first.Action.Name != "t" || first.Observation != "looked up" || first.Error != nil {
+		t.Errorf("first step = %+v, want the completed tool call", first)
+	}
+	if last := result.Trace[1]; !errors.Is(last.Error, context.Canceled) || last.Response != "" {
+		t.Errorf("last step = %+v, want only the cancellation", last)
+	}
+	if !strings.Contains(result.Answer.Text, "Thought: look it up") {
+		t.Errorf("answer = %q, want the partial response", result.Answer.Text)
+	}
+}
+
+func TestExplainFailures(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "t", "arguments": {}}`,
+		"Final Answer: I ran out of steps, but the lookup said ok.",
+	})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.MaxSteps = 1
+	agent.ExplainFailures = true
+
+	got, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+	if err == nil || !strings.Contains(err.Error(), "within 1 steps") {
+		t.Fatalf("Run error = %v, want the step limit error", err)
+	}
+	if got != "I ran out of steps, but the lookup said ok." {
+		t.Errorf("answer = %q, want the explanation", got)
+	}
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "within 1 steps") || !strings.Contains(prompts[1], "Do not use any tools") {
+		t.Errorf("explanation prompt does not describe the failure:\n%s", prompts[len(prompts)-1])
+	}
+}
+
+func TestExplainFailuresKeepsErrorWhenExplanationFails(t *testing.T) {
+	// The script runs out, so the explanation call fails too.
+	agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.MaxSteps = 1
+	agent.ExplainFailures = true
+
+	got, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+	if err == nil || !strings.Contains(err.Error(), "within 1 steps") || got != "" {
+		t.Fatalf("Run = %q, %v; want no answer and the original error", got, err)
+	}
+}
+
+func TestExplainFailuresSkipsStoppedRuns(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+	agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`, "Final Answer: explanation"})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.ExplainFailures = true
+
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", stop); !errors.Is(err, ErrRunStopped) {
+		t.Fatalf("Run error = %v, want ErrRunStopped", err)
+	}
+	if n := len(agent.Client.(*ScriptedClient).Prompts()); n != 1 {
+		t.Errorf("model called %d times, want no explanation for a stopped run", n)
+	}
+}
+
+func TestRetryBudgetSharedAcrossRetryKinds(t *testing.T) {
+	newAgent := func(responses ...string) *Agent {
+		agent := ScriptedAgent(responses)
+		agent.AddTool(ScriptedTool("flaky", "error: upstream down", "error: upstream down"))
+		agent.MaxSteps = 10
+		agent.MaxRetriesPerRun = 2
+		return agent
+	}
+	malformed := "I am not sure what to do."
+	failing := `Action: {"name": "flaky", "arguments": {}}`
+	unknown := `Action: {"name": "nope", "arguments": {}}`
+
+	// A parse retry, a tool retry and an unknown-tool retry: one too many.
+	agent := newAgent(malformed, failing, unknown, "Final Answer: too late")
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); !errors.Is(err, ErrRetryBudgetExceeded) {
+		t.Fatalf("Run error = %v, want ErrRetryBudgetExceeded", err)
+	}
+	if n := len(agent.Client.(*ScriptedClient).Prompts()); n != 3 {
+		t.Errorf("model called %d times, want the run to end on the third retry", n)
+	}
+
+	// Two retries fit within the budget.
+	agent = newAgent(malformed, failing, "Final Answer: made it")
+	if got, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil || got != "made it" {
+		t.Errorf("Run = %q, %v; want the answer within budget", got, err)
+	}
+
+	// Zero means no budget at all.
+	agent = newAgent(malformed, failing, unknown, "Final Answer: fine")
+	agent.MaxRetriesPerRun = 0
+	if got, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil || got != "fine" {
+		t.Errorf("Run without a budget = %q, %v", got, err)
+	}
+}
+
+func TestRunLogsOnlyWhenVerbose(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, verbose := range []bool{false, true} {
+		buf.Reset()
+		agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`, "Final Answer: private answer"})
+		agent.AddTool(ScriptedTool("t", "ok"))
+		agent.Verbose = verbose
+		if _, err := agent.Run(context.Background(), historyPath(t), "private question", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		logged := buf.String()
+		if !verbose && logged != "" {
+			t.Errorf("non-verbose run logged:\n%s", logged)
+		}
+		if verbose && (!strings.Contains(logged, "--- Sending prompt to LLM ---") || !strings.Contains(logged, "private question") || !strings.Contains(logged, "--- Calling tool: t")) {
+			t.Errorf("verbose run did not log the prompt and tool call:\n%s", logged)
+		}
+	}
+}
+
+func TestAnswerFilters(t *testing.T) {
+	path := historyPath(t)
+	agent := ScriptedAgent([]string{"Final Answer: Sure! Here's the answer: call 555-0100."})
+	agent.AnswerFilters = []func(string) string{
+		func(s string) string { return strings.TrimPrefix(s, "Sure! Here's the answer: ") },
+		func(s string) string { return strings.ReplaceAll(s, "555-0100", "[redacted]") },
+	}
+
+	got, err := agent.Run(context.Background(), path, "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "call [redacted]." {
+		t.Errorf("answer = %q, want both filters applied in order", got)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), "Assistant: call [redacted].") || strings.Contains(string(saved), "Sure!") {
+		t.Errorf("history holds the unfiltered answer: %q", saved)
+	}
+}
+
+func TestToolSystemUpdate(t *testing.T) {
+	for _, allow := range []bool{true, false} {
+		agent := ScriptedAgent([]string{`Action: {"name": "set_mode", "arguments": {}}`, "Final Answer: arr"})
+		agent.AllowSystemUpdates = allow
+		agent.AddTool(Tool{Name: "set_mode", Configure: func(map[string]interface{}) (ToolResult, error) {
+			return ToolResult{Observation: "mode set", SystemUpdate: "You are a pirate."}, nil
+		}})
+		if _, err := agent.Run(context.Background(), historyPath(t), "talk like a pirate", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		prompts := agent.Client.(*ScriptedClient).Prompts()
+		if strings.Contains(prompts[0], "You are a pirate.") {
+			t.Errorf("allow=%v: first prompt already has the new persona", allow)
+		}
+		if got := strings.Contains(prompts[1], "You are a pirate."); got != allow {
+			t.Errorf("allow=%v: second prompt has the new persona = %v:\n%s", allow, got, prompts[1])
+		}
+		if got := agent.SystemPrompt == "You are a pirate."; got != allow {
+			t.Errorf("allow=%v: SystemPrompt = %q", allow, agent.SystemPrompt)
+		}
+	}
+}
+
+func TestToolSystemUpdateDuringConcurrentRuns(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.AllowSystemUpdates = true
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		if strings.Contains(prompt, "talk like a pirate") && !strings.Contains(prompt, "mode set") {
+			return `Action: {"name": "set_mode", "arguments": {}}`, nil
+		}
+		return "Final Answer: ok", nil
+	})
+	agent.AddTool(Tool{Name: "set_mode", Configure: func(map[string]interface{}) (ToolResult, error) {
+		return ToolResult{Observation: "mode set", SystemUpdate: "You are a pirate."}, nil
+	}})
+
+	var wg sync.WaitGroup
+	for _, question := range []string{"talk like a pirate", "q", "q", "q"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := agent.Run(context.Background(), historyPath(t), question, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !strings.Contains(agent.GeneratePrompt("", "q"), "You are a pirate.") {
+		t.Error("the update was not kept for later runs")
+	}
+}
+
+func TestShowBudgetInPrompt(t *testing.T) {
+	call := `Action: {"name": "t", "arguments": {}}`
+	agent := ScriptedAgent([]string{call, call, "Final Answer: done"})
+	agent.AddTool(ScriptedTool("t", "ok", "ok"))
+	agent.MaxSteps = 4
+	agent.ShowBudgetInPrompt = true
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	for i, remaining := range []int{3, 2, 1} {
+		want := fmt.Sprintf("You have %d tool calls remaining; reach a Final Answer before they run out.", remaining)
+		if !strings.Contains(prompts[i], want) {
+			t.Errorf("prompt %d does not say %q:\n%s", i, want, prompts[i])
+		}
+	}
+
+	agent = ScriptedAgent([]string{"Final Answer: done"})
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := agent.Client.(*ScriptedClient).Prompts()[0]; strings.Contains(prompt, "tool calls remaining") {
+		t.Errorf("budget shown without ShowBudgetInPrompt:\n%s", prompt)
+	}
+}
+
+// seededModelServer fakes Ollama's generate endpoint with a model whose output
+// is random unless the request carries a seed. It reports the seed of every
+// request it receives, or -1 if one had none.
+func seededModelServer(t *testing.T) (*httptest.Server, func() []int64) {
+	t.Helper()
+	var mu sync.Mutex
+	var seeds []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		seed := int64(-1)
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		if s, ok := req.Options["seed"].(float64); ok {
+			seed = int64(s)
+			rng = rand.New(rand.NewSource(seed + int64(len(req.Prompt))))
+		}
+		mu.Lock()
+		seeds = append(seeds, seed)
+		mu.Unlock()
+
+		response := fmt.Sprintf(`Action: {"name": "echo", "arguments": {"from": "%d"}}`, rng.Intn(1e9))
+		if strings.Contains(req.Prompt, "Observation: from") {
+			response = fmt.Sprintf("Final Answer: %d", rng.Intn(1e9))
+		}
+		json.NewEncoder(w).Encode(OllamaResponse{Response: response, Done: true})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int64(nil), seeds...)
+	}
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	srv, seeds := seededModelServer(t)
+	run := func(seed *int64) *RunResult {
+		calls := 0
+		agent := NewAgent(srv.URL, "m")
+		agent.Seed = seed
+		agent.AddTool(echoTool(&calls))
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	seed, other := int64(42), int64(7)
+	first, second := run(&seed), run(&seed)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("runs with the same seed differ:\n%+v\n%+v", first, second)
+	}
+	for i, s := range seeds() {
+		if s != 42 {
+			t.Errorf("request %d carried seed %d, want 42 on every call", i, s)
+		}
+	}
+	if reflect.DeepEqual(first, run(&other)) {
+		t.Error("runs with different seeds match")
+	}
+	if n := len(seeds()); seeds()[n-1] != 7 {
+		t.Errorf("last request carried seed %d, want 7", seeds()[n-1])
+	}
+}
+
+func TestModelOptions(t *testing.T) {
+	agent := NewAgent("http://unused.invalid", "m")
+	if got := agent.modelOptions(nil); got != nil {
+		t.Errorf("modelOptions without settings = %v, want nil", got)
+	}
+	seed := int64(42)
+	agent.Seed = &seed
+	agent.MaxTokens = 100
+	got := agent.modelOptions(map[string]interface{}{"stop": []string{"x"}})
+	want := map[string]interface{}{"seed": int64(42), "num_predict": 100, "stop": []string{"x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("modelOptions = %v, want %v", got, want)
+	}
+}
+
+// truncatingServer answers the i-th generate request with replies[i], as a
+// single JSON response, repeating the last reply once they run out. It
+// returns the requests it received.
+func truncatingServer(t *testing.T, replies ...string) (*httptest.Server, *[]OllamaRequest) {
+	t.Helper()
+	var requests []OllamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		fmt.Fprint(w, replies[min(len(requests), len(replies))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestRunContinuesTruncatedResponse(t *testing.T) {
+	srv, requests := truncatingServer(t,
+		`{"response":"Thought: the forecast says it will be sun","done":true,"done_reason":"length"}`,
+		`{"response":"ny.\nFinal Answer: sunny","done":true,"done_reason":"stop"}`,
+	)
+	agent := NewAgent(srv.URL, "m")
+	agent.MaxTokens = 12
+
+	got, err := agent.Run(context.Background(), historyPath(t), "weather?", nil)
+	if err != nil || got != "sunny" {
+		t.Fatalf("Run = %q, %v; want the continued answer", got, err)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("made %d requests, want one continuation", len(*requests))
+	}
+	first, second := (*requests)[0], (*requests)[1]
+	if first.Options["num_predict"] != 12.0 || second.Options["num_predict"] != 12.0 {
+		t.Errorf("num_predict = %v, %v; want MaxTokens on every call", first.Options["num_predict"], second.Options["num_predict"])
+	}
+	if second.Prompt != first.Prompt+"Thought: the forecast says it will be sun" {
+		t.Errorf("continuation prompt does not end with the truncated text:\n%s", second.Prompt)
+	}
+}
+
+func TestRunGivesUpContinuingTruncatedResponse(t *testing.T) {
+	srv, requests := truncatingServer(t, `{"response":"Final Answer: it depends on ","done":true,"done_reason":"length"}`)
+	agent := NewAgent(srv.URL, "m")
+	agent.MaxTokens = 8
+
+	got, err := agent.Run(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := len(*requests); n != maxTruncationContinues+1 {
+		t.Errorf("made %d requests, want %d", n, maxTruncationContinues+1)
+	}
+	if !strings.HasPrefix(got, "it depends on") {
+		t.Errorf("answer = %q, want the truncated text used as is", got)
+	}
+}
+
+func TestSetMaxSteps(t *testing.T) {
+	if got := NewAgent("http://unused.invalid", "m").MaxSteps; got != 5 {
+		t.Errorf("default MaxSteps = %d, want 5", got)
+	}
+
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		return `{"name": "t", "arguments": {}}`, nil // never answers
+	})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	for _, n := range []int{0, -3} {
+		if err := agent.SetMaxSteps(n); err == nil || err.Error() != fmt.Sprintf("max steps must be at least 1, got %d", n) {
+			t.Errorf("SetMaxSteps(%d) = %v, want it rejected", n, err)
+		}
+	}
+	if err := agent.SetMaxSteps(3); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err == nil || err.Error() != "agent failed to find a final answer within 3 steps" {
+		t.Errorf("RunWithTrace error = %v, want the configured limit reported", err)
+	}
+	if len(result.Trace) != 3 {
+		t.Errorf("took %d steps, want 3", len(result.Trace))
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestCommandTimeout bounds how long a manifest tool's command may run.
+const manifestCommandTimeout = 30 * time.Second
+
+// placeholderPattern matches {{arg}} placeholders in manifest command templates.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// ToolManifest is the on-disk format for declaratively defined tools.
+type ToolManifest struct {
+	Tools []ManifestTool `json:"tools" yaml:"tools"`
+}
+
+// ManifestTool declares a tool backed by a shell command. Command may contain
+// {{arg}} placeholders naming entries in Args; the argument values are passed
+// to bash as positional parameters, so they are never parsed as shell syntax
+// wherever the placeholder appears.
+type ManifestTool struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description" yaml:"description"`
+	Args        map[string]string `json:"args" yaml:"args"`
+	Command     string            `json:"command" yaml:"command"`
+}
+
+// LoadToolsFromManifest reads a JSON or YAML manifest (chosen by file
+// extension) and turns each entry into a Tool that runs its command with bash,
+// subject to DefaultCommandPolicy.
+func LoadToolsFromManifest(path string) ([]Tool, error) {
+	return LoadToolsFromManifestWithPolicy(path, DefaultCommandPolicy)
+}
+
+// LoadToolsFromManifestWithPolicy is like LoadToolsFromManifest but checks
+// commands against policy. Every placeholder in a command template must name
+// a declared argument, and every program the command runs must be allowed.
+func LoadToolsFromManifestWithPolicy(path string, policy CommandPolicy) ([]Tool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool manifest: %v", err)
+	}
+
+	var manifest ToolManifest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &manifest)
+	default:
+		err = json.Unmarshal(data, &manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tool manifest %s: %v", path, err)
+	}
+
+	tools := make([]Tool, 0, len(manifest.Tools))
+	for i, mt := range manifest.Tools {
+		if err := mt.validate(policy); err != nil {
+			return nil, fmt.Errorf("invalid tool #%d in manifest %s: %v", i+1, path, err)
+		}
+		tools = append(tools, mt.tool())
+	}
+	return tools, nil
+}
+
+// validate checks that the entry is complete, that its command template only
+// references declared arguments and that policy allows the programs it runs.
+// Argument values cannot add programs, so checking the template is enough.
+func (mt ManifestTool) validate(policy CommandPolicy) error {
+	if mt.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if mt.Command == "" {
+		return fmt.Errorf("tool %s: missing command", mt.Name)
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(mt.Command, -1) {
+		if _, ok := mt.Args[m[1]]; !ok {
+			return fmt.Errorf("tool %s: command references undeclared argument %q", mt.Name, m[1])
+		}
+	}
+	script, _ := bindPlaceholders(mt.Command)
+	if err := policy.Check(script); err != nil {
+		return fmt.Errorf("tool %s: %v", mt.Name, err)
+	}
+	return nil
+}
+
+// tool converts the manifest entry into a runnable Tool.
+func (mt ManifestTool) tool() Tool {
+	script, params := bindPlaceholders(mt.Command)
+	return Tool{
+		Name:        mt.Name,
+		Description: mt.Description,
+		Args:        mt.Args,
+		Function: func(args map[string]interface{}) (string, error) {
+			// bash -c script $0 $1 ...: the tool name, then the values.
+			argv := []string{"-c", script, mt.Name}
+			var missing []string
+			for _, name := range params {
+				value, ok := args[name]
+				if !ok {
+					missing = append(missing, name)
+					continue
+				}
+				argv = append(argv, fmt.Sprint(value))
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("missing arguments: %s", strings.Join(missing, ", "))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), manifestCommandTimeout)
+			defer cancel()
+			output, err := exec.CommandContext(ctx, "bash", argv...).CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("command failed: %v: %s", err, strings.TrimSpace(string(output)))
+			}
+			return strings.TrimSpace(string(output)), nil
+		},
+	}
+}
+
+// bindPlaceholders rewrites the {{arg}} placeholders of a command template as
+// references to bash positional parameters, quoted to suit where each one
+// stands, and returns the argument named by each parameter in order.
+func bindPlaceholders(template string) (string, []string) {
+	var sb strings.Builder
+	var params []string
+	index := make(map[string]int)
+	var quote byte
+	last := 0
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		quote = scanQuotes(template[last:m[0]], quote)
+		sb.WriteString(template[last:m[0]])
+		name := template[m[2]:m[3]]
+		n, ok := index[name]
+		if !ok {
+			params = append(params, name)
+			n = len(params)
+			index[name] = n
+		}
+		ref := fmt.Sprintf("${%d}", n)
+		switch quote {
+		case '"':
+			sb.WriteString(ref)
+		case '\'':
+			sb.WriteString(`'"` + ref + `"'`) // step out of the single quotes
+		default:
+			sb.WriteString(`"` + ref + `"`)
+		}
+		last = m[1]
+	}
+	sb.WriteString(template[last:])
+	return sb.String(), params
+}
+
+// scanQuotes returns the bash quoting in effect after s, given the quoting in
+// effect before it.
+func scanQuotes(s string, quote byte) byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		}
+	}
+	return quote
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeManifest writes content to a file called name in a temporary directory.
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadToolsFromManifest(t *testing.T) {
+	requireCommand(t, "bash")
+	path := writeManifest(t, "tools.yaml", `
+tools:
+  - name: greet
+    description: Greets someone.
+    args:
+      who: string
+    command: echo "hello, "{{ who }}
+  - name: fail
+    description: Always fails.
+    args: {}
+    command: echo broken >&2; exit 1
+`)
+	tools, err := LoadToolsFromManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 || tools[0].Name != "greet" || tools[0].Description != "Greets someone." || tools[0].Args["who"] != "string" {
+		t.Fatalf("loaded tools = %+v", tools)
+	}
+
+	got, err := tools[0].Function(map[string]interface{}{"who": "world"})
+	if err != nil || got != "hello, world" {
+		t.Errorf("greet = %q, %v; want hello, world", got, err)
+	}
+	// Argument values are passed as one literal word, never as shell syntax.
+	got, err = tools[0].Function(map[string]interface{}{"who": `it's $(whoami); exit 3`})
+	if err != nil || got != `hello, it's $(whoami); exit 3` {
+		t.Errorf("greet with shell syntax = %q, %v; want it echoed literally", got, err)
+	}
+	if _, err := tools[0].Function(map[string]interface{}{}); err == nil || err.Error() != "missing arguments: who" {
+		t.Errorf("greet without who: got error %v", err)
+	}
+
+	if _, err := tools[1].Function(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("failing command: got error %v, want its output included", err)
+	}
+}
+
+func TestLoadToolsFromJSONManifest(t *testing.T) {
+	requireCommand(t, "bash")
+	path := writeManifest(t, "tools.json", `{"tools": [{"name": "add", "args": {"a": "number", "b": "number"}, "command": "expr {{a}} + {{b}}"}]}`)
+	tools, err := LoadToolsFromManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tools[0].Function(map[string]interface{}{"a": 2.0, "b": 3.0}); err != nil || got != "5" {
+		t.Errorf("add = %q, %v; want 5", got, err)
+	}
+}
+
+func TestLoadToolsFromManifestErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"undeclared.yaml", "tools:\n  - name: t\n    args: {x: string}\n    command: echo {{y}}\n", `command references undeclared argument "y"`},
+		{"noname.json", `{"tools": [{"command": "true"}]}`, "missing name"},
+		{"nocommand.json", `{"tools": [{"name": "t"}]}`, "tool t: missing command"},
+		{"broken.json", `{"tools": [`, "failed to parse tool manifest"},
+		{"denied.yaml", "tools:\n  - name: t\n    args: {}\n    command: ls | xargs rm\n", "tool t: command policy does not allow running xargs"},
+		{"dynamic.yaml", "tools:\n  - name: t\n    args: {cmd: string}\n    command: '{{cmd}} --help'\n", `command policy requires a literal program name, not "${1}"`},
+	}
+	for _, tt := range tests {
+		_, err := LoadToolsFromManifest(writeManifest(t, tt.name, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := LoadToolsFromManifest(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loading a missing manifest succeeded")
+	}
+}
+
+func TestManifestPlaceholdersAreNeverShellSyntax(t *testing.T) {
+	requireCommand(t, "bash")
+	marker := filepath.Join(t.TempDir(), "ran")
+	path := writeManifest(t, "tools.yaml", `
+tools:
+  - name: quoted
+    args: {q: string}
+    command: echo "q={{q}}"
+  - name: single
+    args: {q: string}
+    command: echo 'q={{q}}'
+  - name: bare
+    args: {q: string}
+    command: echo q={{q}} {{q}}
+`)
+	tools, err := LoadToolsFromManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := "$(touch " + marker + ") `touch " + marker + "` '\"; touch " + marker
+	for _, tool := range tools {
+		got, err := tool.Function(map[string]interface{}{"q": value})
+		want := "q=" + value
+		if tool.Name == "bare" {
+			want += " " + value
+		}
+		if err != nil || got != want {
+			t.Errorf("%s = %q, %v; want the value echoed literally", tool.Name, got, err)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("an argument value was run as a command")
+	}
+}
+
+func TestBindPlaceholders(t *testing.T) {
+	script, params := bindPlaceholders(`grep {{pattern}} "{{file}}" '{{file}}' -m {{ n }}`)
+	if want := `grep "${1}" "${2}" ''"${2}"'' -m "${3}"`; script != want {
+		t.Errorf("script = %s, want %s", script, want)
+	}
+	if strings.Join(params, ",") != "pattern,file,n" {
+		t.Errorf("params = %v", params)
+	}
+}
+
+func TestCommandPolicy(t *testing.T) {
+	tests := []struct {
+		command string
+		names   string
+	}{
+		{`echo "hi" | tr a-z A-Z`, "echo,tr"},
+		{`LANG=C sort -u file && /usr/bin/wc -l < file 2>&1; date`, "sort,/usr/bin/wc,date"},
+		{`echo "today is $(date +%A)" > out.txt`, "echo,date"},
+		{"echo `whoami`", "echo,whoami"},
+		{`if test -f x; then cat x; fi`, "test,cat"},
+		{`for f in a b; do wc "$f"; done`, "wc"},
+		{`"${1}" --help`, "${1}"},
+		{`$(which rm) -rf /`, "which,$(...)"},
+		{`echo 'a; rm -rf /'`, "echo"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(commandNames(tt.command), ","); got != tt.names {
+			t.Errorf("commandNames(%q) = %s, want %s", tt.command, got, tt.names)
+		}
+	}
+
+	policy := CommandPolicy{Allow: []string{"echo", "date"}, Deny: []string{"date"}}
+	for command, want := range map[string]string{
+		"echo hi":                 "",
+		"/bin/echo hi":            "",
+		"echo hi; date":           "command policy does not allow running date",
+		"echo $(cat /etc/passwd)": "command policy does not allow running cat",
+		"$PAGER file":             `command policy requires a literal program name, not "$PAGER"`,
+	} {
+		err := policy.Check(command)
+		if want == "" && err != nil || want != "" && (err == nil || err.Error() != want) {
+			t.Errorf("Check(%q) = %v, want %q", command, err, want)
+		}
+	}
+	if err := DefaultCommandPolicy.Check("find . -name '*.go' | xargs rm"); err == nil {
+		t.Error("DefaultCommandPolicy allowed xargs rm")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// maxMatrixDim caps the rows and columns of matrix tool operands.
+const maxMatrixDim = 100
+
+// NewMatrixTool returns a tool for matrix arithmetic on matrices given as
+// nested arrays of rows, e.g. [[1, 2], [3, 4]]. A flat array is a vector: a
+// row vector on the left of a product and a column vector on the right, so
+// 'multiply' also computes matrix-vector products.
+func NewMatrixTool() Tool {
+	return Tool{
+		Name:        "matrix",
+		Description: "A tool that adds, multiplies and transposes matrices and computes determinants. Matrices are arrays of rows, e.g. [[1, 2], [3, 4]].",
+		Args: map[string]string{
+			"operation": "string (e.g., 'add', 'multiply', 'transpose', 'determinant')",
+			"a":         "matrix (array of rows of numbers)",
+			"b":         "matrix (second operand for 'add' and 'multiply')",
+		},
+		OptionalArgs: []string{"b"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			a, aVector, err := matrixArg(args, "a", false)
+			if err != nil {
+				return "", err
+			}
+
+			var result interface{}
+			switch op {
+			case "add":
+				b, _, err := matrixArg(args, "b", false)
+				if err != nil {
+					return "", err
+				}
+				if len(a) != len(b) || len(a[0]) != len(b[0]) {
+					return "", fmt.Errorf("cannot add a %s matrix and a %s matrix: shapes must match", shape(a), shape(b))
+				}
+				sum := newMatrix(len(a), len(a[0]))
+				for i := range a {
+					for j := range a[i] {
+						sum[i][j] = a[i][j] + b[i][j]
+					}
+				}
+				result = sum
+			case "multiply":
+				b, bVector, err := matrixArg(args, "b", true)
+				if err != nil {
+					return "", err
+				}
+				if len(a[0]) != len(b) {
+					return "", fmt.Errorf("cannot multiply a %s matrix by a %s matrix: the first has %d columns but the second has %d rows", shape(a), shape(b), len(a[0]), len(b))
+				}
+				product := newMatrix(len(a), len(b[0]))
+				for i := range a {
+					for j := range b[0] {
+						for k := range b {
+							product[i][j] += a[i][k] * b[k][j]
+						}
+					}
+				}
+				result = product
+				switch {
+				case aVector && bVector:
+					result = product[0][0] // dot product
+				case bVector:
+					column := make([]float64, len(product))
+					for i := range product {
+						column[i] = product[i][0]
+					}
+					result = column
+				case aVector:
+					result = product[0]
+				}
+			case "transpose":
+				t := newMatrix(len(a[0]), len(a))
+				for i := range a {
+					for j := range a[i] {
+						t[j][i] = a[i][j]
+					}
+				}
+				result = t
+			case "determinant":
+				if len(a) != len(a[0]) {
+					return "", fmt.Errorf("determinant needs a square matrix, got %s", shape(a))
+				}
+				result = determinant(a)
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// matrixArg reads a matrix argument, sent as nested arrays or as a JSON
+// string holding them. A flat array is a row vector, or a column vector if
+// column is set; vector reports whether it was one.
+func matrixArg(args map[string]interface{}, key string, column bool) (m [][]float64, vector bool, err error) {
+	v := args[key]
+	if s, ok := v.(string); ok {
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, false, fmt.Errorf("invalid '%s' argument: %v", key, err)
+		}
+	}
+	rows, ok := v.([]interface{})
+	if !ok || len(rows) == 0 {
+		return nil, false, fmt.Errorf("missing or invalid '%s' argument, expected an array of rows", key)
+	}
+	if _, nested := rows[0].([]interface{}); !nested {
+		vec, err := numberRow(rows, key, 0)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(vec) > maxMatrixDim {
+			return nil, false, fmt.Errorf("'%s' is too large: at most %d elements are supported", key, maxMatrixDim)
+		}
+		if !column {
+			return [][]float64{vec}, true, nil
+		}
+		m = newMatrix(len(vec), 1)
+		for i, x := range vec {
+			m[i][0] = x
+		}
+		return m, true, nil
+	}
+
+	if len(rows) > maxMatrixDim {
+		return nil, false, fmt.Errorf("'%s' is too large: at most %d rows are supported", key, maxMatrixDim)
+	}
+	m = make([][]float64, len(rows))
+	for i, r := range rows {
+		row, ok := r.([]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("row %d of '%s' is not an array", i, key)
+		}
+		if m[i], err = numberRow(row, key, i); err != nil {
+			return nil, false, err
+		}
+		if len(m[i]) == 0 || len(m[i]) > maxMatrixDim {
+			return nil, false, fmt.Errorf("row %d of '%s' must have between 1 and %d numbers", i, key, maxMatrixDim)
+		}
+		if len(m[i]) != len(m[0]) {
+			return nil, false, fmt.Errorf("'%s' is not rectangular: row 0 has %d columns but row %d has %d", key, len(m[0]), i, len(m[i]))
+		}
+	}
+	return m, false, nil
+}
+
+// numberRow converts a row of decoded JSON values to numbers.
+func numberRow(row []interface{}, key string, i int) ([]float64, error) {
+	nums := make([]float64, len(row))
+	for j, x := range row {
+		f, ok := x.(float64)
+		if !ok {
+			return nil, fmt.Errorf("element [%d][%d] of '%s' is not a number", i, j, key)
+		}
+		nums[j] = f
+	}
+	return nums, nil
+}
+
+// newMatrix returns a zero rows×cols matrix.
+func newMatrix(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+// shape describes m's dimensions, e.g. "2x3".
+func shape(m [][]float64) string {
+	return fmt.Sprintf("%dx%d", len(m), len(m[0]))
+}
+
+// determinant computes the determinant of the square matrix m by Gaussian
+// elimination with partial pivoting. m is left untouched.
+func determinant(m [][]float64) float64 {
+	n := len(m)
+	a := newMatrix(n, n)
+	for i := range m {
+		copy(a[i], m[i])
+	}
+	det := 1.0
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if a[pivot][col] == 0 {
+			return 0
+		}
+		if pivot != col {
+			a[pivot], a[col] = a[col], a[pivot]
+			det = -det
+		}
+		det *= a[col][col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+		}
+	}
+	return det
+}
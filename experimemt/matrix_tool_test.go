@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMatrixTool(t *testing.T) {
+	tool := NewMatrixTool()
+	tests := []struct {
+		op, a, b, want string
+	}{
+		{"add", "[[1, 2], [3, 4]]", "[[10, 20], [30, 40]]", "[[11,22],[33,44]]"},
+		{"multiply", "[[1, 2, 3], [4, 5, 6]]", "[[7, 8], [9, 10], [11, 12]]", "[[58,64],[139,154]]"},
+		{"multiply", "[[1, 2], [3, 4]]", "[5, 6]", "[17,39]"}, // matrix × column vector
+		{"multiply", "[1, 2]", "[[1, 2], [3, 4]]", "[7,10]"},  // row vector × matrix
+		{"multiply", "[1, 2, 3]", "[4, 5, 6]", "32"},          // dot product
+		{"transpose", "[[1, 2, 3], [4, 5, 6]]", "", "[[1,4],[2,5],[3,6]]"},
+		{"determinant", "[[0, 1], [1, 0]]", "", "-1"},
+		{"determinant", "[[2, 4], [1, 2]]", "", "0"},
+		{"determinant", "[[7]]", "", "7"},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"operation": tt.op, "a": tt.a}
+		if tt.b != "" {
+			args["b"] = tt.b
+		}
+		got, err := tool.Function(args)
+		if err != nil || got != tt.want {
+			t.Errorf("%s %s %s = %s, %v; want %s", tt.op, tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestMatrixToolDeterminant(t *testing.T) {
+	tool := NewMatrixTool()
+	for _, tt := range []struct {
+		a    interface{}
+		want float64
+	}{
+		{[]interface{}{[]interface{}{4.0, 3.0}, []interface{}{6.0, 3.0}}, -6},
+		{"[[2, -3, 1], [2, 0, -1], [1, 4, 5]]", 49},
+	} {
+		got, err := tool.Function(map[string]interface{}{"operation": "determinant", "a": tt.a})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if det, err := strconv.ParseFloat(got, 64); err != nil || math.Abs(det-tt.want) > 1e-9 {
+			t.Errorf("determinant of %v = %s, want %v", tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestMatrixToolErrors(t *testing.T) {
+	tool := NewMatrixTool()
+	tests := []struct {
+		op, a, b, want string
+	}{
+		{"multiply", "[[1, 2, 3], [4, 5, 6]]", "[[1, 2], [3, 4]]", "cannot multiply a 2x3 matrix by a 2x2 matrix: the first has 3 columns but the second has 2 rows"},
+		{"multiply", "[1, 2]", "[1, 2, 3]", "cannot multiply a 1x2 matrix by a 3x1 matrix"},
+		{"add", "[[1, 2]]", "[[1], [2]]", "cannot add a 1x2 matrix and a 2x1 matrix: shapes must match"},
+		{"determinant", "[[1, 2, 3], [4, 5, 6]]", "", "determinant needs a square matrix, got 2x3"},
+		{"transpose", "[[1, 2], [3]]", "", "'a' is not rectangular: row 0 has 2 columns but row 1 has 1"},
+		{"transpose", `[[1, "x"]]`, "", "element [0][1] of 'a' is not a number"},
+		{"transpose", "[]", "", "missing or invalid 'a' argument"},
+		{"transpose", "[[1, 2]", "", "invalid 'a' argument"},
+		{"add", "[[1]]", "", "missing or invalid 'b' argument"},
+		{"invert", "[[1]]", "", "unsupported operation: invert"},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"operation": tt.op, "a": tt.a}
+		if tt.b != "" {
+			args["b"] = tt.b
+		}
+		if _, err := tool.Function(args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %s %s: error = %v, want %q", tt.op, tt.a, tt.b, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/flock"
+)
+
+// NewMemoryTool returns a tool that gives the agent durable key-value memory
+// across runs, stored as a JSON object in the file at path. Every operation
+// holds an exclusive lock on path+".lock", so concurrent agents sharing the
+// file cannot clobber each other's writes.
+func NewMemoryTool(path string) Tool {
+	store := &memoryStore{path: path, lock: flock.New(path + ".lock")}
+	return Tool{
+		Name:        "memory",
+		Description: "A tool that remembers facts across conversations, such as user preferences.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'set', 'get', 'delete', 'list')",
+			"key":       "string (not needed for 'list')",
+			"value":     "string (only for 'set')",
+		},
+		OptionalArgs: []string{"key", "value"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			if op == "list" {
+				return store.list()
+			}
+
+			key, ok := args["key"].(string)
+			if !ok || key == "" {
+				return "", fmt.Errorf("missing 'key' argument")
+			}
+			switch op {
+			case "set":
+				value, ok := args["value"].(string)
+				if !ok {
+					return "", fmt.Errorf("missing 'value' argument")
+				}
+				return store.set(key, value)
+			case "get":
+				return store.get(key)
+			case "delete":
+				return store.delete(key)
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// memoryStore is the file-backed state behind the memory tool.
+type memoryStore struct {
+	path string
+	mu   sync.Mutex // flock does not exclude callers sharing one handle
+	lock *flock.Flock
+}
+
+func (m *memoryStore) set(key, value string) (string, error) {
+	err := m.update(func(data map[string]string) (bool, error) {
+		data[key] = value
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Remembered %s.", key), nil
+}
+
+func (m *memoryStore) get(key string) (string, error) {
+	var value string
+	err := m.update(func(data map[string]string) (bool, error) {
+		v, ok := data[key]
+		if !ok {
+			return false, fmt.Errorf("no memory stored for key %q", key)
+		}
+		value = v
+		return false, nil
+	})
+	return value, err
+}
+
+func (m *memoryStore) delete(key string) (string, error) {
+	err := m.update(func(data map[string]string) (bool, error) {
+		if _, ok := data[key]; !ok {
+			return false, fmt.Errorf("no memory stored for key %q", key)
+		}
+		delete(data, key)
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Forgot %s.", key), nil
+}
+
+func (m *memoryStore) list() (string, error) {
+	var keys []string
+	err := m.update(func(data map[string]string) (bool, error) {
+		for k := range data {
+			keys = append(keys, k)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "No memories stored.", nil
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n"), nil
+}
+
+// update loads the store under the file lock, applies fn and, if fn reports a
+// change, writes the store back before releasing the lock.
+func (m *memoryStore) update(fn func(data map[string]string) (bool, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.lock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock memory file: %v", err)
+	}
+	defer m.lock.Unlock()
+
+	data := make(map[string]string)
+	raw, err := os.ReadFile(m.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read memory file: %v", err)
+	case len(raw) > 0:
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("failed to parse memory file: %v", err)
+		}
+	}
+
+	changed, err := fn(data)
+	if err != nil || !changed {
+		return err
+	}
+
+	raw, err = json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode memory file: %v", err)
+	}
+	if err := writeFileAtomic(m.path, raw); err != nil {
+		return fmt.Errorf("failed to save memory file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMemoryToolPersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	if _, err := NewMemoryTool(path).Function(map[string]interface{}{"operation": "set", "key": "color", "value": "blue"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	// A fresh tool, as a later run would create, sees the stored value.
+	tool := NewMemoryTool(path)
+	got, err := tool.Function(map[string]interface{}{"operation": "get", "key": "color"})
+	if err != nil || got != "blue" {
+		t.Fatalf("get = %q, %v; want blue", got, err)
+	}
+	if _, err := tool.Function(map[string]interface{}{"operation": "delete", "key": "color"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := tool.Function(map[string]interface{}{"operation": "get", "key": "color"}); err == nil {
+		t.Fatal("get after delete succeeded, want an error")
+	}
+	if got, _ := tool.Function(map[string]interface{}{"operation": "list"}); got != "No memories stored." {
+		t.Fatalf("list = %q", got)
+	}
+}
+
+func TestMemoryToolConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	shared := NewMemoryTool(path)
+	const writers = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Half share one tool, half use their own, like separate agents.
+			tool := shared
+			if i%2 == 1 {
+				tool = NewMemoryTool(path)
+			}
+			key := fmt.Sprintf("k%02d", i)
+			if _, err := tool.Function(map[string]interface{}{"operation": "set", "key": key, "value": key}); err != nil {
+				t.Errorf("set %s: %v", key, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got, err := shared.Function(map[string]interface{}{"operation": "list"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if keys := strings.Split(got, "\n"); len(keys) != writers {
+		t.Fatalf("list has %d keys, want %d; lost writes:\n%s", len(keys), writers, got)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// RunMetadata tags a run with caller-defined values such as a request or user
+// ID. It travels in the context passed to Run, and the observer and logger
+// include it in everything they report for that run.
+type RunMetadata map[string]string
+
+// runMetadataKey is the context key for RunMetadata.
+type runMetadataKey struct{}
+
+// WithRunMetadata returns a copy of ctx carrying md, merged over any metadata
+// ctx already carries.
+func WithRunMetadata(ctx context.Context, md RunMetadata) context.Context {
+	merged := RunMetadata{}
+	for k, v := range RunMetadataFrom(ctx) {
+		merged[k] = v
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, runMetadataKey{}, merged)
+}
+
+// RunMetadataFrom returns the metadata carried by ctx, or nil if there is none.
+func RunMetadataFrom(ctx context.Context) RunMetadata {
+	md, _ := ctx.Value(runMetadataKey{}).(RunMetadata)
+	return md
+}
+
+// String renders the metadata as space-separated key=value pairs in key order.
+func (md RunMetadata) String() string {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + md[k]
+	}
+	return strings.Join(pairs, " ")
+}
+
+// Kinds of RunEvent.
+const (
+	EventRunStart      = "run_start"
+	EventModelChunk    = "model_chunk" // streamed piece of a response
+	EventModelResponse = "model_response"
+	EventToolCall      = "tool_call"
+	EventObservation   = "observation"
+	EventFinalAnswer   = "final_answer"
+	EventRunEnd        = "run_end"
+)
+
+// RunEvent is something that happened during a run, as reported to
+// Agent.Observer.
+type RunEvent struct {
+	Kind     string
+	Metadata RunMetadata // from the run's context
+	Step     int         // zero-based loop iteration, or -1 if not tied to one
+	Tool     string      // for tool_call and observation
+	Text     string      // model response, observation or final answer
+	Err      error       // for run_end, why the run failed
+}
+
+// emit reports ev to the observer, tagged with the metadata in ctx.
+func (a *Agent) emit(ctx context.Context, ev RunEvent) {
+	if a.Observer == nil {
+		return
+	}
+	ev.Metadata = RunMetadataFrom(ctx)
+	a.Observer(ev)
+}
+
+// runLogger returns a logf that prefixes messages with the metadata in ctx.
+func (a *Agent) runLogger(ctx context.Context) func(format string, args ...interface{}) {
+	md := RunMetadataFrom(ctx)
+	if len(md) == 0 {
+		return a.logf
+	}
+	return func(format string, args ...interface{}) {
+		a.logf("[%s] "+format, append([]interface{}{md}, args...)...)
+	}
+}
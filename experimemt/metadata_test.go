@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithRunMetadataMerges(t *testing.T) {
+	ctx := WithRunMetadata(context.Background(), RunMetadata{"request_id": "r1", "user": "u1"})
+	ctx = WithRunMetadata(ctx, RunMetadata{"user": "u2", "tenant": "acme"})
+	md := RunMetadataFrom(ctx)
+	if want := (RunMetadata{"request_id": "r1", "user": "u2", "tenant": "acme"}); !reflect.DeepEqual(md, want) {
+		t.Errorf("merged metadata = %v, want %v", md, want)
+	}
+	if got := md.String(); got != "request_id=r1 tenant=acme user=u2" {
+		t.Errorf("String = %q", got)
+	}
+	if md := RunMetadataFrom(context.Background()); md != nil {
+		t.Errorf("metadata of a plain context = %v, want nil", md)
+	}
+}
+
+func TestRunMetadataReachesObserver(t *testing.T) {
+	agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`, "Final Answer: done"})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	var events []RunEvent
+	agent.Observer = func(ev RunEvent) { events = append(events, ev) }
+
+	md := RunMetadata{"request_id": "r1", "user": "u1"}
+	if _, err := agent.Run(WithRunMetadata(context.Background(), md), historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind)
+		if !reflect.DeepEqual(ev.Metadata, md) {
+			t.Errorf("%s event metadata = %v, want %v", ev.Kind, ev.Metadata, md)
+		}
+	}
+	want := []string{EventRunStart, EventModelResponse, EventToolCall, EventObservation, EventModelResponse, EventFinalAnswer, EventRunEnd}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("events = %v, want %v", kinds, want)
+	}
+}
+
+func TestRunMetadataTagsLogs(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	agent := ScriptedAgent([]string{`Action: {"name": "t", "arguments": {}}`, "Final Answer: done"})
+	agent.AddTool(ScriptedTool("t", "ok"))
+	agent.Verbose = true
+	ctx := WithRunMetadata(context.Background(), RunMetadata{"request_id": "r1"})
+	if _, err := agent.Run(ctx, historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "[request_id=r1] --- Calling tool: t") {
+		t.Errorf("logs are not tagged with the run metadata:\n%s", buf.String())
+	}
+}
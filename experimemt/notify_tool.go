@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// NotifyConfig configures the delivery channels of the notify tool. A channel
+// is offered only when its settings are present.
+type NotifyConfig struct {
+	// WebhookURL receives a JSON POST of {"subject", "body"}.
+	WebhookURL string
+
+	// SMTPAddr is the mail server as host:port. SMTPUser and SMTPPassword
+	// enable PLAIN authentication when set.
+	SMTPAddr     string
+	SMTPUser     string
+	SMTPPassword string
+	From         string
+	To           []string
+}
+
+// notifyTimeout bounds a webhook delivery.
+const notifyTimeout = 10 * time.Second
+
+// NewNotifyTool returns a tool that sends a notification over a webhook or by
+// email. Sending has side effects, so the tool requires confirmation (see
+// Agent.ConfirmToolCall). The observation reports how the delivery went. Only
+// the webhook's host may be contacted, and only at a public address.
+func NewNotifyTool(config NotifyConfig) Tool {
+	client := SafeHTTPClient([]string{urlHost(config.WebhookURL)})
+	client.Timeout = notifyTimeout
+	return NewNotifyToolWithClient(config, client)
+}
+
+// NewNotifyToolWithClient is like NewNotifyTool but delivers webhooks with
+// client, e.g. one that may reach a webhook on the local network.
+func NewNotifyToolWithClient(config NotifyConfig, client *http.Client) Tool {
+	var channels []string
+	if config.WebhookURL != "" {
+		channels = append(channels, "'webhook'")
+	}
+	if config.SMTPAddr != "" {
+		channels = append(channels, "'email'")
+	}
+
+	return Tool{
+		Name:                 "notify",
+		Description:          "A tool that sends a notification to the user's configured webhook or email address.",
+		RequiresConfirmation: true,
+		Args: map[string]string{
+			"channel": fmt.Sprintf("string (one of %s)", strings.Join(channels, ", ")),
+			"subject": "string",
+			"body":    "string",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			channel, ok := args["channel"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'channel' argument")
+			}
+			subject, ok := args["subject"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'subject' argument")
+			}
+			body, ok := args["body"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'body' argument")
+			}
+
+			switch channel {
+			case "webhook":
+				if config.WebhookURL == "" {
+					return "", fmt.Errorf("the webhook channel is not configured")
+				}
+				payload, err := json.Marshal(map[string]string{"subject": subject, "body": body})
+				if err != nil {
+					return "", fmt.Errorf("failed to encode notification: %v", err)
+				}
+				resp, err := client.Post(config.WebhookURL, "application/json", bytes.NewReader(payload))
+				if err != nil {
+					return "", fmt.Errorf("webhook delivery failed: %v", err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+					msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+					return "", fmt.Errorf("webhook delivery failed with status %d: %s", resp.StatusCode, msg)
+				}
+				return fmt.Sprintf("Notification delivered via webhook (status %d).", resp.StatusCode), nil
+			case "email":
+				if config.SMTPAddr == "" || len(config.To) == 0 {
+					return "", fmt.Errorf("the email channel is not configured")
+				}
+				var auth smtp.Auth
+				if config.SMTPUser != "" {
+					host, _, _ := strings.Cut(config.SMTPAddr, ":")
+					auth = smtp.PlainAuth("", config.SMTPUser, config.SMTPPassword, host)
+				}
+				msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+					config.From, strings.Join(config.To, ", "), sanitizeHeader(subject), body)
+				if err := smtp.SendMail(config.SMTPAddr, auth, config.From, config.To, []byte(msg)); err != nil {
+					return "", fmt.Errorf("email delivery failed: %v", err)
+				}
+				return fmt.Sprintf("Notification emailed to %s.", strings.Join(config.To, ", ")), nil
+			default:
+				return "", fmt.Errorf("unsupported channel: %s", channel)
+			}
+		},
+	}
+}
+
+// sanitizeHeader keeps a header value on one line so that model-written text
+// cannot inject extra headers.
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
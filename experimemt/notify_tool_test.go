@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotifyToolWebhook(t *testing.T) {
+	var payloads []map[string]string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+		w.WriteHeader(status)
+		if status >= 300 {
+			fmt.Fprint(w, "receiver is down")
+		}
+	}))
+	defer srv.Close()
+	tool := NewNotifyToolWithClient(NotifyConfig{WebhookURL: srv.URL}, srv.Client())
+
+	if !tool.RequiresConfirmation {
+		t.Error("notify does not require confirmation")
+	}
+	if desc := tool.Args["channel"]; !strings.Contains(desc, "'webhook'") || strings.Contains(desc, "'email'") {
+		t.Errorf("channel description = %q, want only the configured webhook", desc)
+	}
+
+	args := map[string]interface{}{"channel": "webhook", "subject": "Disk full", "body": "/var is at 98%"}
+	got, err := tool.Function(args)
+	if err != nil || got != "Notification delivered via webhook (status 204)." {
+		t.Errorf("notify = %q, %v", got, err)
+	}
+	if len(payloads) != 1 || payloads[0]["subject"] != "Disk full" || payloads[0]["body"] != "/var is at 98%" {
+		t.Errorf("payloads = %v", payloads)
+	}
+
+	status = http.StatusServiceUnavailable
+	if _, err := tool.Function(args); err == nil || !strings.Contains(err.Error(), "status 503: receiver is down") {
+		t.Errorf("failed delivery error = %v, want the status and body", err)
+	}
+
+	for _, channel := range []string{"email", "pager"} {
+		args["channel"] = channel
+		if _, err := tool.Function(args); err == nil {
+			t.Errorf("notify over %s succeeded", channel)
+		}
+	}
+}
+
+func TestNotifyToolNeedsConfirmation(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+	agent := ScriptedAgent([]string{
+		`{"name": "notify", "arguments": {"channel": "webhook", "subject": "s", "body": "b"}}`,
+		"Final Answer: not sent",
+	})
+	agent.AddTool(NewNotifyToolWithClient(NotifyConfig{WebhookURL: srv.URL}, srv.Client()))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 0 || !strings.Contains(result.Trace[0].Observation, "did not confirm") {
+		t.Errorf("unconfirmed notify hit the webhook %d times, observation %q", hits, result.Trace[0].Observation)
+	}
+}
+
+// fakeSMTPServer accepts one message and sends its DATA on the returned
+// channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				fmt.Fprint(conn, "250 localhost\r\n")
+			case cmd == "DATA":
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				messages <- data.String()
+				fmt.Fprint(conn, "250 queued\r\n")
+			case cmd == "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestNotifyToolEmail(t *testing.T) {
+	addr, messages := fakeSMTPServer(t)
+	tool := NewNotifyTool(NotifyConfig{SMTPAddr: addr, From: "agent@example.com", To: []string{"ops@example.com"}})
+
+	got, err := tool.Function(map[string]interface{}{
+		"channel": "email",
+		"subject": "Disk full\r\nBcc: attacker@example.com",
+		"body":    "/var is at 98%",
+	})
+	if err != nil || got != "Notification emailed to ops@example.com." {
+		t.Fatalf("notify = %q, %v", got, err)
+	}
+	msg := <-messages
+	if !strings.Contains(msg, "Subject: Disk full  Bcc: attacker@example.com\r\n") || strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("subject was not kept on one line:\n%s", msg)
+	}
+	if !strings.Contains(msg, "To: ops@example.com\r\n") || !strings.Contains(msg, "\r\n\r\n/var is at 98%") {
+		t.Errorf("unexpected message:\n%s", msg)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Caps that keep numbertheory from hanging on huge inputs.
+const (
+	maxPrimeIndex   = 100000 // the 100000th prime is 1299709
+	maxFactorialArg = 5000
+	maxFactorArg    = 1e15 // trial division up to ~3.2e7
+)
+
+// NewNumberTheoryTool returns a tool for exact integer arithmetic that models
+// get wrong. It supports these operations:
+//
+//   - prime: the nth prime, counting 2 as the first
+//   - factorial: n!, computed exactly with math/big
+//   - gcd, lcm: of a and b
+//   - factors: the prime factorization of n, e.g. "2^2 × 3 × 5"
+func NewNumberTheoryTool() Tool {
+	return Tool{
+		Name:        "numbertheory",
+		Description: "A tool that computes exact number theory results: the nth prime, factorials, GCD, LCM and prime factorizations.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'prime', 'factorial', 'gcd', 'lcm', 'factors')",
+			"n":         "integer (for 'prime', 'factorial' and 'factors')",
+			"a":         "integer (first operand for 'gcd' and 'lcm')",
+			"b":         "integer (second operand for 'gcd' and 'lcm')",
+		},
+		OptionalArgs: []string{"n", "a", "b"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+
+			switch op {
+			case "prime":
+				n, err := integerArg(args, "n")
+				if err != nil {
+					return "", err
+				}
+				if n < 1 || n > maxPrimeIndex {
+					return "", fmt.Errorf("'n' must be between 1 and %d for 'prime'", maxPrimeIndex)
+				}
+				return fmt.Sprint(nthPrime(int(n))), nil
+			case "factorial":
+				n, err := integerArg(args, "n")
+				if err != nil {
+					return "", err
+				}
+				if n < 0 {
+					return "", fmt.Errorf("factorial is undefined for negative numbers")
+				}
+				if n > maxFactorialArg {
+					return "", fmt.Errorf("'n' must be at most %d for 'factorial'", maxFactorialArg)
+				}
+				return new(big.Int).MulRange(1, n).String(), nil
+			case "gcd", "lcm":
+				a, err := integerArg(args, "a")
+				if err != nil {
+					return "", err
+				}
+				b, err := integerArg(args, "b")
+				if err != nil {
+					return "", err
+				}
+				x, y := big.NewInt(a), big.NewInt(b)
+				x.Abs(x)
+				y.Abs(y)
+				gcd := new(big.Int).GCD(nil, nil, x, y)
+				if op == "gcd" {
+					return gcd.String(), nil
+				}
+				if gcd.Sign() == 0 {
+					return "0", nil
+				}
+				return new(big.Int).Mul(new(big.Int).Quo(x, gcd), y).String(), nil
+			case "factors":
+				n, err := integerArg(args, "n")
+				if err != nil {
+					return "", err
+				}
+				if n < 2 {
+					return "", fmt.Errorf("'n' must be at least 2 for 'factors'")
+				}
+				if n > maxFactorArg {
+					return "", fmt.Errorf("'n' must be at most %g for 'factors'", float64(maxFactorArg))
+				}
+				return primeFactorization(n), nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// integerArg reads a whole-number argument. JSON numbers arrive as float64,
+// so fractional or out-of-range values are rejected rather than truncated.
+func integerArg(args map[string]interface{}, key string) (int64, error) {
+	v, ok := args[key].(float64)
+	if !ok {
+		return 0, fmt.Errorf("missing or invalid '%s' argument", key)
+	}
+	if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+		return 0, fmt.Errorf("'%s' must be an integer", key)
+	}
+	return int64(v), nil
+}
+
+// nthPrime returns the nth prime using a sieve sized by the prime number
+// theorem's upper bound n(ln n + ln ln n).
+func nthPrime(n int) int {
+	limit := 15
+	if n >= 6 {
+		f := float64(n)
+		limit = int(f*(math.Log(f)+math.Log(math.Log(f)))) + 1
+	}
+	composite := make([]bool, limit+1)
+	count := 0
+	for i := 2; i <= limit; i++ {
+		if composite[i] {
+			continue
+		}
+		count++
+		if count == n {
+			return i
+		}
+		for j := i * i; j <= limit; j += i {
+			composite[j] = true
+		}
+	}
+	return -1 // unreachable: the bound always holds enough primes
+}
+
+// primeFactorization renders the prime factors of n by trial division.
+func primeFactorization(n int64) string {
+	var parts []string
+	add := func(p int64, exp int) {
+		if exp == 1 {
+			parts = append(parts, fmt.Sprint(p))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d^%d", p, exp))
+		}
+	}
+	for p := int64(2); p*p <= n; p++ {
+		exp := 0
+		for n%p == 0 {
+			n /= p
+			exp++
+		}
+		if exp > 0 {
+			add(p, exp)
+		}
+	}
+	if n > 1 {
+		add(n, 1)
+	}
+	return strings.Join(parts, " × ")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumberTheoryTool(t *testing.T) {
+	tool := NewNumberTheoryTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "prime", "n": 1.0}, "2"},
+		{map[string]interface{}{"operation": "prime", "n": 6.0}, "13"},
+		{map[string]interface{}{"operation": "prime", "n": 1000.0}, "7919"},
+		{map[string]interface{}{"operation": "prime", "n": 100000.0}, "1299709"},
+		{map[string]interface{}{"operation": "factorial", "n": 0.0}, "1"},
+		{map[string]interface{}{"operation": "factorial", "n": 25.0}, "15511210043330985984000000"},
+		{map[string]interface{}{"operation": "gcd", "a": -12.0, "b": 18.0}, "6"},
+		{map[string]interface{}{"operation": "gcd", "a": 0.0, "b": 0.0}, "0"},
+		{map[string]interface{}{"operation": "lcm", "a": 4.0, "b": 6.0}, "12"},
+		{map[string]interface{}{"operation": "lcm", "a": 0.0, "b": 5.0}, "0"},
+		{map[string]interface{}{"operation": "factors", "n": 360.0}, "2^3 × 3^2 × 5"},
+		{map[string]interface{}{"operation": "factors", "n": 97.0}, "97"},
+		{map[string]interface{}{"operation": "factors", "n": 600851475143.0}, "71 × 839 × 1471 × 6857"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("numbertheory(%v) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestNumberTheoryBigFactorial(t *testing.T) {
+	got, err := NewNumberTheoryTool().Function(map[string]interface{}{"operation": "factorial", "n": 100.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100! has 158 digits and ends in 24 zeros.
+	if len(got) != 158 || !strings.HasPrefix(got, "93326215443944152681") || !strings.HasSuffix(got, "864"+strings.Repeat("0", 24)) {
+		t.Errorf("100! = %s", got)
+	}
+}
+
+func TestNumberTheoryToolErrors(t *testing.T) {
+	tool := NewNumberTheoryTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "prime", "n": 0.0}, "between 1 and 100000"},
+		{map[string]interface{}{"operation": "prime", "n": 100001.0}, "between 1 and 100000"},
+		{map[string]interface{}{"operation": "factorial", "n": -1.0}, "undefined for negative numbers"},
+		{map[string]interface{}{"operation": "factorial", "n": 5001.0}, "at most 5000"},
+		{map[string]interface{}{"operation": "factorial", "n": 2.5}, "'n' must be an integer"},
+		{map[string]interface{}{"operation": "factors", "n": 1.0}, "at least 2"},
+		{map[string]interface{}{"operation": "factors", "n": 2e15}, "at most 1e+15"},
+		{map[string]interface{}{"operation": "gcd", "a": 4.0}, "missing or invalid 'b'"},
+		{map[string]interface{}{"operation": "fibonacci", "n": 3.0}, "unsupported operation"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("numbertheory(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// maxQRCodeInput caps the payload so the rendered code still fits a terminal.
+const maxQRCodeInput = 300
+
+// NewQRCodeTool returns a tool that renders text as a QR code made of Unicode
+// half-block characters, two module rows per line of output, so it can be
+// scanned straight from a terminal.
+func NewQRCodeTool() Tool {
+	return Tool{
+		Name:        "qrcode",
+		Description: "A tool that renders text or a URL as a scannable QR code for the terminal.",
+		Args:        map[string]string{"text": fmt.Sprintf("string (at most %d bytes)", maxQRCodeInput)},
+		Function: func(args map[string]interface{}) (string, error) {
+			text, ok := args["text"].(string)
+			if !ok || text == "" {
+				return "", fmt.Errorf("missing 'text' argument")
+			}
+			if len(text) > maxQRCodeInput {
+				return "", fmt.Errorf("text is %d bytes, the maximum is %d", len(text), maxQRCodeInput)
+			}
+
+			code, err := qrcode.New(text, qrcode.Medium)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode QR code: %v", err)
+			}
+			return renderHalfBlocks(code.Bitmap()), nil
+		},
+	}
+}
+
+// renderHalfBlocks draws a bitmap where true is a dark module, packing two rows
+// into each line of text.
+func renderHalfBlocks(bitmap [][]bool) string {
+	var sb strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			top := bitmap[y][x]
+			bottom := y+1 < len(bitmap) && bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
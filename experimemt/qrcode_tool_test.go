@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// unpackHalfBlocks reverses renderHalfBlocks, two module rows per line.
+func unpackHalfBlocks(s string) [][]bool {
+	var rows [][]bool
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		var top, bottom []bool
+		for _, r := range line {
+			top = append(top, r == '█' || r == '▀')
+			bottom = append(bottom, r == '█' || r == '▄')
+		}
+		rows = append(rows, top, bottom)
+	}
+	return rows
+}
+
+func TestRenderHalfBlocks(t *testing.T) {
+	got := renderHalfBlocks([][]bool{
+		{true, true, false, false},
+		{true, false, true, false},
+		{true, false, false, true},
+	})
+	if want := "█▀▄ \n▀  ▀\n"; got != want {
+		t.Errorf("renderHalfBlocks = %q, want %q", got, want)
+	}
+}
+
+func TestQRCodeToolFinderPatterns(t *testing.T) {
+	out, err := NewQRCodeTool().Function(map[string]interface{}{"text": "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := unpackHalfBlocks(out)
+	size := len(bitmap[0])
+	const quiet = 4 // blank border go-qrcode puts around the code
+
+	// A finder pattern is a dark 7x7 ring around a light ring around a dark
+	// 3x3 square, in three corners of the code.
+	finderAt := func(top, left int) bool {
+		for y := 0; y < 7; y++ {
+			for x := 0; x < 7; x++ {
+				ring := min(x, y, 6-x, 6-y)
+				if want := ring != 1; bitmap[top+y][left+x] != want {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	corners := map[string][2]int{
+		"top left":    {quiet, quiet},
+		"top right":   {quiet, size - quiet - 7},
+		"bottom left": {size - quiet - 7, quiet},
+	}
+	for name, at := range corners {
+		if !finderAt(at[0], at[1]) {
+			t.Errorf("no finder pattern at the %s corner:\n%s", name, out)
+		}
+	}
+	if finderAt(size-quiet-7, size-quiet-7) {
+		t.Error("unexpected finder pattern at the bottom right corner")
+	}
+}
+
+func TestQRCodeToolRejectsOversizedText(t *testing.T) {
+	_, err := NewQRCodeTool().Function(map[string]interface{}{"text": strings.Repeat("x", maxQRCodeInput+1)})
+	if err == nil || !strings.Contains(err.Error(), "maximum") {
+		t.Errorf("oversized text error = %v, want the limit reported", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// maxPDFText is how much text the command line's read_pdf tool returns.
+const maxPDFText = 8192
+
+// NewReadPDFTool returns a tool that extracts the plain text of a PDF file
+// under root, returning at most maxBytes of it. Paths are resolved with
+// sandboxPath, so the model cannot reach files outside root.
+func NewReadPDFTool(root string, maxBytes int) Tool {
+	return Tool{
+		Name:        "read_pdf",
+		Description: "A tool that extracts the text content of a PDF file. Paths are relative to the document folder.",
+		Args:        map[string]string{"path": "string (path to the PDF file)"},
+		Function: func(args map[string]interface{}) (string, error) {
+			path, _ := args["path"].(string)
+			full, err := sandboxPath(root, path)
+			if err != nil {
+				return "", err
+			}
+			text, err := extractPDFText(full, maxBytes)
+			if err != nil {
+				return "", fmt.Errorf("cannot read PDF %s: %v", path, err)
+			}
+			if strings.TrimSpace(text) == "" {
+				return "The PDF contains no extractable text (it may be scanned images).", nil
+			}
+			return text, nil
+		},
+	}
+}
+
+// extractPDFText returns up to maxBytes of the text in the PDF at path. The
+// PDF library panics on some malformed files, so panics are turned into
+// errors.
+func extractPDFText(path string, maxBytes int) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	r, err := pdf.NewReader(f, info.Size())
+	if errors.Is(err, pdf.ErrInvalidPassword) {
+		return "", fmt.Errorf("the PDF is encrypted")
+	}
+	if err != nil {
+		return "", err
+	}
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(plain, int64(maxBytes)+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxBytes {
+		return truncate(string(data), maxBytes), nil
+	}
+	return string(data), nil
+}
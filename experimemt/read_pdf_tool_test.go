@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// minimalPDF returns a one-page PDF showing lines of text in Helvetica.
+func minimalPDF(lines ...string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 72 720 Td 14 TL\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", line)
+	}
+	content.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestReadPDFTool(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0o755)
+	os.WriteFile(filepath.Join(root, "docs", "memo.pdf"), minimalPDF("Quarterly report", "Revenue grew 12 percent"), 0o644)
+
+	got, err := NewReadPDFTool(root, 1000).Function(map[string]interface{}{"path": "docs/memo.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Quarterly report") || !strings.Contains(got, "Revenue grew 12 percent") {
+		t.Errorf("read_pdf = %q, want the page text", got)
+	}
+
+	got, err = NewReadPDFTool(root, 10).Function(map[string]interface{}{"path": "docs/memo.pdf"})
+	if err != nil || !strings.Contains(got, "Quarterly") || strings.Contains(got, "Revenue") || !strings.HasSuffix(got, "(truncated)") {
+		t.Errorf("read_pdf with a 10 byte limit = %q, %v; want truncated text", got, err)
+	}
+}
+
+func TestReadPDFToolErrors(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.pdf"), minimalPDF("secret"), 0o644)
+	os.Symlink(filepath.Join(outside, "secret.pdf"), filepath.Join(root, "link.pdf"))
+	os.WriteFile(filepath.Join(root, "broken.pdf"), []byte("%PDF-1.4\nnot really a pdf"), 0o644)
+	tool := NewReadPDFTool(root, 1000)
+
+	tests := []struct {
+		path, want string
+	}{
+		{"", "missing 'path' argument"},
+		{"link.pdf", "outside the allowed directory"},
+		{"missing.pdf", "cannot read PDF missing.pdf"},
+		{"broken.pdf", "cannot read PDF broken.pdf"},
+	}
+	for _, tt := range tests {
+		if got, err := tool.Function(map[string]interface{}{"path": tt.path}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("read_pdf(%q) = %q, %v; want error %q", tt.path, got, err, tt.want)
+		}
+	}
+
+	// ../ is resolved against the root rather than escaping it.
+	if _, err := tool.Function(map[string]interface{}{"path": "../" + filepath.Base(outside) + "/secret.pdf"}); err == nil {
+		t.Error("read_pdf escaped the root with ../")
+	}
+}
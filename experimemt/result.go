@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// citationPattern matches observation citations such as [obs:2] in a final
+// answer. Observations are numbered from 1 in the order the run produced them.
+var citationPattern = regexp.MustCompile(`\s*\[obs:\s*(\d+)\]`)
+
+// Step records one iteration of the agent loop. When a run ends early, the
+// last step carries the reason in Error; it may hold the partial response and
+// action of the interrupted iteration or nothing else at all.
+type Step struct {
+	Response    string          // raw model output for this step
+	Action      *ToolInvocation // tool the model asked for, if any
+	Observation string          // tool result or failure message fed back to the model
+	Succeeded   bool            // Action ran and returned a result
+	Error       error           // why the run stopped at this step, if it did
+}
+
+// FinalAnswer is the agent's answer together with the observations it cites.
+// Each citation is an index into RunResult.Observations.
+type FinalAnswer struct {
+	Text      string
+	Citations []int
+}
+
+// RunResult is the outcome of RunWithTrace.
+type RunResult struct {
+	Answer FinalAnswer
+	Trace  []Step
+
+	// LowConfidence is set when Agent.ConfidenceFunc flags the answer, e.g.
+	// because a factual question was answered without consulting any tool.
+	LowConfidence bool
+}
+
+// Observations returns the observations recorded in the trace, in order.
+func (r *RunResult) Observations() []string {
+	var obs []string
+	for _, step := range r.Trace {
+		if step.Observation != "" {
+			obs = append(obs, step.Observation)
+		}
+	}
+	return obs
+}
+
+// parseFinalAnswer strips [obs:N] citations from text and returns them as
+// zero-based indexes into the run's observations. Citations outside the range
+// of available observations are dropped, as are repeats.
+func parseFinalAnswer(text string, observations int) FinalAnswer {
+	answer := FinalAnswer{Citations: []int{}}
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > observations || seen[n-1] {
+			continue
+		}
+		seen[n-1] = true
+		answer.Citations = append(answer.Citations, n-1)
+	}
+	answer.Text = strings.TrimSpace(citationPattern.ReplaceAllString(text, ""))
+	return answer
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseFinalAnswer(t *testing.T) {
+	tests := []struct {
+		text         string
+		observations int
+		want         FinalAnswer
+	}{
+		{"Paris is sunny [obs:1].", 1, FinalAnswer{Text: "Paris is sunny.", Citations: []int{0}}},
+		{"It is 21C [obs:2] and dry [obs: 1][obs:2].", 2, FinalAnswer{Text: "It is 21C and dry.", Citations: []int{1, 0}}},
+		{"Made up [obs:3] and [obs:0].", 2, FinalAnswer{Text: "Made up and.", Citations: []int{}}},
+		{"No citations.", 0, FinalAnswer{Text: "No citations.", Citations: []int{}}},
+	}
+	for _, tt := range tests {
+		if got := parseFinalAnswer(tt.text, tt.observations); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFinalAnswer(%q, %d) = %+v, want %+v", tt.text, tt.observations, got, tt.want)
+		}
+	}
+}
+
+func TestRunReturnsCitations(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "weather", "arguments": {}}`,
+		`Action: {"name": "fx", "arguments": {}}`,
+		"Final Answer: It is sunny [obs:1] and 10 USD is 9 EUR [obs:2] [obs:7].",
+	})
+	agent.AddTool(ScriptedTool("weather", "sunny, 21C"))
+	agent.AddTool(ScriptedTool("fx", "10 USD = 9 EUR"))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FinalAnswer{Text: "It is sunny and 10 USD is 9 EUR.", Citations: []int{0, 1}}
+	if !reflect.DeepEqual(result.Answer, want) {
+		t.Errorf("answer = %+v, want %+v", result.Answer, want)
+	}
+	if obs := result.Observations(); len(obs) != 2 || obs[result.Answer.Citations[1]] != "10 USD = 9 EUR" {
+		t.Errorf("observations = %q, want the cited ones in run order", obs)
+	}
+}
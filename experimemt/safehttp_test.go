@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSafeHTTPClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer srv.Close()
+
+	_, err := SafeHTTPClient(nil).Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Fatalf("Get error = %v, want the loopback address refused", err)
+	}
+}
+
+func TestSafeHTTPClientAllowedHosts(t *testing.T) {
+	_, err := SafeHTTPClient([]string{"example.com"}).Get("http://evil.test/")
+	if err == nil || !strings.Contains(err.Error(), "not in the allowed list") {
+		t.Fatalf("Get error = %v, want the host refused", err)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"api.example.com"}
+	for host, want := range map[string]bool{
+		"api.example.com":      true,
+		"API.example.com.":     true,
+		"eu.api.example.com":   true,
+		"example.com":          false,
+		"badapi.example.com":   false,
+		"api.example.com.evil": false,
+	} {
+		if got := hostAllowed(host, allowed); got != want {
+			t.Errorf("hostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if !hostAllowed("anything.test", nil) {
+		t.Error("an empty list should allow every host")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":      true,
+		"2606:4700::1": true,
+		"127.0.0.1":    false,
+		"10.1.2.3":     false,
+		"192.168.0.1":  false,
+		"169.254.1.1":  false,
+		"100.64.0.1":   false,
+		"::1":          false,
+		"fe80::1":      false,
+		"0.0.0.0":      false,
+	} {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestNetworkToolsUseSafeClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%s reached the loopback server", r.URL)
+	}))
+	defer srv.Close()
+
+	tools := []struct {
+		tool Tool
+		args map[string]interface{}
+	}{
+		{NewTranslateTool(srv.URL), map[string]interface{}{"text": "hi", "to": "fr"}},
+		{NewNotifyTool(NotifyConfig{WebhookURL: srv.URL}), map[string]interface{}{"channel": "webhook", "subject": "s", "body": "b"}},
+		{NewFXTool(srv.URL), map[string]interface{}{"amount": 1.0, "from": "USD", "to": "EUR"}},
+		{NewIPInfoTool(srv.URL), map[string]interface{}{"ip": "8.8.8.8"}},
+		{NewGeocodeTool(srv.URL), map[string]interface{}{"address": "London"}},
+	}
+	for _, tt := range tools {
+		if _, err := tt.tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Errorf("%s error = %v, want the loopback address refused", tt.tool.Name, err)
+		}
+	}
+}
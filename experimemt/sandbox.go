@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// sandboxPath resolves path, taken relative to root, and makes sure the result
+// stays inside root even after following symlinks. File tools use it so that
+// the model cannot read or write outside the directory it was given.
+func sandboxPath(root, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("missing 'path' argument")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid sandbox root: %v", err)
+	}
+	if realRoot, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = realRoot
+	}
+
+	full := filepath.Join(absRoot, filepath.Clean("/"+path))
+	if real, err := filepath.EvalSymlinks(full); err == nil {
+		full = real
+	}
+	rel, err := filepath.Rel(absRoot, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the allowed directory", path)
+	}
+	return full, nil
+}
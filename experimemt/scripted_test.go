@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ScriptedClient is an LLMClient that replays a fixed sequence of responses,
+// one per call, and records the prompts it was given. It fails once the script
+// runs out, so a loop that makes more calls than expected is caught.
+type ScriptedClient struct {
+	mu        sync.Mutex
+	responses []string
+	prompts   []string
+}
+
+// NewScriptedClient returns a ScriptedClient that replays responses in order.
+func NewScriptedClient(responses ...string) *ScriptedClient {
+	return &ScriptedClient{responses: responses}
+}
+
+// Generate implements LLMClient.
+func (c *ScriptedClient) Generate(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, prompt)
+	if len(c.prompts) > len(c.responses) {
+		return "", fmt.Errorf("scripted client: no response scripted for call %d", len(c.prompts))
+	}
+	return c.responses[len(c.prompts)-1], nil
+}
+
+// Prompts returns the prompts received so far, in order.
+func (c *ScriptedClient) Prompts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.prompts...)
+}
+
+// ScriptedAgent returns an agent whose model replays responses, for exercising
+// Run deterministically without an Ollama server. Use ScriptedTool to give it
+// tools with canned results; the client is available as agent.Client.
+func ScriptedAgent(responses []string) *Agent {
+	agent := NewAgent("http://scripted.invalid", "scripted")
+	agent.Client = NewScriptedClient(responses...)
+	return agent
+}
+
+// ScriptedTool returns a tool named name that returns results in order, one
+// per call, and fails once they run out. A result of the form "error: ..." is
+// returned as an error instead.
+func ScriptedTool(name string, results ...string) Tool {
+	var mu sync.Mutex
+	calls := 0
+	return Tool{
+		Name:        name,
+		Description: "A scripted tool for testing.",
+		Args:        map[string]string{},
+		Function: func(args map[string]interface{}) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls > len(results) {
+				return "", fmt.Errorf("scripted tool %s: no result scripted for call %d", name, calls)
+			}
+			result := results[calls-1]
+			if msg, ok := strings.CutPrefix(result, "error: "); ok {
+				return "", fmt.Errorf("%s", msg)
+			}
+			return result, nil
+		},
+	}
+}
+
+// historyPath returns a fresh history file path for a test run.
+func historyPath(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "history.txt")
+}
+
+func TestScriptedAgentDrivesRunLoop(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Thought: look it up
+Action: {"name": "lookup", "arguments": {}}`,
+		"Final Answer: it is 42",
+	})
+	agent.AddTool(ScriptedTool("lookup", "the answer is 42"))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "what is it?", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if result.Answer.Text != "it is 42" {
+		t.Errorf("answer = %q, want %q", result.Answer.Text, "it is 42")
+	}
+	if len(result.Trace) != 2 || result.Trace[0].Observation != "the answer is 42" {
+		t.Fatalf("trace = %+v, want the tool result observed on the first step", result.Trace)
+	}
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "the answer is 42") {
+		t.Errorf("second prompt does not carry the observation: %q", prompts)
+	}
+}
+
+func TestScriptedToolErrorsAreObserved(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`{"name": "lookup", "arguments": {}}`,
+		"Final Answer: gave up",
+	})
+	agent.AddTool(ScriptedTool("lookup", "error: service down"))
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+	if !strings.Contains(result.Trace[0].Observation, "service down") {
+		t.Errorf("observation = %q, want the scripted error", result.Trace[0].Observation)
+	}
+}
+
+func TestScriptedClientRunsOut(t *testing.T) {
+	agent := ScriptedAgent([]string{"no marker and no call"})
+	agent.SetMaxSteps(2)
+	if _, err := agent.Run(context.Background(), historyPath(t), "q", nil); err == nil || !strings.Contains(err.Error(), "no response scripted") {
+		t.Fatalf("Run error = %v, want the script to run out", err)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewSetModeTool returns a tool through which the model can change its own
+// persona. The new persona only takes effect when Agent.AllowSystemUpdates is
+// set; otherwise the update is ignored.
+func NewSetModeTool() Tool {
+	return Tool{
+		Name:        "set_mode",
+		Description: "A tool that changes the assistant's persona for the rest of the conversation, e.g. 'You are a terse Linux expert.'",
+		Args:        map[string]string{"persona": "string (the new system prompt)"},
+		Configure: func(args map[string]interface{}) (ToolResult, error) {
+			persona, ok := args["persona"].(string)
+			if !ok || strings.TrimSpace(persona) == "" {
+				return ToolResult{}, fmt.Errorf("missing 'persona' argument")
+			}
+			persona = strings.TrimSpace(persona)
+			return ToolResult{
+				Observation:  fmt.Sprintf("Persona set to: %s", persona),
+				SystemUpdate: persona,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NewSetOpsTool returns a tool that performs set operations on two lists. Items
+// are compared as trimmed strings and the result is a JSON array with
+// duplicates removed, ordered by first appearance in a and then in b.
+func NewSetOpsTool() Tool {
+	return Tool{
+		Name:        "set_ops",
+		Description: "A tool that performs set operations on two lists of items.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'union', 'intersection', 'difference', 'symmetric_difference')",
+			"a":         "list of strings",
+			"b":         "list of strings",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			a, err := stringListArg(args, "a")
+			if err != nil {
+				return "", err
+			}
+			b, err := stringListArg(args, "b")
+			if err != nil {
+				return "", err
+			}
+
+			inA, inB := toSet(a), toSet(b)
+			var result []string
+			switch op {
+			case "union":
+				result = appendUnique(nil, a, nil)
+				result = appendUnique(result, b, nil)
+			case "intersection":
+				result = appendUnique(nil, a, func(s string) bool { return inB[s] })
+			case "difference":
+				result = appendUnique(nil, a, func(s string) bool { return !inB[s] })
+			case "symmetric_difference":
+				result = appendUnique(nil, a, func(s string) bool { return !inB[s] })
+				result = appendUnique(result, b, func(s string) bool { return !inA[s] })
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+
+			if result == nil {
+				result = []string{}
+			}
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// toSet returns a membership map for items.
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// appendUnique appends the items accepted by keep (all items when keep is nil)
+// to dst, skipping any already present.
+func appendUnique(dst, items []string, keep func(string) bool) []string {
+	seen := toSet(dst)
+	for _, item := range items {
+		if seen[item] || (keep != nil && !keep(item)) {
+			continue
+		}
+		seen[item] = true
+		dst = append(dst, item)
+	}
+	return dst
+}
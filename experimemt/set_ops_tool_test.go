@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetOpsTool(t *testing.T) {
+	tool := NewSetOpsTool()
+	a := []interface{}{"apple", " banana ", "cherry", "apple"}
+	b := []interface{}{"cherry", "date", "banana", 7.0}
+	tests := []struct {
+		op, want string
+	}{
+		{"union", `["apple","banana","cherry","date","7"]`},
+		{"intersection", `["banana","cherry"]`},
+		{"difference", `["apple"]`},
+		{"symmetric_difference", `["apple","date","7"]`},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"operation": tt.op, "a": a, "b": b})
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %s, %v; want %s", tt.op, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetOpsToolEmptyResultAndStringLists(t *testing.T) {
+	tool := NewSetOpsTool()
+	got, err := tool.Function(map[string]interface{}{"operation": "intersection", "a": "x, y", "b": []interface{}{"z"}})
+	if err != nil || got != "[]" {
+		t.Errorf("disjoint intersection = %s, %v; want []", got, err)
+	}
+	got, err = tool.Function(map[string]interface{}{"operation": "difference", "a": "x, y", "b": "y"})
+	if err != nil || got != `["x"]` {
+		t.Errorf("comma-separated lists = %s, %v; want [\"x\"]", got, err)
+	}
+}
+
+func TestSetOpsToolErrors(t *testing.T) {
+	tool := NewSetOpsTool()
+	_, err := tool.Function(map[string]interface{}{"operation": "product", "a": "x", "b": "y"})
+	if err == nil || err.Error() != "unsupported operation: product" {
+		t.Errorf("unknown operation: got error %v", err)
+	}
+	_, err = tool.Function(map[string]interface{}{"operation": "union", "a": "x", "b": 3.0})
+	if err == nil || !strings.Contains(err.Error(), "'b'") {
+		t.Errorf("invalid list: got error %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// streamGenerate sends prompt to the generate endpoint with streaming enabled
+// and calls onChunk for every decoded response object until the server marks
+// the response done. An error returned by onChunk aborts the stream and is
+// returned as is. The request is bound to ctx rather than a fixed client
+// timeout, since long generations legitimately stream for a while.
+func (a *Agent) streamGenerate(ctx context.Context, prompt string, onChunk func(OllamaResponse) error) error {
+	return a.streamRequest(ctx, OllamaRequest{Model: a.Model, Prompt: prompt, Options: a.modelOptions(nil), KeepAlive: a.keepAlive()}, onChunk)
+}
+
+// streamRequest is streamGenerate for a prepared request, e.g. one carrying
+// stop sequences. Stream is always turned on. A response cut off by
+// Agent.MaxTokens ends with ErrResponseTruncated.
+func (a *Agent) streamRequest(ctx context.Context, reqData OllamaRequest, onChunk func(OllamaResponse) error) error {
+	reqData.Stream = true
+	jsonData, err := json.Marshal(reqData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint(generatePath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &OllamaError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk OllamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to decode Ollama response: %v", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("Ollama stream failed: %s", chunk.Error)
+		}
+		if err := onChunk(chunk); err != nil {
+			return err
+		}
+		if chunk.Done {
+			recordTokenCounts(ctx, chunk.PromptEvalCount, chunk.EvalCount)
+			if chunk.DoneReason == "length" {
+				return ErrResponseTruncated
+			}
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read Ollama stream: %v", err)
+	}
+	return fmt.Errorf("Ollama stream ended before the response was done")
+}
+
+// CallOllamaStreamCh streams the response to prompt over channels. Tokens are
+// sent on the first channel; exactly one value, nil on success, is sent on the
+// second once the stream ends. Both channels are then closed. Cancelling ctx
+// aborts the request and unblocks any pending send, so the goroutine behind
+// the channels never leaks even if the consumer stops reading.
+func (a *Agent) CallOllamaStreamCh(ctx context.Context, prompt string) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(tokens)
+		errc <- a.streamGenerate(ctx, prompt, func(chunk OllamaResponse) error {
+			if chunk.Response == "" {
+				return nil
+			}
+			select {
+			case tokens <- chunk.Response:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return tokens, errc
+}
+
+// CallOllamaStream streams the response to prompt, calling onChunk with each
+// piece of text as it arrives, and returns the full response once the server
+// marks it done. An error returned by onChunk aborts the stream and is
+// returned along with the text received so far. The whole call is bounded by
+// ollamaCallTimeout. When Client is set, its response is passed to onChunk in
+// one piece, and any text it returns with an error, such as
+// ErrResponseTruncated, is returned with that error.
+func (a *Agent) CallOllamaStream(prompt string, onChunk func(string) error) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaCallTimeout)
+	defer cancel()
+	if a.Client != nil {
+		response, err := a.Client.Generate(ctx, prompt)
+		if response == "" {
+			return "", err
+		}
+		if chunkErr := onChunk(response); err == nil {
+			err = chunkErr
+		}
+		return response, err
+	}
+
+	var sb strings.Builder
+	tokens, errc := a.CallOllamaStreamCh(ctx, prompt)
+	for token := range tokens {
+		sb.WriteString(token)
+		if err := onChunk(token); err != nil {
+			cancel() // stops the stream goroutine
+			return sb.String(), err
+		}
+	}
+	return sb.String(), <-errc
+}
+
+// maxStreamResumes is how many times CallOllamaResumable picks a broken
+// stream back up before giving up.
+const maxStreamResumes = 2
+
+// CallOllamaResumable streams the response to prompt and returns it in full.
+// If the stream breaks after some text has arrived, the model is asked to
+// continue from that text and the continuation is merged in with
+// mergeContinuation, so repeated tokens at the seam are not duplicated.
+func (a *Agent) CallOllamaResumable(ctx context.Context, prompt string) (string, error) {
+	return a.resumeStream(ctx, prompt, func(ctx context.Context, prompt string) (string, error) {
+		var sb strings.Builder
+		err := a.streamGenerate(ctx, prompt, func(chunk OllamaResponse) error {
+			sb.WriteString(chunk.Response)
+			return nil
+		})
+		return sb.String(), err
+	})
+}
+
+// resumeStream runs stream, which returns the text it received along with any
+// error, and resumes it as CallOllamaResumable describes. A response cut off
+// by MaxTokens is not a broken stream and is returned as is for generate to
+// continue.
+func (a *Agent) resumeStream(ctx context.Context, prompt string, stream func(ctx context.Context, prompt string) (string, error)) (string, error) {
+	var text string
+	for attempt := 0; ; attempt++ {
+		more, err := stream(ctx, prompt+text)
+		text = mergeContinuation(text, more)
+		if err == nil || errors.Is(err, ErrResponseTruncated) {
+			return text, err
+		}
+		if ctx.Err() != nil || text == "" || attempt >= maxStreamResumes {
+			return text, err
+		}
+		a.logf("--- Stream broke after %d bytes, resuming: %v ---", len(text), err)
+	}
+}
+
+// mergeContinuation appends continuation to partial, dropping the longest
+// prefix of continuation that repeats the end of partial. Only overlaps that
+// start on a word boundary in partial count, so that a continuation which
+// merely begins with the same letter a word ended in is kept intact.
+func mergeContinuation(partial, continuation string) string {
+	for k := min(len(partial), len(continuation)); k > 0; k-- {
+		start := len(partial) - k
+		if !strings.HasPrefix(continuation, partial[start:]) {
+			continue
+		}
+		if start == 0 || isWordBoundary(partial[start-1]) || isWordBoundary(partial[start]) {
+			return partial + continuation[k:]
+		}
+	}
+	return partial + continuation
+}
+
+// isWordBoundary reports whether b separates words.
+func isWordBoundary(b byte) bool {
+	return b < 0x80 && (unicode.IsSpace(rune(b)) || unicode.IsPunct(rune(b)))
+}
+
+// actionStopSequences end generation where a model would start inventing the
+// result of its own tool call.
+func (a *Agent) actionStopSequences() []string {
+	return []string{"\n" + a.label(EntryObservation)}
+}
+
+// errActionComplete aborts a stream once it holds a complete tool call.
+var errActionComplete = errors.New("tool call complete")
+
+// generateUntilAction streams the response to prompt and cuts it short as soon
+// as it contains a complete tool call, dropping whatever the model would have
+// rambled on with afterwards. Responses that announce a final answer before
+// any tool call are streamed to the end so that classifyResponse sees them
+// whole.
+func (a *Agent) generateUntilAction(ctx context.Context, prompt string) (string, error) {
+	var sb strings.Builder
+	var response string
+	req := OllamaRequest{
+		Model:     a.Model,
+		Prompt:    prompt,
+		Options:   a.modelOptions(map[string]interface{}{"stop": a.actionStopSequences()}),
+		KeepAlive: a.keepAlive(),
+	}
+	err := a.streamRequest(ctx, req, func(chunk OllamaResponse) error {
+		sb.WriteString(chunk.Response)
+		if chunk.Response != "" {
+			a.emit(ctx, RunEvent{Kind: EventModelChunk, Step: -1, Text: chunk.Response})
+		}
+		if !strings.Contains(chunk.Response, "}") {
+			return nil
+		}
+		text := sb.String()
+		callJSON, at := findToolCallJSON(text)
+		if at < 0 {
+			return nil
+		}
+		if answerAt := indexFold(text, a.finalAnswerPrefix()); answerAt >= 0 && answerAt < at {
+			return nil
+		}
+		response = text[:at+len(callJSON)]
+		return errActionComplete
+	})
+	if errors.Is(err, errActionComplete) {
+		a.logf("--- Tool call complete, stopped generation early ---")
+		return response, nil
+	}
+	return sb.String(), err
+}
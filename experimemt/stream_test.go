@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ndjsonServer answers every request by streaming chunks as newline-delimited
+// JSON, flushing after each.
+func ndjsonServer(t *testing.T, chunks ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range chunks {
+			fmt.Fprintln(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCallOllamaStream(t *testing.T) {
+	srv := ndjsonServer(t, `{"response":"Hel"}`, `{"response":"lo"}`, `{"response":"","done":true}`)
+	agent := NewAgent(srv.URL, "m")
+
+	var chunks []string
+	got, err := agent.CallOllamaStream("p", func(s string) error {
+		chunks = append(chunks, s)
+		return nil
+	})
+	if err != nil || got != "Hello" {
+		t.Fatalf("CallOllamaStream = %q, %v; want Hello", got, err)
+	}
+	if strings.Join(chunks, "|") != "Hel|lo" {
+		t.Errorf("chunks = %q", chunks)
+	}
+
+	if got, err := agent.CallOllama("p"); err != nil || got != "Hello" {
+		t.Errorf("CallOllama = %q, %v; want Hello", got, err)
+	}
+}
+
+func TestCallOllamaStreamCallbackAborts(t *testing.T) {
+	srv := ndjsonServer(t, `{"response":"a"}`, `{"response":"b"}`, `{"response":"c","done":true}`)
+	stop := errors.New("stop")
+	got, err := NewAgent(srv.URL, "m").CallOllamaStream("p", func(s string) error {
+		if s == "b" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || got != "ab" {
+		t.Fatalf("CallOllamaStream = %q, %v; want ab and the callback error", got, err)
+	}
+}
+
+func TestCallOllamaStreamClientKeepsPartialResponse(t *testing.T) {
+	agent := NewAgent("http://scripted.invalid", "m")
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		return "partial", ErrResponseTruncated
+	})
+	var chunks []string
+	got, err := agent.CallOllamaStream("p", func(s string) error {
+		chunks = append(chunks, s)
+		return nil
+	})
+	if !errors.Is(err, ErrResponseTruncated) || got != "partial" {
+		t.Fatalf("CallOllamaStream = %q, %v; want the partial text and ErrResponseTruncated", got, err)
+	}
+	if strings.Join(chunks, "|") != "partial" {
+		t.Errorf("chunks = %q", chunks)
+	}
+}
+
+func TestCallOllamaStalledServerTimesOut(t *testing.T) {
+	defer func(d time.Duration) { ollamaCallTimeout = d }(ollamaCallTimeout)
+	ollamaCallTimeout = 100 * time.Millisecond
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response":"par"}`)
+		w.(http.Flusher).Flush()
+		select { // never finish the response
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	got, err := NewAgent(srv.URL, "m").CallOllama("p")
+	if err == nil {
+		t.Fatal("CallOllama succeeded against a stalled server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("CallOllama took %s, want it to give up after the timeout", elapsed)
+	}
+	if got != "par" {
+		t.Errorf("partial text = %q, want par", got)
+	}
+}
+
+// dropConnection, as the last chunk of a reply, makes droppingServer cut the
+// connection instead of finishing the response.
+const dropConnection = "DROP"
+
+// droppingServer streams replies[i] to the i-th request, repeating the last
+// reply once they run out, and returns a function reporting the prompts it
+// received.
+func droppingServer(t *testing.T, replies ...[]string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		mu.Lock()
+		prompts = append(prompts, req.Prompt)
+		reply := replies[min(len(prompts), len(replies))-1]
+		mu.Unlock()
+
+		for _, chunk := range reply {
+			if chunk == dropConnection {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("hijacking connection: %v", err)
+					return
+				}
+				conn.Close()
+				return
+			}
+			fmt.Fprintln(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), prompts...)
+	}
+}
+
+func TestCallOllamaResumableResumesDroppedStream(t *testing.T) {
+	srv, prompts := droppingServer(t,
+		[]string{`{"response":"The quick "}`, `{"response":"brown"}`, dropConnection},
+		[]string{`{"response":"brown fox"}`, `{"response":"","done":true}`},
+	)
+	got, err := NewAgent(srv.URL, "m").CallOllamaResumable(context.Background(), "p: ")
+	if err != nil || got != "The quick brown fox" {
+		t.Fatalf("CallOllamaResumable = %q, %v; want the merged response", got, err)
+	}
+	if p := prompts(); len(p) != 2 || p[1] != "p: The quick brown" {
+		t.Errorf("prompts = %q, want the second to continue the partial text", p)
+	}
+}
+
+func TestCallOllamaResumableGivesUp(t *testing.T) {
+	srv, prompts := droppingServer(t, []string{`{"response":"more "}`, dropConnection})
+	got, err := NewAgent(srv.URL, "m").CallOllamaResumable(context.Background(), "p")
+	if err == nil {
+		t.Fatal("CallOllamaResumable succeeded although every stream broke")
+	}
+	if n := len(prompts()); n != maxStreamResumes+1 {
+		t.Errorf("made %d requests, want %d", n, maxStreamResumes+1)
+	}
+	if got != "more " {
+		t.Errorf("partial text = %q, want the repeated text merged away", got)
+	}
+
+	srv, prompts = droppingServer(t, []string{dropConnection})
+	if _, err := NewAgent(srv.URL, "m").CallOllamaResumable(context.Background(), "p"); err == nil {
+		t.Fatal("CallOllamaResumable succeeded without any response")
+	}
+	if n := len(prompts()); n != 1 {
+		t.Errorf("made %d requests for a stream that broke before any text, want 1", n)
+	}
+}
+
+func TestRunResumesDroppedActionStream(t *testing.T) {
+	srv, prompts := droppingServer(t,
+		[]string{`{"response":"Action: {\"name\": \"echo\", \"argu"}`, dropConnection},
+		[]string{`{"response":"ments\": {\"from\": \"x\"}}"}`, `{"response":"","done":true}`},
+		[]string{`{"response":"Final Answer: done"}`, `{"response":"","done":true}`},
+	)
+	calls := 0
+	agent := NewAgent(srv.URL, "m")
+	agent.StreamActions = true
+	agent.AddTool(echoTool(&calls))
+
+	got, err := agent.Run(context.Background(), historyPath(t), "go", nil)
+	if err != nil || got != "done" {
+		t.Fatalf("Run = %q, %v; want done", got, err)
+	}
+	if calls != 1 {
+		t.Errorf("echo called %d times, want 1", calls)
+	}
+	if p := prompts(); len(p) != 3 || !strings.HasSuffix(p[1], `"argu`) {
+		t.Errorf("prompts = %q, want the second to resume the broken tool call", p)
+	}
+}
+
+func TestCallOllamaStreamCh(t *testing.T) {
+	srv := ndjsonServer(t, `{"response":"a"}`, `{"response":""}`, `{"response":"b","done":true}`)
+	tokens, errc := NewAgent(srv.URL, "m").CallOllamaStreamCh(context.Background(), "p")
+
+	var got []string
+	for token := range tokens {
+		got = append(got, token)
+	}
+	if strings.Join(got, "|") != "a|b" {
+		t.Errorf("tokens = %q, want a|b", got)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("stream error = %v", err)
+	}
+	if _, ok := <-errc; ok {
+		t.Error("error channel not closed after the stream ended")
+	}
+}
+
+func TestCallOllamaStreamChCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for { // stream forever
+			fmt.Fprintln(w, `{"response":"x"}`)
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens, errc := NewAgent(srv.URL, "m").CallOllamaStreamCh(ctx, "p")
+	if token := <-tokens; token != "x" {
+		t.Fatalf("first token = %q, want x", token)
+	}
+	// Stop reading tokens altogether; the goroutine must still finish.
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) && !strings.Contains(fmt.Sprint(err), "context canceled") {
+			t.Errorf("stream error = %v, want cancellation", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream goroutine did not finish after cancellation")
+	}
+	// errc is closed last, so this proves the goroutine has returned.
+	if _, ok := <-errc; ok {
+		t.Error("error channel not closed after cancellation")
+	}
+}
+
+func TestRunDispatchesStreamedActionBeforeProse(t *testing.T) {
+	var mu sync.Mutex
+	var requests []OllamaRequest
+	proseSent := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		n := len(requests)
+		mu.Unlock()
+
+		if n > 1 {
+			fmt.Fprintln(w, `{"response":"Final Answer: done","done":true}`)
+			return
+		}
+		fmt.Fprintln(w, `{"response":"Thought: echo it\nAction: {\"name\": \"echo\", "}`)
+		fmt.Fprintln(w, `{"response":"\"arguments\": {\"from\": \"x\"}}"}`)
+		w.(http.Flusher).Flush()
+		// A rambling model would go on; the agent should hang up instead.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			close(proseSent)
+			fmt.Fprintln(w, `{"response":"\nI think the result will be x, because...","done":true}`)
+		}
+	}))
+	defer srv.Close()
+
+	agent := NewAgent(srv.URL, "m")
+	agent.StreamActions = true
+	dispatched := make(chan bool, 1)
+	agent.AddTool(Tool{Name: "echo", Args: map[string]string{"from": "string"}, Function: func(args map[string]interface{}) (string, error) {
+		select {
+		case <-proseSent:
+			dispatched <- false
+		default:
+			dispatched <- true
+		}
+		return "from x", nil
+	}})
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "go", nil)
+	if err != nil || result.Answer.Text != "done" {
+		t.Fatalf("RunWithTrace = %q, %v; want done", result.Answer.Text, err)
+	}
+	if !<-dispatched {
+		t.Error("the tool was dispatched only after the model's trailing prose")
+	}
+	if got, want := result.Trace[0].Response, "Thought: echo it\nAction: {\"name\": \"echo\", \"arguments\": {\"from\": \"x\"}}"; got != want {
+		t.Errorf("response = %q, want it cut after the tool call", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if stop, _ := requests[0].Options["stop"].([]interface{}); len(stop) != 1 || stop[0] != "\nObservation:" {
+		t.Errorf("stop sequences = %v, want the observation label", requests[0].Options["stop"])
+	}
+}
+
+func TestRunCancelledMidStreamKeepsPartialResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response":"Thought: the forecast for "}`)
+		fmt.Fprintln(w, `{"response":"Paris says rain"}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // still generating when the user gives up
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agent := NewAgent(srv.URL, "m")
+	agent.StreamActions = true
+	agent.Observer = func(ev RunEvent) {
+		if ev.Kind == EventModelChunk && strings.Contains(ev.Text, "Paris") {
+			cancel()
+		}
+	}
+	path := historyPath(t)
+
+	got, err := agent.Run(ctx, path, "will it rain in Paris?", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run error = %v, want context.Canceled", err)
+	}
+	const partial = "Thought: the forecast for Paris says rain"
+	if got != partial {
+		t.Errorf("answer = %q, want the partial response", got)
+	}
+	history, err := agent.GetConversationHistory(path)
+	if err != nil || !strings.Contains(history, partialResponseMarker+" "+partial) {
+		t.Fatalf("history does not keep the partial response (%v):\n%s", err, history)
+	}
+
+	// The next run picks up the interrupted response from the history.
+	agent.Observer = nil
+	agent.StreamActions = false
+	var prompt string
+	agent.Client = LLMClientFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return "Final Answer: yes, rain", nil
+	})
+	if _, err := agent.Run(context.Background(), path, "so, umbrella?", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, partialResponseMarker+" "+partial) {
+		t.Errorf("next prompt lacks the interrupted response:\n%s", prompt)
+	}
+}
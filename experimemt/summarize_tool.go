@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits for the summarize tool.
+const (
+	maxSummarizeInput    = 32000 // characters; more would overflow small context windows
+	defaultSummaryWords  = 150
+	maxSummaryWords      = 1000
+	summarizeToolTimeout = 2 * time.Minute
+)
+
+// NewSummarizeTool returns a tool that condenses long text, such as a bulky
+// observation, by asking client for a summary. The model is prompted directly,
+// not through the agent loop, so it cannot call tools; a summary requested
+// while another is still being written is refused, which stops a client that
+// does route back through an agent from recursing.
+func NewSummarizeTool(client LLMClient) Tool {
+	var busy atomic.Bool
+	return Tool{
+		Name:        "summarize",
+		Description: "A tool that summarizes long text, e.g. a lengthy observation, so that it takes up less space.",
+		Args: map[string]string{
+			"text":   "string",
+			"length": "integer (optional, the most words the summary may use; defaults to 150)",
+		},
+		OptionalArgs: []string{"length"},
+		Timeout:      summarizeToolTimeout,
+		Function: func(args map[string]interface{}) (string, error) {
+			text, ok := args["text"].(string)
+			if !ok || strings.TrimSpace(text) == "" {
+				return "", fmt.Errorf("missing 'text' argument")
+			}
+			if n := utf8.RuneCountInString(text); n > maxSummarizeInput {
+				return "", fmt.Errorf("text is too long to summarize (%d characters, limit %d); summarize it in parts", n, maxSummarizeInput)
+			}
+			words := int64(defaultSummaryWords)
+			if _, ok := args["length"]; ok {
+				var err error
+				if words, err = integerArg(args, "length"); err != nil {
+					return "", err
+				}
+				if words < 1 || words > maxSummaryWords {
+					return "", fmt.Errorf("'length' must be between 1 and %d words", maxSummaryWords)
+				}
+			}
+
+			if !busy.CompareAndSwap(false, true) {
+				return "", fmt.Errorf("a summary is already being written; summarize cannot be nested")
+			}
+			defer busy.Store(false)
+
+			ctx, cancel := context.WithTimeout(context.Background(), summarizeToolTimeout)
+			defer cancel()
+			summary, err := client.Generate(ctx, summarizePrompt(text, int(words)))
+			if err != nil {
+				return "", fmt.Errorf("summarization failed: %v", err)
+			}
+			summary = strings.TrimSpace(summary)
+			if summary == "" {
+				return "", fmt.Errorf("summarization failed: the model returned an empty summary")
+			}
+			return summary, nil
+		},
+	}
+}
+
+// summarizePrompt asks for a summary of text in at most words words.
+func summarizePrompt(text string, words int) string {
+	return fmt.Sprintf(`Summarize the text below in at most %d words. Keep names, numbers and dates exactly as written. Reply with the summary only, without any preamble.
+
+Text:
+%s`, words, text)
+}
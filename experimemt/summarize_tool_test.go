@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeTool(t *testing.T) {
+	var prompts []string
+	tool := NewSummarizeTool(LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "  Revenue rose 12% in Q3.\n", nil
+	}))
+
+	got, err := tool.Function(map[string]interface{}{"text": "The quarterly report says revenue rose 12% in Q3...", "length": 20.0})
+	if err != nil || got != "Revenue rose 12% in Q3." {
+		t.Fatalf("summarize = %q, %v; want the trimmed summary", got, err)
+	}
+	if len(prompts) != 1 || !strings.HasPrefix(prompts[0], "Summarize the text below in at most 20 words.") ||
+		!strings.HasSuffix(prompts[0], "Text:\nThe quarterly report says revenue rose 12% in Q3...") {
+		t.Errorf("prompt = %q", prompts)
+	}
+
+	if _, err := tool.Function(map[string]interface{}{"text": "short"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompts[1], "at most 150 words") {
+		t.Errorf("prompt without length = %q, want the default", prompts[1])
+	}
+}
+
+func TestSummarizeToolErrors(t *testing.T) {
+	calls := 0
+	tool := NewSummarizeTool(LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		if strings.Contains(prompt, "fail") {
+			return "", errors.New("connection refused")
+		}
+		return " ", nil
+	}))
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"text": "  "}, "missing 'text' argument"},
+		{map[string]interface{}{"text": strings.Repeat("x", maxSummarizeInput+1)}, "text is too long to summarize"},
+		{map[string]interface{}{"text": "t", "length": 0.0}, "'length' must be between 1 and 1000 words"},
+		{map[string]interface{}{"text": "fail"}, "summarization failed: connection refused"},
+		{map[string]interface{}{"text": "t"}, "the model returned an empty summary"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error = %v, want %q", err, tt.want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("client called %d times, want only for valid input", calls)
+	}
+}
+
+func TestSummarizeToolRefusesNesting(t *testing.T) {
+	var tool Tool
+	var nested error
+	tool = NewSummarizeTool(LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		// A client routing back through an agent might call the tool again.
+		_, nested = tool.Function(map[string]interface{}{"text": "inner"})
+		return "outer summary", nil
+	}))
+	if got, err := tool.Function(map[string]interface{}{"text": "outer"}); err != nil || got != "outer summary" {
+		t.Fatalf("summarize = %q, %v", got, err)
+	}
+	if nested == nil || !strings.Contains(nested.Error(), "cannot be nested") {
+		t.Errorf("nested call error = %v, want it refused", nested)
+	}
+}
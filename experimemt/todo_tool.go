@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// todoItem is one entry of the todo tool's list.
+type todoItem struct {
+	id   int
+	text string
+	done bool
+}
+
+// NewTodoTool returns a tool with which the agent keeps a checklist of
+// subtasks for the session. Every operation returns the updated list, and the
+// list is shown to the model as tool state when Agent.ShowToolState is set.
+func NewTodoTool() Tool {
+	var mu sync.Mutex
+	var items []todoItem
+	nextID := 1
+
+	render := func() string {
+		if len(items) == 0 {
+			return "The TODO list is empty."
+		}
+		var sb strings.Builder
+		sb.WriteString("TODO list:")
+		for _, item := range items {
+			mark := " "
+			if item.done {
+				mark = "x"
+			}
+			fmt.Fprintf(&sb, "\n%d. [%s] %s", item.id, mark, item.text)
+		}
+		return sb.String()
+	}
+	find := func(args map[string]interface{}) (int, error) {
+		id, err := integerArg(args, "id")
+		if err != nil {
+			return -1, err
+		}
+		for i, item := range items {
+			if int64(item.id) == id {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("no TODO item with id %d", id)
+	}
+
+	return Tool{
+		Name:        "todo",
+		Description: "A tool that keeps a TODO list of subtasks for the current task, so you can plan and track what is left to do.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'add', 'complete', 'list', 'remove')",
+			"text":      "string (the subtask, only for 'add')",
+			"id":        "number (item number, for 'complete' and 'remove')",
+		},
+		OptionalArgs: []string{"text", "id"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch op {
+			case "add":
+				text, ok := args["text"].(string)
+				if !ok || strings.TrimSpace(text) == "" {
+					return "", fmt.Errorf("missing 'text' argument")
+				}
+				items = append(items, todoItem{id: nextID, text: strings.TrimSpace(text)})
+				nextID++
+			case "complete":
+				i, err := find(args)
+				if err != nil {
+					return "", err
+				}
+				items[i].done = true
+			case "remove":
+				i, err := find(args)
+				if err != nil {
+					return "", err
+				}
+				items = append(items[:i], items[i+1:]...)
+			case "list":
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+			return render(), nil
+		},
+		State: func() string {
+			mu.Lock()
+			defer mu.Unlock()
+			return render()
+		},
+	}
+}
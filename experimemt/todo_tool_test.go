@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTodoTool(t *testing.T) {
+	tool := NewTodoTool()
+	steps := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "list"}, "The TODO list is empty."},
+		{map[string]interface{}{"operation": "add", "text": " book flight "}, "TODO list:\n1. [ ] book flight"},
+		{map[string]interface{}{"operation": "add", "text": "book hotel"}, "TODO list:\n1. [ ] book flight\n2. [ ] book hotel"},
+		{map[string]interface{}{"operation": "complete", "id": 1.0}, "TODO list:\n1. [x] book flight\n2. [ ] book hotel"},
+		{map[string]interface{}{"operation": "add", "text": "rent car"}, "TODO list:\n1. [x] book flight\n2. [ ] book hotel\n3. [ ] rent car"},
+		{map[string]interface{}{"operation": "remove", "id": 2.0}, "TODO list:\n1. [x] book flight\n3. [ ] rent car"},
+		{map[string]interface{}{"operation": "list"}, "TODO list:\n1. [x] book flight\n3. [ ] rent car"},
+	}
+	for _, step := range steps {
+		got, err := tool.Function(step.args)
+		if err != nil || got != step.want {
+			t.Fatalf("todo(%v) = %q, %v; want %q", step.args, got, err, step.want)
+		}
+	}
+	if got := tool.State(); got != steps[len(steps)-1].want {
+		t.Errorf("State = %q, want the current list", got)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"operation": "complete", "id": 2.0},
+		{"operation": "remove"},
+		{"operation": "add", "text": "  "},
+		{"operation": "sort"},
+	} {
+		if got, err := tool.Function(args); err == nil {
+			t.Errorf("todo(%v) = %q, want an error", args, got)
+		}
+	}
+}
+
+func TestTodoStateInPrompt(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`{"name": "todo", "arguments": {"operation": "add", "text": "check the weather"}}`,
+		"Final Answer: planned",
+	})
+	agent.AddTool(NewTodoTool())
+	agent.ShowToolState = true
+	if _, err := agent.Run(context.Background(), historyPath(t), "plan my trip", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	if !strings.Contains(prompts[0], "CURRENT TOOL STATE:\ntodo:\nThe TODO list is empty.") {
+		t.Errorf("first prompt does not show the empty list:\n%s", prompts[0])
+	}
+	if !strings.Contains(prompts[1], "todo:\nTODO list:\n1. [ ] check the weather") {
+		t.Errorf("second prompt does not show the added item:\n%s", prompts[1])
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// cachedResult is a tool result kept for Tool.CacheTTL.
+type cachedResult struct {
+	result  ToolResult
+	expires time.Time
+}
+
+// toolCacheKey identifies a call by tool name and arguments. encoding/json
+// sorts map keys, so equal arguments always give the same key.
+func toolCacheKey(name string, args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Sprintf("%s:%v", name, args)
+	}
+	return name + ":" + string(data)
+}
+
+func (a *Agent) cacheNow() time.Time {
+	if a.CacheClock != nil {
+		return a.CacheClock.Now()
+	}
+	return SystemClock.Now()
+}
+
+// cachedResult returns the cached result for key if it has not expired.
+func (a *Agent) cachedResult(key string) (ToolResult, bool) {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	c, ok := a.cache[key]
+	if !ok || !a.cacheNow().Before(c.expires) {
+		return ToolResult{}, false
+	}
+	return c.result, true
+}
+
+// cacheResult stores result under key for ttl.
+func (a *Agent) cacheResult(key string, result ToolResult, ttl time.Duration) {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	if a.cache == nil {
+		a.cache = make(map[string]cachedResult)
+	}
+	a.cache[key] = cachedResult{result: result, expires: a.cacheNow().Add(ttl)}
+}
+
+// PurgeExpiredCache drops expired tool results from the cache and reports how
+// many were removed. Expired entries are never served, so this only frees
+// memory in long-lived agents.
+func (a *Agent) PurgeExpiredCache() int {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	now := a.cacheNow()
+	purged := 0
+	for key, c := range a.cache {
+		if !now.Before(c.expires) {
+			delete(a.cache, key)
+			purged++
+		}
+	}
+	return purged
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestToolCacheTTL(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	weather := Tool{Name: "weather", Args: map[string]string{"city": "string"}, CacheTTL: 10 * time.Minute,
+		Function: func(args map[string]interface{}) (string, error) {
+			calls++
+			return fmt.Sprintf("%v: %d°C", args["city"], 10+calls), nil
+		}}
+	agent := NewAgent("http://scripted.invalid", "m")
+	step := 0
+	agent.Client = LLMClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		step++
+		if step%2 == 1 {
+			return `{"name": "weather", "arguments": {"city": "Oslo"}}`, nil
+		}
+		return "Final Answer: done", nil
+	})
+	agent.CacheClock = clock
+	agent.AddTool(weather)
+
+	run := func() string {
+		t.Helper()
+		result, err := agent.RunWithTrace(context.Background(), historyPath(t), "weather in Oslo?", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Trace[0].Observation
+	}
+
+	if obs := run(); obs != "Oslo: 11°C" || calls != 1 {
+		t.Fatalf("first run observed %q after %d calls", obs, calls)
+	}
+	clock.Advance(9 * time.Minute)
+	if obs := run(); obs != "Oslo: 11°C" || calls != 1 {
+		t.Errorf("within the TTL: observed %q after %d calls, want the cached result", obs, calls)
+	}
+	if _, err := agent.callTool(weather, map[string]interface{}{"city": "Bergen"}); err != nil || calls != 2 {
+		t.Errorf("other arguments: %v after %d calls, want the tool called", err, calls)
+	}
+	clock.Advance(time.Minute)
+	if obs := run(); obs != "Oslo: 13°C" || calls != 3 {
+		t.Errorf("after the TTL: observed %q after %d calls, want the tool called again", obs, calls)
+	}
+}
+
+func TestToolCacheSkipsErrors(t *testing.T) {
+	calls := 0
+	flaky := Tool{Name: "fx", CacheTTL: time.Hour, Function: func(map[string]interface{}) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.New("rate limited")
+		}
+		return "1.08", nil
+	}}
+	agent := NewAgent("http://unused.invalid", "m")
+	for i, want := range []string{"", "1.08", "1.08"} {
+		result, err := agent.callTool(flaky, nil)
+		if result.Observation != want || (err != nil) != (i == 0) {
+			t.Errorf("call %d = %q, %v; want %q", i, result.Observation, err, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("tool called %d times, want the error not cached", calls)
+	}
+}
+
+func TestPurgeExpiredCache(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	agent := NewAgent("http://unused.invalid", "m")
+	agent.CacheClock = clock
+	agent.cacheResult("short", ToolResult{Observation: "a"}, time.Minute)
+	agent.cacheResult("long", ToolResult{Observation: "b"}, time.Hour)
+
+	if n := agent.PurgeExpiredCache(); n != 0 {
+		t.Errorf("purged %d entries before any expired", n)
+	}
+	clock.Advance(time.Minute)
+	if n := agent.PurgeExpiredCache(); n != 1 {
+		t.Errorf("purged %d entries, want the expired one", n)
+	}
+	if _, ok := agent.cachedResult("long"); !ok || len(agent.cache) != 1 {
+		t.Errorf("cache = %v, want only the unexpired entry left", agent.cache)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// selectionStopwords are words too common in questions and tool descriptions
+// to say anything about relevance.
+var selectionStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "this": true, "with": true,
+	"from": true, "into": true, "can": true, "are": true, "what": true, "how": true,
+	"tool": true, "tools": true, "use": true, "using": true, "string": true, "number": true,
+}
+
+// selectRelevantTools returns up to n available tools ranked by how many words
+// of input appear in their name or description. A tool named in the input
+// outranks any word overlap. Ties keep name order so prompts are stable.
+func (a *Agent) selectRelevantTools(input string, n int) []Tool {
+	words := relevanceWords(input)
+	lowerInput := strings.ToLower(input)
+
+	type scored struct {
+		tool  Tool
+		score int
+	}
+	var candidates []scored
+	for _, tool := range a.Tools {
+		if !tool.IsAvailable() {
+			continue
+		}
+		score := 0
+		for word := range relevanceWords(tool.Name + " " + tool.Description) {
+			if words[word] {
+				score++
+			}
+		}
+		if strings.Contains(lowerInput, strings.ToLower(tool.Name)) {
+			score += len(words) + 1
+		}
+		candidates = append(candidates, scored{tool, score})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].tool.Name < candidates[j].tool.Name
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	tools := make([]Tool, len(candidates))
+	for i, c := range candidates {
+		tools[i] = c.tool
+	}
+	return tools
+}
+
+// relevanceWords returns the distinct lowercase words of s that carry
+// meaning: at least three characters long and not a stopword.
+func relevanceWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, tok := range tokenize(strings.ToLower(s)) {
+		if len(tok) >= 3 && !selectionStopwords[tok] {
+			words[tok] = true
+		}
+	}
+	return words
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func toolNames(tools []Tool) []string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
+func selectionAgent() *Agent {
+	agent := ScriptedAgent([]string{"Final Answer: ok"})
+	for _, tool := range []Tool{NewColorTool(), NewCronTool(), NewGeoDistanceTool(), NewJWTTool(), NewTodoTool(), NewURLTool()} {
+		agent.AddTool(tool)
+	}
+	return agent
+}
+
+func TestSelectRelevantTools(t *testing.T) {
+	agent := selectionAgent()
+	tests := []struct {
+		input string
+		first string
+	}{
+		{"convert the hex color #ff8800 to RGB", "color"},
+		{"what is the distance between these latitude/longitude points?", "geo_distance"},
+		{"when does the cron expression 0 9 * * 1 fire next?", "cron"},
+		{"please decode this with jwt", "jwt"},
+		{"parse the query string of this URL", "url_tool"},
+	}
+	for _, tt := range tests {
+		got := toolNames(agent.selectRelevantTools(tt.input, 2))
+		if len(got) != 2 || got[0] != tt.first {
+			t.Errorf("selectRelevantTools(%q) = %v, want %s first", tt.input, got, tt.first)
+		}
+	}
+
+	// Without any overlap, ties fall back to name order.
+	if got := toolNames(agent.selectRelevantTools("hello", 3)); strings.Join(got, ",") != "color,cron,geo_distance" {
+		t.Errorf("selectRelevantTools without overlap = %v, want name order", got)
+	}
+	if got := agent.selectRelevantTools("hello", 10); len(got) != 6 {
+		t.Errorf("selectRelevantTools with a large n returned %d tools, want all 6", len(got))
+	}
+
+	color := agent.Tools["color"]
+	color.Available = func() bool { return false }
+	agent.Tools["color"] = color
+	if got := toolNames(agent.selectRelevantTools("convert the hex color to RGB", 1)); got[0] == "color" {
+		t.Error("an unavailable tool was selected")
+	}
+}
+
+func TestMaxPromptTools(t *testing.T) {
+	agent := selectionAgent()
+	agent.MaxPromptTools = 2
+	if _, err := agent.Run(context.Background(), historyPath(t), "convert the hex color #ff8800 to RGB", nil); err != nil {
+		t.Fatal(err)
+	}
+	prompt := agent.Client.(*ScriptedClient).Prompts()[0]
+	if n := strings.Count(prompt, "\nName: "); n != 2 || !strings.Contains(prompt, "Name: color\n") {
+		t.Errorf("prompt describes %d tools, want 2 including color:\n%s", n, prompt)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ToolSpec is the declaration of a tool without its implementation: what the
+// model is told about it. Specs exported from one agent can be imported into
+// another to reproduce its tools prompt, with functions bound by name.
+type ToolSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Args        map[string]string `json:"args,omitempty"`
+
+	OptionalArgs []string `json:"optional_args,omitempty"`
+}
+
+// ExportToolSpecs returns the specs of the registered tools in name order.
+func (a *Agent) ExportToolSpecs() []ToolSpec {
+	specs := make([]ToolSpec, 0, len(a.Tools))
+	for _, tool := range a.Tools {
+		specs = append(specs, ToolSpec{
+			Name:         tool.Name,
+			Description:  tool.Description,
+			Args:         tool.Args,
+			OptionalArgs: tool.OptionalArgs,
+		})
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// ImportToolSpecs registers a tool for each spec, replacing any tool of the
+// same name. The tools are described in the prompt straight away but fail
+// when called until a function is bound to them with BindFunction.
+func (a *Agent) ImportToolSpecs(specs []ToolSpec) {
+	for _, spec := range specs {
+		name := spec.Name
+		a.AddTool(Tool{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Args:         spec.Args,
+			OptionalArgs: spec.OptionalArgs,
+			Function: func(map[string]interface{}) (string, error) {
+				return "", fmt.Errorf("tool %s has no function bound", name)
+			},
+		})
+	}
+}
+
+// BindFunction sets the function behind the registered tool name, typically
+// one added by ImportToolSpecs.
+func (a *Agent) BindFunction(name string, fn func(args map[string]interface{}) (string, error)) error {
+	tool, ok := a.Tools[name]
+	if !ok {
+		return fmt.Errorf("unknown tool: %s", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("no function given for tool %s", name)
+	}
+	tool.Function = fn
+	tool.Configure = nil
+	a.Tools[name] = tool
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToolSpecsRoundTrip(t *testing.T) {
+	source := NewAgent("http://unused.invalid", "m")
+	source.AddTool(NewCaseTool())
+	source.AddTool(NewYAMLTool())
+
+	data, err := json.Marshal(source.ExportToolSpecs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var specs []ToolSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0].Name != "case" || specs[1].Name != "yaml" || !reflect.DeepEqual(specs[1].OptionalArgs, []string{"path"}) {
+		t.Fatalf("exported specs = %+v", specs)
+	}
+
+	target := NewAgent("http://unused.invalid", "m")
+	target.ImportToolSpecs(specs)
+	if got, want := target.GetToolsPrompt(), source.GetToolsPrompt(); got != want {
+		t.Errorf("imported tools prompt =\n%s\nwant\n%s", got, want)
+	}
+	if !reflect.DeepEqual(target.ExportToolSpecs(), specs) {
+		t.Errorf("re-exported specs = %+v, want %+v", target.ExportToolSpecs(), specs)
+	}
+}
+
+func TestBindFunction(t *testing.T) {
+	source := NewAgent("http://unused.invalid", "m")
+	source.AddTool(NewCaseTool())
+	agent := ScriptedAgent([]string{
+		`{"name": "case", "arguments": {"input": "hi", "style": "upper"}}`,
+		"Final Answer: could not convert",
+		`{"name": "case", "arguments": {"input": "hi", "style": "upper"}}`,
+		"Final Answer: HI",
+	})
+	agent.ImportToolSpecs(source.ExportToolSpecs())
+
+	result, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs := result.Trace[0].Observation; !strings.Contains(obs, "tool case has no function bound") {
+		t.Errorf("unbound tool observation = %q", obs)
+	}
+
+	if err := agent.BindFunction("case", NewCaseTool().Function); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = agent.RunWithTrace(context.Background(), historyPath(t), "q", nil); err != nil {
+		t.Fatal(err)
+	}
+	if obs := result.Trace[0].Observation; obs != "HI" {
+		t.Errorf("bound tool observation = %q, want HI", obs)
+	}
+
+	if err := agent.BindFunction("missing", NewCaseTool().Function); err == nil || err.Error() != "unknown tool: missing" {
+		t.Errorf("binding an unknown tool: %v", err)
+	}
+	if err := agent.BindFunction("case", nil); err == nil {
+		t.Error("binding a nil function succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpStep   = 30 * time.Second // RFC 6238 default time step
+	totpDigits = 6
+	totpSkew   = 1 // steps either side of now accepted by verify
+)
+
+// NewTOTPTool returns a tool that generates and verifies RFC 6238 TOTP codes
+// using the system clock.
+func NewTOTPTool() Tool {
+	return NewTOTPToolWithClock(SystemClock)
+}
+
+// NewTOTPToolWithClock is like NewTOTPTool but reads the current time from
+// clock. Codes are six-digit HMAC-SHA1 codes over 30 second steps; verify also
+// accepts the code from one step either side to tolerate clock drift.
+func NewTOTPToolWithClock(clock Clock) Tool {
+	return Tool{
+		Name:        "totp",
+		Description: "A tool that generates or verifies time-based one-time passwords (TOTP, RFC 6238).",
+		Args: map[string]string{
+			"operation": "string (e.g., 'generate', 'verify')",
+			"secret":    "string (base32 encoded shared secret)",
+			"code":      "string (6-digit code, only for 'verify')",
+		},
+		OptionalArgs: []string{"code"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			secret, ok := args["secret"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'secret' argument")
+			}
+			key, err := decodeTOTPSecret(secret)
+			if err != nil {
+				return "", err
+			}
+			counter := uint64(clock.Now().Unix() / int64(totpStep/time.Second))
+
+			switch op {
+			case "generate":
+				return totpCode(key, counter), nil
+			case "verify":
+				code, ok := args["code"].(string)
+				if !ok {
+					return "", fmt.Errorf("missing 'code' argument")
+				}
+				code = strings.TrimSpace(code)
+				for skew := -totpSkew; skew <= totpSkew; skew++ {
+					expected := totpCode(key, counter+uint64(skew))
+					if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+						return "valid", nil
+					}
+				}
+				return "invalid", nil
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// decodeTOTPSecret decodes a base32 secret, tolerating lowercase letters,
+// spaces and missing padding as commonly shown by authenticator apps.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	cleaned := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	cleaned = strings.TrimRight(cleaned, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(cleaned)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("malformed secret: expected a base32 encoded string")
+	}
+	return key, nil
+}
+
+// totpCode computes the HOTP value (RFC 4226) for counter.
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
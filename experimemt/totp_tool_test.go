@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238, "12345678901234567890",
+// in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPToolGenerate(t *testing.T) {
+	// RFC 6238 appendix B lists eight-digit codes; the tool gives the last six.
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		tool := NewTOTPToolWithClock(newFakeClock(time.Unix(v.unix, 0)))
+		got, err := tool.Function(map[string]interface{}{"operation": "generate", "secret": rfc6238Secret})
+		if err != nil || got != v.want {
+			t.Errorf("generate at %d = %q, %v; want %s", v.unix, got, err, v.want)
+		}
+	}
+
+	// Authenticator apps show secrets in lowercase groups without padding.
+	tool := NewTOTPToolWithClock(newFakeClock(time.Unix(59, 0)))
+	got, err := tool.Function(map[string]interface{}{"operation": "generate", "secret": "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"})
+	if err != nil || got != "287082" {
+		t.Errorf("generate with a formatted secret = %q, %v", got, err)
+	}
+}
+
+func TestTOTPToolVerify(t *testing.T) {
+	clock := newFakeClock(time.Unix(1111111111, 0))
+	tool := NewTOTPToolWithClock(clock)
+	verify := func(code string) string {
+		t.Helper()
+		got, err := tool.Function(map[string]interface{}{"operation": "verify", "secret": rfc6238Secret, "code": code})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := verify(" 050471 "); got != "valid" {
+		t.Errorf("current code: got %s", got)
+	}
+	clock.Advance(totpStep) // one step of drift is tolerated
+	if got := verify("050471"); got != "valid" {
+		t.Errorf("code from the previous step: got %s", got)
+	}
+	clock.Advance(totpStep)
+	if got := verify("050471"); got != "invalid" {
+		t.Errorf("code from two steps ago: got %s", got)
+	}
+	if got := verify("123456"); got != "invalid" {
+		t.Errorf("wrong code: got %s", got)
+	}
+}
+
+func TestTOTPToolErrors(t *testing.T) {
+	tool := NewTOTPToolWithClock(newFakeClock(time.Unix(0, 0)))
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "generate", "secret": "not base32!"}, "malformed secret: expected a base32 encoded string"},
+		{map[string]interface{}{"operation": "generate", "secret": "  "}, "malformed secret: expected a base32 encoded string"},
+		{map[string]interface{}{"operation": "verify", "secret": rfc6238Secret}, "missing 'code' argument"},
+		{map[string]interface{}{"operation": "revoke", "secret": rfc6238Secret}, "unsupported operation: revoke"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || err.Error() != tt.want {
+			t.Errorf("totp(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
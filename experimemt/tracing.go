@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Span attributes, named after the OpenTelemetry GenAI conventions.
+const (
+	attrModel        = attribute.Key("gen_ai.request.model")
+	attrToolName     = attribute.Key("gen_ai.tool.name")
+	attrInputTokens  = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens = attribute.Key("gen_ai.usage.output_tokens")
+	attrStep         = attribute.Key("agent.step")
+)
+
+// tracer returns Agent.Tracer, or a tracer that records nothing.
+func (a *Agent) tracer() trace.Tracer {
+	if a.Tracer != nil {
+		return a.Tracer
+	}
+	return noop.NewTracerProvider().Tracer("gemmalocalllm")
+}
+
+// endSpan marks span as failed if err is non-nil and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordTokenCounts adds the token usage Ollama reports for a model call to
+// the call's span, if any.
+func recordTokenCounts(ctx context.Context, promptTokens, outputTokens int) {
+	if promptTokens == 0 && outputTokens == 0 {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrInputTokens.Int(promptTokens), attrOutputTokens.Int(outputTokens))
+}
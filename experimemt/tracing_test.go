@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// spanRecorder is an in-memory trace.Tracer that keeps every span it starts.
+type spanRecorder struct {
+	noop.Tracer
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+// recordedSpan is a span started by a spanRecorder.
+type recordedSpan struct {
+	noop.Span
+	name   string
+	parent *recordedSpan
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	ended  bool
+}
+
+func (r *spanRecorder) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[attribute.Key]attribute.Value{}}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func TestRunEmitsSpans(t *testing.T) {
+	srv, _ := droppingServer(t,
+		[]string{`{"response":"Action: {\"name\": \"echo\", \"arguments\": {\"from\": \"a\"}}","prompt_eval_count":120,"eval_count":15,"done":true}`},
+		[]string{`{"response":"Action: {\"name\": \"fail\", \"arguments\": {}}","done":true}`},
+		[]string{`{"response":"Final Answer: done","prompt_eval_count":200,"eval_count":4,"done":true}`},
+	)
+	recorder := &spanRecorder{}
+	calls := 0
+	agent := NewAgent(srv.URL, "gemma3")
+	agent.Tracer = recorder
+	agent.AddTool(echoTool(&calls))
+	agent.AddTool(Tool{Name: "fail", Function: func(map[string]interface{}) (string, error) {
+		return "", errors.New("boom")
+	}})
+
+	if _, err := agent.RunWithTrace(context.Background(), historyPath(t), "q", nil); err != nil {
+		t.Fatalf("RunWithTrace: %v", err)
+	}
+
+	var names []string
+	for _, span := range recorder.spans {
+		names = append(names, span.name)
+		if !span.ended {
+			t.Errorf("span %s was not ended", span.name)
+		}
+	}
+	want := "agent.run,llm.generate,tool.call,llm.generate,tool.call,llm.generate"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("spans = %v, want %s", names, want)
+	}
+	root := recorder.spans[0]
+	if root.parent != nil || root.attrs[attrModel].AsString() != "gemma3" {
+		t.Errorf("root span: parent %v, attributes %v", root.parent, root.attrs)
+	}
+	for _, span := range recorder.spans[1:] {
+		if span.parent != root {
+			t.Errorf("span %s is not a child of the run", span.name)
+		}
+	}
+
+	first := recorder.spans[1]
+	if first.attrs[attrInputTokens].AsInt64() != 120 || first.attrs[attrOutputTokens].AsInt64() != 15 {
+		t.Errorf("model call token counts = %v", first.attrs)
+	}
+	if _, ok := recorder.spans[3].attrs[attrInputTokens]; ok {
+		t.Error("token counts recorded for a call that reported none")
+	}
+	echo, fail := recorder.spans[2], recorder.spans[4]
+	if echo.attrs[attrToolName].AsString() != "echo" || echo.attrs[attrStep].AsInt64() != 0 || echo.status == codes.Error {
+		t.Errorf("echo span: attributes %v, status %v", echo.attrs, echo.status)
+	}
+	if fail.attrs[attrToolName].AsString() != "fail" || fail.status != codes.Error {
+		t.Errorf("failing tool span: attributes %v, status %v; want an error status", fail.attrs, fail.status)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// languageCodePattern matches ISO 639 language codes with an optional region or
+// script, e.g. "en", "pt-BR" or "zh-Hant".
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// translateTimeout bounds a translation request; long texts take a while.
+const translateTimeout = 30 * time.Second
+
+// NewTranslateTool returns a tool that translates text through a
+// LibreTranslate compatible endpoint, e.g. https://libretranslate.com/translate.
+// The endpoint receives {"q", "source", "target", "format"} and answers with
+// {"translatedText"} plus "detectedLanguage" when the source is "auto". Only
+// the endpoint's host may be contacted, and only at a public address.
+func NewTranslateTool(endpoint string) Tool {
+	client := SafeHTTPClient([]string{urlHost(endpoint)})
+	client.Timeout = translateTimeout
+	return NewTranslateToolWithClient(endpoint, client)
+}
+
+// NewTranslateToolWithClient is like NewTranslateTool but sends its requests
+// with client, e.g. one that may reach a translation server on the local
+// network.
+func NewTranslateToolWithClient(endpoint string, client *http.Client) Tool {
+	return Tool{
+		Name:        "translate",
+		Description: "A tool that translates text between languages given as ISO 639 codes such as 'en', 'fr' or 'de'. Use 'auto' as the source language to detect it.",
+		Args: map[string]string{
+			"text": "string",
+			"from": "string (source language code, or 'auto')",
+			"to":   "string (target language code)",
+		},
+		OptionalArgs: []string{"from"},
+		Function: func(args map[string]interface{}) (string, error) {
+			text, ok := args["text"].(string)
+			if !ok || text == "" {
+				return "", fmt.Errorf("missing 'text' argument")
+			}
+			from, ok := args["from"].(string)
+			if !ok || from == "" {
+				from = "auto"
+			}
+			to, ok := args["to"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'to' argument")
+			}
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			if from != "auto" && !languageCodePattern.MatchString(from) {
+				return "", fmt.Errorf("unsupported source language %q: expected a language code such as 'en' or 'auto'", from)
+			}
+			if !languageCodePattern.MatchString(to) {
+				return "", fmt.Errorf("unsupported target language %q: expected a language code such as 'fr'", to)
+			}
+
+			body, err := json.Marshal(map[string]string{"q": text, "source": from, "target": to, "format": "text"})
+			if err != nil {
+				return "", fmt.Errorf("failed to encode request: %v", err)
+			}
+			resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+			if err != nil {
+				return "", fmt.Errorf("translation request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var result struct {
+				TranslatedText   string `json:"translatedText"`
+				Error            string `json:"error"`
+				DetectedLanguage *struct {
+					Language   string  `json:"language"`
+					Confidence float64 `json:"confidence"`
+				} `json:"detectedLanguage"`
+			}
+			data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+			if err != nil {
+				return "", fmt.Errorf("failed to read translation response: %v", err)
+			}
+			if err := json.Unmarshal(data, &result); err != nil && resp.StatusCode == http.StatusOK {
+				return "", fmt.Errorf("invalid translation response: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				if result.Error != "" {
+					return "", fmt.Errorf("translation failed: %s", result.Error)
+				}
+				return "", fmt.Errorf("translation failed with status %d: %s", resp.StatusCode, truncate(string(data), 512))
+			}
+
+			if from == "auto" && result.DetectedLanguage != nil {
+				return fmt.Sprintf("%s (detected source language: %s)", result.TranslatedText, result.DetectedLanguage.Language), nil
+			}
+			return result.TranslatedText, nil
+		},
+	}
+}
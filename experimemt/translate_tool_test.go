@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTranslateTool(t *testing.T) {
+	var requests []map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		requests = append(requests, req)
+		switch {
+		case req["target"] == "xx":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "xx is not supported"}`)
+		case req["source"] == "auto":
+			fmt.Fprint(w, `{"translatedText": "Hello world", "detectedLanguage": {"language": "fr", "confidence": 92}}`)
+		default:
+			fmt.Fprint(w, `{"translatedText": "Bonjour le monde"}`)
+		}
+	}))
+	defer srv.Close()
+	tool := NewTranslateToolWithClient(srv.URL, srv.Client())
+
+	got, err := tool.Function(map[string]interface{}{"text": "Hello world", "from": "en", "to": " fr "})
+	if err != nil || got != "Bonjour le monde" {
+		t.Errorf("translate(en->fr) = %q, %v", got, err)
+	}
+	want := map[string]string{"q": "Hello world", "source": "en", "target": "fr", "format": "text"}
+	if !reflect.DeepEqual(requests[0], want) {
+		t.Errorf("request = %v, want %v", requests[0], want)
+	}
+
+	got, err = tool.Function(map[string]interface{}{"text": "Bonjour le monde", "to": "en"})
+	if err != nil || got != "Hello world (detected source language: fr)" {
+		t.Errorf("translate(auto->en) = %q, %v", got, err)
+	}
+	if requests[1]["source"] != "auto" {
+		t.Errorf("source = %q, want auto when from is omitted", requests[1]["source"])
+	}
+
+	_, err = tool.Function(map[string]interface{}{"text": "hi", "to": "xx"})
+	if err == nil || err.Error() != "translation failed: xx is not supported" {
+		t.Errorf("unsupported language error = %v, want the endpoint's error", err)
+	}
+}
+
+func TestTranslateToolRejectsBadLanguageCodes(t *testing.T) {
+	tool := NewTranslateToolWithClient("http://unused.invalid", http.DefaultClient)
+	for _, args := range []map[string]interface{}{
+		{"text": "hi", "from": "English", "to": "fr"},
+		{"text": "hi", "to": "french"},
+		{"text": "hi", "to": "auto"},
+	} {
+		_, err := tool.Function(args)
+		if err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Errorf("translate(%v) error = %v, want an unsupported language", args, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// NewTZConvertTool returns a tool that converts an RFC3339 timestamp between
+// IANA time zones, e.g. from "America/New_York" to "Europe/London". Daylight
+// saving transitions are handled by the time zone database.
+func NewTZConvertTool() Tool {
+	return Tool{
+		Name:        "tz_convert",
+		Description: "A tool that converts a time between IANA time zones (e.g. 'America/New_York', 'Europe/London', 'UTC').",
+		Args: map[string]string{
+			"time": "string (RFC3339, e.g. '2024-03-10T01:30:00' or '2024-03-10T01:30:00-05:00')",
+			"from": "string (IANA zone the time is in, used when the time has no offset)",
+			"to":   "string (IANA zone to convert to)",
+		},
+		OptionalArgs: []string{"from"},
+		Function: func(args map[string]interface{}) (string, error) {
+			value, ok := args["time"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'time' argument")
+			}
+			fromName, _ := args["from"].(string)
+			toName, ok := args["to"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'to' argument")
+			}
+
+			var from *time.Location
+			if fromName != "" {
+				var err error
+				if from, err = time.LoadLocation(fromName); err != nil {
+					return "", fmt.Errorf("invalid 'from' time zone %q: %v", fromName, err)
+				}
+			}
+			to, err := time.LoadLocation(toName)
+			if err != nil {
+				return "", fmt.Errorf("invalid 'to' time zone %q: %v", toName, err)
+			}
+
+			t, err := parseTimeIn(value, from)
+			if err != nil {
+				return "", err
+			}
+			return t.In(to).Format(time.RFC3339), nil
+		},
+	}
+}
+
+// parseTimeIn parses an RFC3339 timestamp. A timestamp without an offset is
+// interpreted as wall-clock time in loc, and rejected if loc is nil.
+func parseTimeIn(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if loc == nil {
+		if _, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+			return time.Time{}, fmt.Errorf("time %q has no offset, so the 'from' time zone is needed", value)
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 such as 2006-01-02T15:04:05Z07:00", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTZConvertTool(t *testing.T) {
+	tool := NewTZConvertTool()
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"wall clock in from zone", map[string]interface{}{"time": "2024-07-01T12:00:00", "from": "America/New_York", "to": "Europe/London"}, "2024-07-01T17:00:00+01:00"},
+		{"offset needs no from", map[string]interface{}{"time": "2024-01-15T09:00:00-05:00", "to": "UTC"}, "2024-01-15T14:00:00Z"},
+		{"across DST boundary", map[string]interface{}{"time": "2024-03-31T00:30:00Z", "to": "Europe/Berlin"}, "2024-03-31T01:30:00+01:00"},
+		{"after DST starts", map[string]interface{}{"time": "2024-03-31T01:30:00Z", "to": "Europe/Berlin"}, "2024-03-31T03:30:00+02:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Function(tt.args)
+			if err != nil {
+				t.Fatalf("Function: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTZConvertToolErrors(t *testing.T) {
+	tool := NewTZConvertTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"time": "2024-07-01T12:00:00", "to": "UTC"}, "'from' time zone is needed"},
+		{map[string]interface{}{"time": "2024-07-01T12:00:00Z", "to": "Mars/Olympus"}, "invalid 'to' time zone"},
+		{map[string]interface{}{"time": "noon", "from": "UTC", "to": "UTC"}, "invalid time"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Function(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
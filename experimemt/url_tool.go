@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// URLParts is the structured form of a URL used by the url_tool's parse and
+// build operations.
+type URLParts struct {
+	Scheme   string              `json:"scheme"`
+	Host     string              `json:"host"`
+	Path     string              `json:"path"`
+	Query    map[string][]string `json:"query"`
+	Fragment string              `json:"fragment,omitempty"`
+}
+
+// NewURLTool returns a tool for URL manipulation:
+//
+//   - encode / decode: query-escape or unescape a string value
+//   - parse: split a URL into scheme, host, path and query (JSON)
+//   - build: assemble a URL from scheme, host, path and a query object
+func NewURLTool() Tool {
+	return Tool{
+		Name:        "url_tool",
+		Description: "A tool that encodes, decodes, parses and builds URLs and query strings.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'encode', 'decode', 'parse', 'build')",
+			"value":     "string (text for 'encode'/'decode', URL for 'parse')",
+			"scheme":    "string (for 'build', e.g. 'https')",
+			"host":      "string (for 'build')",
+			"path":      "string (for 'build')",
+			"query":     "object of parameter names to values (for 'build')",
+		},
+		OptionalArgs: []string{"value", "scheme", "host", "path", "query"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+
+			switch op {
+			case "encode", "decode", "parse":
+				value, ok := args["value"].(string)
+				if !ok {
+					return "", fmt.Errorf("missing 'value' argument")
+				}
+				switch op {
+				case "encode":
+					return url.QueryEscape(value), nil
+				case "decode":
+					decoded, err := url.QueryUnescape(value)
+					if err != nil {
+						return "", fmt.Errorf("malformed encoded value: %v", err)
+					}
+					return decoded, nil
+				}
+				return parseURLParts(value)
+			case "build":
+				return buildURL(args)
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// parseURLParts returns the components of rawURL as JSON.
+func parseURLParts(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("malformed URL: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("malformed URL %q: expected an absolute URL with scheme and host", rawURL)
+	}
+	parts := URLParts{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Path:     u.Path,
+		Query:    u.Query(),
+		Fragment: u.Fragment,
+	}
+	out, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode URL parts: %v", err)
+	}
+	return string(out), nil
+}
+
+// buildURL assembles a URL from the scheme, host, path and query arguments.
+// Query values may be single values or lists.
+func buildURL(args map[string]interface{}) (string, error) {
+	scheme, _ := args["scheme"].(string)
+	host, _ := args["host"].(string)
+	path, _ := args["path"].(string)
+	if scheme == "" {
+		scheme = "https"
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing 'host' argument")
+	}
+
+	query := url.Values{}
+	if raw, ok := args["query"]; ok && raw != nil {
+		params, ok := raw.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("invalid 'query' argument, expected an object")
+		}
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch v := params[k].(type) {
+			case []interface{}:
+				for _, item := range v {
+					query.Add(k, fmt.Sprint(item))
+				}
+			default:
+				query.Add(k, fmt.Sprint(v))
+			}
+		}
+	}
+
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	u := url.URL{Scheme: scheme, Host: host, Path: path, RawQuery: query.Encode()}
+	return u.String(), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestURLToolEncodeDecode(t *testing.T) {
+	tool := NewURLTool()
+	encoded, err := tool.Function(map[string]interface{}{"operation": "encode", "value": "a b&c=d/é"})
+	if err != nil || encoded != "a+b%26c%3Dd%2F%C3%A9" {
+		t.Fatalf("encode = %q, %v", encoded, err)
+	}
+	decoded, err := tool.Function(map[string]interface{}{"operation": "decode", "value": encoded})
+	if err != nil || decoded != "a b&c=d/é" {
+		t.Fatalf("decode = %q, %v", decoded, err)
+	}
+	if _, err := tool.Function(map[string]interface{}{"operation": "decode", "value": "100%"}); err == nil {
+		t.Error("decode of a malformed escape succeeded")
+	}
+}
+
+func TestURLToolParse(t *testing.T) {
+	tool := NewURLTool()
+	out, err := tool.Function(map[string]interface{}{
+		"operation": "parse",
+		"value":     "https://example.com:8080/a/b?q=go+lang&tag=x&tag=y#top",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts URLParts
+	if err := json.Unmarshal([]byte(out), &parts); err != nil {
+		t.Fatalf("parse returned invalid JSON %q: %v", out, err)
+	}
+	want := URLParts{
+		Scheme:   "https",
+		Host:     "example.com:8080",
+		Path:     "/a/b",
+		Query:    map[string][]string{"q": {"go lang"}, "tag": {"x", "y"}},
+		Fragment: "top",
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("parse = %+v, want %+v", parts, want)
+	}
+
+	for _, bad := range []string{"/relative/path", "http://[::1", "example.com"} {
+		if _, err := tool.Function(map[string]interface{}{"operation": "parse", "value": bad}); err == nil {
+			t.Errorf("parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestURLToolBuild(t *testing.T) {
+	tool := NewURLTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"host": "example.com"}, "https://example.com"},
+		{map[string]interface{}{"scheme": "http", "host": "example.com", "path": "a b"}, "http://example.com/a%20b"},
+		{map[string]interface{}{
+			"host":  "example.com",
+			"path":  "/search",
+			"query": map[string]interface{}{"q": "go lang", "n": 10.0, "tag": []interface{}{"x", "y"}},
+		}, "https://example.com/search?n=10&q=go+lang&tag=x&tag=y"},
+	}
+	for _, tt := range tests {
+		tt.args["operation"] = "build"
+		got, err := tool.Function(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("build(%v) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	if _, err := tool.Function(map[string]interface{}{"operation": "build", "path": "/x"}); err == nil {
+		t.Error("build without a host succeeded")
+	}
+	if _, err := tool.Function(map[string]interface{}{"operation": "build", "host": "h", "query": "q=1"}); err == nil {
+		t.Error("build with a non-object query succeeded")
+	}
+	if _, err := tool.Function(map[string]interface{}{"operation": "shorten"}); err == nil {
+		t.Error("unsupported operation succeeded")
+	}
+}
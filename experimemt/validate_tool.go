@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// uuidPattern matches the canonical 8-4-4-4-12 hexadecimal UUID form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidationResult is the structured outcome returned by the validate tool.
+type ValidationResult struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason"`
+}
+
+// NewValidateTool returns a tool that checks whether a value is a well-formed
+// email address, URL, IPv4 address, UUID or JSON document. The result is a
+// JSON encoded ValidationResult.
+func NewValidateTool() Tool {
+	return Tool{
+		Name:        "validate",
+		Description: "A tool that checks whether a value matches a common format. Returns {\"valid\": bool, \"reason\": string}.",
+		Args: map[string]string{
+			"value":  "string",
+			"format": "string (e.g., 'email', 'url', 'ipv4', 'uuid', 'json')",
+		},
+		Function: func(args map[string]interface{}) (string, error) {
+			value, ok := args["value"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'value' argument")
+			}
+			format, ok := args["format"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'format' argument")
+			}
+
+			var result ValidationResult
+			switch strings.ToLower(format) {
+			case "email":
+				result = validateEmail(value)
+			case "url":
+				result = validateURL(value)
+			case "ipv4":
+				result = validateIPv4(value)
+			case "uuid":
+				if uuidPattern.MatchString(value) {
+					result = ValidationResult{Valid: true, Reason: "well-formed UUID"}
+				} else {
+					result = ValidationResult{Reason: "expected 32 hex digits in the form 8-4-4-4-12"}
+				}
+			case "json":
+				var v interface{}
+				if err := json.Unmarshal([]byte(value), &v); err != nil {
+					result = ValidationResult{Reason: err.Error()}
+				} else {
+					result = ValidationResult{Valid: true, Reason: "well-formed JSON"}
+				}
+			default:
+				return "", fmt.Errorf("unsupported format: %s", format)
+			}
+
+			out, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %v", err)
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// validateEmail accepts a bare address such as user@example.com. Display-name
+// forms like "Jane <jane@example.com>" are rejected.
+func validateEmail(value string) ValidationResult {
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return ValidationResult{Reason: err.Error()}
+	}
+	if addr.Address != value {
+		return ValidationResult{Reason: "value contains more than a bare email address"}
+	}
+	if !strings.Contains(addr.Address[strings.LastIndex(addr.Address, "@"):], ".") {
+		return ValidationResult{Reason: "domain has no top-level domain"}
+	}
+	return ValidationResult{Valid: true, Reason: "well-formed email address"}
+}
+
+// validateURL requires an absolute URL with a scheme and host.
+func validateURL(value string) ValidationResult {
+	u, err := url.Parse(value)
+	if err != nil {
+		return ValidationResult{Reason: err.Error()}
+	}
+	if u.Scheme == "" {
+		return ValidationResult{Reason: "missing scheme"}
+	}
+	if u.Host == "" {
+		return ValidationResult{Reason: "missing host"}
+	}
+	return ValidationResult{Valid: true, Reason: "well-formed URL"}
+}
+
+// validateIPv4 accepts dotted-quad IPv4 addresses only.
+func validateIPv4(value string) ValidationResult {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return ValidationResult{Reason: "not an IP address"}
+	}
+	if ip.To4() == nil || strings.Contains(value, ":") {
+		return ValidationResult{Reason: "IPv6 address, not IPv4"}
+	}
+	return ValidationResult{Valid: true, Reason: "well-formed IPv4 address"}
+}
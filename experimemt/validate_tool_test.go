@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateTool(t *testing.T) {
+	tool := NewValidateTool()
+	tests := []struct {
+		format, value string
+		valid         bool
+		reason        string
+	}{
+		{"email", "user@example.com", true, "well-formed email address"},
+		{"email", "Jane <jane@example.com>", false, "value contains more than a bare email address"},
+		{"email", "user@localhost", false, "domain has no top-level domain"},
+		{"email", "not an email", false, ""},
+		{"url", "https://example.com/path?q=1", true, "well-formed URL"},
+		{"url", "example.com/path", false, "missing scheme"},
+		{"url", "mailto:user@example.com", false, "missing host"},
+		{"ipv4", "192.168.0.1", true, "well-formed IPv4 address"},
+		{"ipv4", "::ffff:192.168.0.1", false, "IPv6 address, not IPv4"},
+		{"ipv4", "256.1.1.1", false, "not an IP address"},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true, "well-formed UUID"},
+		{"UUID", "123e4567e89b12d3a456426614174000", false, "expected 32 hex digits in the form 8-4-4-4-12"},
+		{"json", `{"a": [1, 2]}`, true, "well-formed JSON"},
+		{"json", `{"a": }`, false, ""},
+	}
+	for _, tt := range tests {
+		out, err := tool.Function(map[string]interface{}{"value": tt.value, "format": tt.format})
+		if err != nil {
+			t.Errorf("validate(%q as %s): %v", tt.value, tt.format, err)
+			continue
+		}
+		var got ValidationResult
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("result %q is not JSON: %v", out, err)
+		}
+		if got.Valid != tt.valid || got.Reason == "" || (tt.reason != "" && got.Reason != tt.reason) {
+			t.Errorf("validate(%q as %s) = %+v, want valid=%v reason %q", tt.value, tt.format, got, tt.valid, tt.reason)
+		}
+	}
+}
+
+func TestValidateToolUnknownFormat(t *testing.T) {
+	_, err := NewValidateTool().Function(map[string]interface{}{"value": "x", "format": "iban"})
+	if err == nil || err.Error() != "unsupported format: iban" {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// toolUsageNudge is appended to the history when VerifyToolUsage finds that a
+// final answer ignored the latest tool observation.
+const toolUsageNudge = "Your final answer does not use the result of the last tool call. Base your Final Answer on that observation instead of guessing."
+
+// answerUsesObservation is a lightweight check that answer refers to the tool
+// observation it should be based on. It looks for any significant token of the
+// observation (a number, compared numerically, or a word of four or more
+// letters) in the answer. Observations without significant tokens always pass.
+func answerUsesObservation(answer, observation string) bool {
+	answer = strings.ToLower(answer)
+	answerNumbers := numericTokens(answer)
+
+	significant := 0
+	for _, tok := range tokenize(strings.ToLower(observation)) {
+		if n, err := strconv.ParseFloat(tok, 64); err == nil {
+			significant++
+			for _, m := range answerNumbers {
+				if n == m {
+					return true
+				}
+			}
+			continue
+		}
+		if len(tok) >= 4 {
+			significant++
+			if strings.Contains(answer, tok) {
+				return true
+			}
+		}
+	}
+	return significant == 0
+}
+
+// tokenize splits s into runs of letters, digits and decimal points, trimming
+// any stray leading or trailing dots.
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if f = strings.Trim(f, "."); f != "" {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// numericTokens returns every token of s that parses as a number.
+func numericTokens(s string) []float64 {
+	var nums []float64
+	for _, tok := range tokenize(s) {
+		if n, err := strconv.ParseFloat(tok, 64); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	return nums
+}
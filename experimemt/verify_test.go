@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAnswerUsesObservation(t *testing.T) {
+	tests := []struct {
+		answer, observation string
+		want                bool
+	}{
+		{"The total is 42.", "42", true},
+		{"It costs 12.50 dollars.", `{"price": 12.5}`, true},
+		{"It is sunny in Paris.", "Weather in Paris: sunny, 21C", true},
+		{"It is probably 7.", "42", false},
+		{"It rained.", "Weather in Paris: sunny", false},
+		{"Done.", "ok", true}, // nothing significant to look for
+		{"Saved.", "", true},
+	}
+	for _, tt := range tests {
+		if got := answerUsesObservation(tt.answer, tt.observation); got != tt.want {
+			t.Errorf("answerUsesObservation(%q, %q) = %v, want %v", tt.answer, tt.observation, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyToolUsageNudgesOnce(t *testing.T) {
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "calc", "arguments": {}}`,
+		"Final Answer: about 40",
+		"Final Answer: it is 1764",
+	})
+	agent.AddTool(ScriptedTool("calc", "1764"))
+	agent.VerifyToolUsage = true
+
+	got, err := agent.Run(context.Background(), historyPath(t), "what is 42 squared?", nil)
+	if err != nil || got != "it is 1764" {
+		t.Fatalf("Run = %q, %v; want the answer that uses the observation", got, err)
+	}
+	prompts := agent.Client.(*ScriptedClient).Prompts()
+	if len(prompts) != 3 || !strings.Contains(prompts[2], toolUsageNudge) {
+		t.Errorf("want a third prompt carrying the nudge, got %d prompts", len(prompts))
+	}
+}
+
+func TestVerifyToolUsageAcceptsSecondAnswer(t *testing.T) {
+	// The nudge is given once; a second answer that still ignores the
+	// observation is accepted rather than looping.
+	agent := ScriptedAgent([]string{
+		`Action: {"name": "calc", "arguments": {}}`,
+		"Final Answer: about 40",
+		"Final Answer: still about 40",
+	})
+	agent.AddTool(ScriptedTool("calc", "1764"))
+	agent.VerifyToolUsage = true
+
+	if got, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil || got != "still about 40" {
+		t.Fatalf("Run = %q, %v; want the second answer", got, err)
+	}
+}
+
+func TestVerifyToolUsageOff(t *testing.T) {
+	agent := ScriptedAgent([]string{`Action: {"name": "calc", "arguments": {}}`, "Final Answer: about 40"})
+	agent.AddTool(ScriptedTool("calc", "1764"))
+	if got, err := agent.Run(context.Background(), historyPath(t), "q", nil); err != nil || got != "about 40" {
+		t.Fatalf("Run = %q, %v; want the answer unchecked", got, err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Warmup makes Ollama load the agent's model into memory, so the first Run
+// doesn't also pay for loading it. It sends a generate request without a
+// prompt, which Ollama treats as load-only, or an empty prompt to Client when
+// one is set. KeepAlive, if set, controls how long the model then stays loaded.
+func (a *Agent) Warmup(ctx context.Context) error {
+	if a.Client != nil {
+		_, err := a.Client.Generate(ctx, "")
+		return err
+	}
+
+	body, err := json.Marshal(OllamaRequest{Model: a.Model, KeepAlive: a.keepAlive()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint(generatePath), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// No client timeout: loading a large model can legitimately take minutes,
+	// so the caller's context bounds the wait instead.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send warmup request to Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &OllamaError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// keepAlive returns KeepAlive in the form the Ollama API expects, or "" for
+// the server default.
+func (a *Agent) keepAlive() string {
+	if a.KeepAlive <= 0 {
+		return ""
+	}
+	return a.KeepAlive.String()
+}
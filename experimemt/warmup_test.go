@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	var requests []OllamaRequest
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"model":"gemma3","response":"","done":true,"done_reason":"load"}`))
+	}))
+	defer srv.Close()
+
+	agent := NewAgent(srv.URL+"/api/generate", "gemma3")
+	agent.KeepAlive = 30 * time.Minute
+	if err := agent.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if len(requests) != 1 || paths[0] != "/api/generate" {
+		t.Fatalf("requests = %v to %v, want one to /api/generate", requests, paths)
+	}
+	if req := requests[0]; req.Model != "gemma3" || req.Prompt != "" || req.KeepAlive != "30m0s" {
+		t.Errorf("warmup request = %+v, want an empty prompt with keep_alive", req)
+	}
+}
+
+func TestWarmupErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model 'nope' not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+	var ollamaErr *OllamaError
+	if err := NewAgent(srv.URL, "nope").Warmup(context.Background()); !errors.As(err, &ollamaErr) || ollamaErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Warmup error = %v, want the server's 404", err)
+	}
+
+	agent := ScriptedAgent([]string{""})
+	if err := agent.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup with a client: %v", err)
+	}
+	if prompts := agent.Client.(*ScriptedClient).Prompts(); len(prompts) != 1 || prompts[0] != "" {
+		t.Errorf("client prompts = %q, want a single empty prompt", prompts)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Default Open-Meteo endpoints. Neither needs an API key.
+const (
+	openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	openMeteoForecastURL  = "https://api.open-meteo.com/v1/forecast"
+)
+
+// weatherCodes describes the WMO weather interpretation codes used by Open-Meteo.
+var weatherCodes = map[int]string{
+	0: "clear sky", 1: "mainly clear", 2: "partly cloudy", 3: "overcast",
+	45: "fog", 48: "depositing rime fog",
+	51: "light drizzle", 53: "moderate drizzle", 55: "dense drizzle",
+	56: "light freezing drizzle", 57: "dense freezing drizzle",
+	61: "slight rain", 63: "moderate rain", 65: "heavy rain",
+	66: "light freezing rain", 67: "heavy freezing rain",
+	71: "slight snow", 73: "moderate snow", 75: "heavy snow", 77: "snow grains",
+	80: "slight rain showers", 81: "moderate rain showers", 82: "violent rain showers",
+	85: "slight snow showers", 86: "heavy snow showers",
+	95: "thunderstorm", 96: "thunderstorm with slight hail", 99: "thunderstorm with heavy hail",
+}
+
+// NewWeatherTool returns a tool that reports the current weather for a place
+// using the public Open-Meteo API.
+func NewWeatherTool() Tool {
+	client := SafeHTTPClient([]string{urlHost(openMeteoGeocodingURL), urlHost(openMeteoForecastURL)})
+	return NewWeatherToolWithEndpoints(openMeteoGeocodingURL, openMeteoForecastURL, client)
+}
+
+// NewWeatherToolWithEndpoints is like NewWeatherTool but talks to the given
+// Open-Meteo compatible geocoding and forecast endpoints using client. The
+// location is geocoded first and the current conditions are then fetched for
+// its coordinates.
+func NewWeatherToolWithEndpoints(geocodingURL, forecastURL string, client *http.Client) Tool {
+	return Tool{
+		Name:        "weather",
+		Description: "A tool that reports the current weather for a location, e.g. 'London' or 'Paris, France'.",
+		Args:        map[string]string{"location": "string"},
+		Function: func(args map[string]interface{}) (string, error) {
+			location, ok := args["location"].(string)
+			if !ok || location == "" {
+				return "", fmt.Errorf("missing 'location' argument")
+			}
+
+			var geo struct {
+				Results []struct {
+					Name      string  `json:"name"`
+					Country   string  `json:"country"`
+					Latitude  float64 `json:"latitude"`
+					Longitude float64 `json:"longitude"`
+				} `json:"results"`
+			}
+			query := url.Values{"name": {location}, "count": {"1"}}
+			if err := getJSON(client, geocodingURL+"?"+query.Encode(), &geo); err != nil {
+				return "", fmt.Errorf("geocoding failed: %v", err)
+			}
+			if len(geo.Results) == 0 {
+				return "", fmt.Errorf("unknown location: %s", location)
+			}
+			place := geo.Results[0]
+
+			var forecast struct {
+				Current struct {
+					Temperature float64 `json:"temperature_2m"`
+					Humidity    float64 `json:"relative_humidity_2m"`
+					WindSpeed   float64 `json:"wind_speed_10m"`
+					WeatherCode int     `json:"weather_code"`
+				} `json:"current"`
+			}
+			query = url.Values{
+				"latitude":  {fmt.Sprint(place.Latitude)},
+				"longitude": {fmt.Sprint(place.Longitude)},
+				"current":   {"temperature_2m,relative_humidity_2m,wind_speed_10m,weather_code"},
+			}
+			if err := getJSON(client, forecastURL+"?"+query.Encode(), &forecast); err != nil {
+				return "", fmt.Errorf("weather lookup failed: %v", err)
+			}
+
+			name := place.Name
+			if place.Country != "" {
+				name += ", " + place.Country
+			}
+			conditions, ok := weatherCodes[forecast.Current.WeatherCode]
+			if !ok {
+				conditions = fmt.Sprintf("weather code %d", forecast.Current.WeatherCode)
+			}
+			return fmt.Sprintf("Current weather in %s: %.1f°C, %s, humidity %.0f%%, wind %.1f km/h",
+				name, forecast.Current.Temperature, conditions, forecast.Current.Humidity, forecast.Current.WindSpeed), nil
+		},
+	}
+}
+
+// getJSON fetches rawURL and decodes the JSON body into v, treating any
+// non-200 status as an error.
+func getJSON(client *http.Client, rawURL string, v interface{}) error {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(client, req, v)
+}
+
+// doJSON is getJSON for a prepared request, e.g. one carrying extra headers.
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	return nil
+}
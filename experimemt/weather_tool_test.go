@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWeatherTool(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/geo", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") == "Atlantis" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprint(w, `{"results": [{"name": "London", "country": "United Kingdom", "latitude": 51.5, "longitude": -0.12}]}`)
+	})
+	mux.HandleFunc("/forecast", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("latitude") != "51.5" || q.Get("longitude") != "-0.12" {
+			t.Errorf("forecast for %s,%s, want the geocoded coordinates", q.Get("latitude"), q.Get("longitude"))
+		}
+		fmt.Fprint(w, `{"current": {"temperature_2m": 14.26, "relative_humidity_2m": 81, "wind_speed_10m": 12.3, "weather_code": 61}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	tool := NewWeatherToolWithEndpoints(srv.URL+"/geo", srv.URL+"/forecast", srv.Client())
+
+	got, err := tool.Function(map[string]interface{}{"location": "London"})
+	want := "Current weather in London, United Kingdom: 14.3°C, slight rain, humidity 81%, wind 12.3 km/h"
+	if err != nil || got != want {
+		t.Errorf("weather(London) = %q, %v; want %q", got, err, want)
+	}
+
+	_, err = tool.Function(map[string]interface{}{"location": "Atlantis"})
+	if err == nil || err.Error() != "unknown location: Atlantis" {
+		t.Errorf("weather(Atlantis) error = %v, want unknown location", err)
+	}
+}
+
+func TestWeatherToolAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	tool := NewWeatherToolWithEndpoints(srv.URL, srv.URL, srv.Client())
+
+	_, err := tool.Function(map[string]interface{}{"location": "London"})
+	if err == nil || !strings.Contains(err.Error(), "geocoding failed: status 429") {
+		t.Errorf("error = %v, want the API status reported", err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NewYAMLTool returns a tool that converts YAML to and from JSON, reformats it
+// and extracts values by dotted path, e.g. "spec.containers.0.image", where
+// numeric segments index into lists.
+func NewYAMLTool() Tool {
+	return Tool{
+		Name:        "yaml",
+		Description: "A tool that converts YAML to and from JSON, reformats YAML and extracts values from it by dotted path.",
+		Args: map[string]string{
+			"operation": "string (e.g., 'to_json', 'from_json', 'format', 'get')",
+			"input":     "string (YAML, or JSON for 'from_json')",
+			"path":      "string (dotted path for 'get', e.g. 'spec.replicas' or 'items.0.name')",
+		},
+		OptionalArgs: []string{"path"},
+		Function: func(args map[string]interface{}) (string, error) {
+			op, ok := args["operation"].(string)
+			if !ok {
+				return "", fmt.Errorf("missing 'operation' argument")
+			}
+			input, ok := args["input"].(string)
+			if !ok || strings.TrimSpace(input) == "" {
+				return "", fmt.Errorf("missing 'input' argument")
+			}
+
+			switch op {
+			case "to_json":
+				v, err := parseYAML(input)
+				if err != nil {
+					return "", err
+				}
+				out, err := json.Marshal(v)
+				if err != nil {
+					return "", fmt.Errorf("cannot convert to JSON: %v", err)
+				}
+				return string(out), nil
+			case "from_json":
+				var v interface{}
+				if err := json.Unmarshal([]byte(input), &v); err != nil {
+					return "", fmt.Errorf("invalid JSON: %v", err)
+				}
+				return marshalYAML(v)
+			case "format":
+				var node yaml.Node
+				if err := yaml.Unmarshal([]byte(input), &node); err != nil {
+					return "", fmt.Errorf("invalid YAML: %v", err)
+				}
+				return marshalYAML(&node)
+			case "get":
+				path, ok := args["path"].(string)
+				if !ok || path == "" {
+					return "", fmt.Errorf("missing 'path' argument")
+				}
+				v, err := parseYAML(input)
+				if err != nil {
+					return "", err
+				}
+				v, err = lookupPath(v, path)
+				if err != nil {
+					return "", err
+				}
+				if s, ok := v.(string); ok {
+					return s, nil
+				}
+				return marshalYAML(v)
+			default:
+				return "", fmt.Errorf("unsupported operation: %s", op)
+			}
+		},
+	}
+}
+
+// parseYAML decodes the first YAML document in input into plain maps, slices
+// and scalars, converting non-string map keys to strings so the result can be
+// encoded as JSON.
+func parseYAML(input string) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(input), &v); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	return stringKeys(v), nil
+}
+
+// stringKeys rewrites map[interface{}]interface{} values within v, which YAML
+// produces for non-string keys, as map[string]interface{}.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// lookupPath follows a dotted path through maps and lists.
+func lookupPath(v interface{}, path string) (interface{}, error) {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		at := strings.Join(segments[:i+1], ".")
+		switch node := v.(type) {
+		case map[string]interface{}:
+			item, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", at)
+			}
+			v = item
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %q is not a list index", at, segment)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("path %q: index %d out of range (list has %d items)", at, index, len(node))
+			}
+			v = node[index]
+		default:
+			return nil, fmt.Errorf("path %q not found: %q is not a map or list", at, strings.Join(segments[:i], "."))
+		}
+	}
+	return v, nil
+}
+
+// marshalYAML encodes v as YAML with two-space indentation.
+func marshalYAML(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const deploymentYAML = `spec:
+  replicas: 3
+  containers:
+    - name: web
+      image: nginx:1.25
+    - name: sidecar
+      image: envoy
+`
+
+func TestYAMLToolConversions(t *testing.T) {
+	tool := NewYAMLTool()
+	got, err := tool.Function(map[string]interface{}{"operation": "to_json", "input": "name: app\nports: [80, 443]\n1: one\n"})
+	if want := `{"1":"one","name":"app","ports":[80,443]}`; err != nil || got != want {
+		t.Errorf("to_json = %q, %v; want %q", got, err, want)
+	}
+
+	got, err = tool.Function(map[string]interface{}{"operation": "from_json", "input": `{"name":"app","tags":["a","b"]}`})
+	if want := "name: app\ntags:\n  - a\n  - b"; err != nil || got != want {
+		t.Errorf("from_json = %q, %v; want %q", got, err, want)
+	}
+
+	got, err = tool.Function(map[string]interface{}{"operation": "format", "input": "a:   1\nb:\n      - x # keep me\n"})
+	if want := "a: 1\nb:\n  - x # keep me"; err != nil || got != want {
+		t.Errorf("format = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestYAMLToolGet(t *testing.T) {
+	tool := NewYAMLTool()
+	tests := []struct {
+		path, want string
+	}{
+		{"spec.replicas", "3"},
+		{"spec.containers.1.image", "envoy"},
+		{"spec.containers.0", "image: nginx:1.25\nname: web"},
+	}
+	for _, tt := range tests {
+		got, err := tool.Function(map[string]interface{}{"operation": "get", "input": deploymentYAML, "path": tt.path})
+		if err != nil || got != tt.want {
+			t.Errorf("get %s = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestYAMLToolErrors(t *testing.T) {
+	tool := NewYAMLTool()
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"operation": "to_json", "input": "a: [1, 2"}, "invalid YAML"},
+		{map[string]interface{}{"operation": "from_json", "input": "{"}, "invalid JSON"},
+		{map[string]interface{}{"operation": "get", "input": deploymentYAML, "path": "spec.volumes"}, `path "spec.volumes" not found`},
+		{map[string]interface{}{"operation": "get", "input": deploymentYAML, "path": "spec.containers.5"}, "index 5 out of range (list has 2 items)"},
+		{map[string]interface{}{"operation": "get", "input": deploymentYAML, "path": "spec.replicas.x"}, `"spec.replicas" is not a map or list`},
+		{map[string]interface{}{"operation": "get", "input": deploymentYAML}, "missing 'path' argument"},
+		{map[string]interface{}{"operation": "sort", "input": "a: 1"}, "unsupported operation: sort"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Function(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
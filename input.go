@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chzyer/readline"
+)
+
+// Prompts shown by the REPL.
+const (
+	userPrompt   = "You: "
+	actionPrompt = "Choose an action to execute (or press Enter to continue): "
+)
+
+// inputHistoryLimit caps how many past inputs are kept in the history file.
+const inputHistoryLimit = 500
+
+// defaultInputHistoryPath returns the file in the user's home directory where
+// REPL inputs are persisted between sessions, or "" when there is no home
+// directory, which disables persistence.
+func defaultInputHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gemmalocalllm_history")
+}
+
+// newLineReader returns a line editor with up/down arrow recall of the inputs
+// stored in historyPath. Entries are only recorded when the caller passes them
+// to SaveHistory, so answers to action prompts stay out of the history.
+func newLineReader(historyPath string) (*readline.Instance, error) {
+	return readline.NewEx(lineReaderConfig(historyPath))
+}
+
+// lineReaderConfig returns the configuration of newLineReader.
+func lineReaderConfig(historyPath string) *readline.Config {
+	return &readline.Config{
+		Prompt:                 userPrompt,
+		HistoryFile:            historyPath,
+		HistoryLimit:           inputHistoryLimit,
+		DisableAutoSaveHistory: true,
+		HistorySearchFold:      true,
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chzyer/readline"
+)
+
+// openHistory returns a readline operation that records inputs in the
+// history file at path like newLineReader does. It runs without a terminal:
+// readline's terminal goroutine races with Instance.Close, so tests go
+// straight to the history layer.
+func openHistory(t *testing.T, path string) *readline.Operation {
+	t.Helper()
+	cfg := lineReaderConfig(path)
+	cfg.Stdin = io.NopCloser(strings.NewReader(""))
+	cfg.Stdout, cfg.Stderr = io.Discard, io.Discard
+	if err := cfg.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return readline.NewOperation(&readline.Terminal{}, cfg)
+}
+
+// historyLines returns the entries in the input history file at path.
+func historyLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestLineReaderPersistsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	if !lineReaderConfig(path).DisableAutoSaveHistory {
+		t.Error("line reader saves every line, including answers to action prompts")
+	}
+	rl := openHistory(t, path)
+	rl.SaveHistory("first question")
+	rl.SaveHistory("second question")
+	rl.Close()
+
+	// A later session appends to what the earlier one saved.
+	rl = openHistory(t, path)
+	rl.SaveHistory("third question")
+	rl.Close()
+
+	want := []string{"first question", "second question", "third question"}
+	if got := historyLines(t, path); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("history file holds %q, want %q", got, want)
+	}
+}
+
+func TestLineReaderHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	rl := openHistory(t, path)
+	for i := 0; i < inputHistoryLimit+20; i++ {
+		rl.SaveHistory(fmt.Sprintf("input %d", i))
+	}
+	rl.Close()
+
+	// The file is trimmed to the newest entries when it is next opened.
+	rl = openHistory(t, path)
+	rl.SaveHistory("latest")
+	rl.Close()
+
+	got := historyLines(t, path)
+	if len(got) > inputHistoryLimit+1 {
+		t.Errorf("history file holds %d entries, want at most %d", len(got), inputHistoryLimit+1)
+	}
+	if got[len(got)-1] != "latest" || got[len(got)-2] != fmt.Sprintf("input %d", inputHistoryLimit+19) {
+		t.Errorf("history file ends with %q, want the newest entries kept", got[len(got)-2:])
+	}
+}
+
+func TestDefaultInputHistoryPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if got, want := defaultInputHistoryPath(), filepath.Join(home, ".gemmalocalllm_history"); got != want {
+		t.Errorf("defaultInputHistoryPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv("HOME", "")
+	if got := defaultInputHistoryPath(); got != "" {
+		t.Errorf("without a home directory got %q, want persistence disabled", got)
+	}
+}
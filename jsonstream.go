@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Detection states for structuredStream.
+const (
+	streamDetecting = iota
+	streamStructured
+	streamPlain
+)
+
+// structuredStream incrementally scans a streamed model response. If the
+// response is a structured JSON object (optionally wrapped in a ```json fence),
+// it surfaces the characters of the top-level "text" field as soon as they
+// arrive and holds back everything else, such as the actions array, for the
+// final json.Unmarshal. Any other response is passed through unchanged.
+type structuredStream struct {
+	mode    int
+	pending string // input held back while detecting the response type
+
+	depth     int
+	inString  bool
+	escape    string // escape sequence being decoded, including the backslash
+	expectKey bool   // next string at depth 1 is an object key
+	isKey     bool   // current string is an object key
+	key       strings.Builder
+	lastKey   string
+	inText    bool // current string is the value of the top-level "text" key
+}
+
+// Write consumes the next streamed chunk and returns the text that should be
+// shown to the user now.
+func (s *structuredStream) Write(chunk string) string {
+	switch s.mode {
+	case streamPlain:
+		return chunk
+	case streamStructured:
+		return s.scan(chunk)
+	}
+
+	s.pending += chunk
+	rest := strings.TrimLeft(s.pending, " \t\r\n")
+	for _, fence := range []string{"```json", "```"} {
+		if strings.HasPrefix(fence, rest) {
+			return "" // could still become a fence, wait for more input
+		}
+		if strings.HasPrefix(rest, fence) {
+			rest = strings.TrimLeft(strings.TrimPrefix(rest, fence), " \t\r\n")
+			break
+		}
+	}
+	if rest == "" {
+		return ""
+	}
+
+	pending := s.pending
+	s.pending = ""
+	if rest[0] != '{' {
+		s.mode = streamPlain
+		return pending
+	}
+	s.mode = streamStructured
+	return s.scan(rest)
+}
+
+// scan advances the JSON scanner over input, returning decoded characters of
+// the top-level "text" string.
+func (s *structuredStream) scan(input string) string {
+	var out strings.Builder
+	for _, r := range input {
+		if !s.inString {
+			switch r {
+			case '{', '[':
+				s.depth++
+				s.expectKey = s.depth == 1 && r == '{'
+			case '}', ']':
+				s.depth--
+			case ',':
+				s.expectKey = s.depth == 1
+			case '"':
+				s.inString = true
+				s.isKey = s.depth == 1 && s.expectKey
+				s.inText = s.depth == 1 && !s.expectKey && s.lastKey == "text"
+				s.key.Reset()
+			}
+			continue
+		}
+
+		if s.escape != "" {
+			s.escape += string(r)
+			if decoded, done := decodeEscape(s.escape); done {
+				s.escape = ""
+				s.emit(&out, decoded)
+			}
+			continue
+		}
+
+		switch r {
+		case '\\':
+			s.escape = `\`
+		case '"':
+			s.inString = false
+			if s.isKey {
+				s.lastKey = s.key.String()
+				s.expectKey = false
+			}
+			s.isKey, s.inText = false, false
+		default:
+			s.emit(&out, string(r))
+		}
+	}
+	return out.String()
+}
+
+// emit routes decoded string content to the key buffer or the visible output.
+func (s *structuredStream) emit(out *strings.Builder, text string) {
+	switch {
+	case s.isKey:
+		s.key.WriteString(text)
+	case s.inText:
+		out.WriteString(text)
+	}
+}
+
+// decodeEscape decodes a JSON escape sequence such as `\n` or `\u00e9`. It
+// reports false while the sequence is still incomplete. Surrogate pairs are
+// decoded independently, so astral characters come out as replacement runes.
+func decodeEscape(seq string) (string, bool) {
+	if len(seq) < 2 {
+		return "", false
+	}
+	switch seq[1] {
+	case 'n':
+		return "\n", true
+	case 't':
+		return "\t", true
+	case 'r':
+		return "\r", true
+	case 'b':
+		return "\b", true
+	case 'f':
+		return "\f", true
+	case 'u':
+		if len(seq) < 6 {
+			return "", false
+		}
+		n, err := strconv.ParseUint(seq[2:6], 16, 16)
+		if err != nil {
+			return "", true
+		}
+		return string(utf16.Decode([]uint16{uint16(n)})), true
+	default:
+		return seq[1:], true // \" \\ \/ and anything unexpected
+	}
+}
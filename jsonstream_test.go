@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+// streamAll feeds chunks to a fresh structuredStream and returns everything
+// it surfaced.
+func streamAll(chunks ...string) string {
+	var s structuredStream
+	var out string
+	for _, chunk := range chunks {
+		out += s.Write(chunk)
+	}
+	return out
+}
+
+func TestStructuredStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"plain text", "Hello there", "Hello there"},
+		{"plain text after whitespace", "\n  Hi", "\n  Hi"},
+		{"structured", `{"text": "Hi there", "actions": [{"name": "x"}]}`, "Hi there"},
+		{"text after other keys", `{"actions": [], "text": "late"}`, "late"},
+		{"fenced", "```json\n{\"text\": \"fenced\"}\n```", "fenced"},
+		{"bare fence", "```\n{\"text\": \"bare\"}\n```", "bare"},
+		{"escapes", `{"text": "a\nb \"q\" \\ é"}`, "a\nb \"q\" \\ é"},
+		{"nested text ignored", `{"actions": [{"text": "no"}], "meta": {"text": "no"}, "text": "yes"}`, "yes"},
+		{"text as a value", `{"kind": "text", "other": "no"}`, ""},
+		{"escaped key", `{"te\u0078t": "decoded key"}`, "decoded key"},
+		{"fence then prose", "```\nnot json", "```\nnot json"},
+	}
+	for _, tt := range tests {
+		if got := streamAll(tt.response); got != tt.want {
+			t.Errorf("%s: whole response gave %q, want %q", tt.name, got, tt.want)
+		}
+
+		// Splitting the response into single characters, as a stream of
+		// tiny tokens would, must not change what is surfaced.
+		var chunks []string
+		for _, r := range tt.response {
+			chunks = append(chunks, string(r))
+		}
+		if got := streamAll(chunks...); got != tt.want {
+			t.Errorf("%s: character stream gave %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStructuredStreamSurfacesTextEarly(t *testing.T) {
+	var s structuredStream
+	if got := s.Write(`{"text": "Hel`); got != "Hel" {
+		t.Errorf("first chunk surfaced %q, want Hel", got)
+	}
+	if got := s.Write(`lo", "actions": [`); got != "lo" {
+		t.Errorf("second chunk surfaced %q, want lo", got)
+	}
+	if got := s.Write(`{"text": "hidden"}]}`); got != "" {
+		t.Errorf("actions surfaced %q, want nothing", got)
+	}
+}
+
+func TestStructuredStreamWaitsForFence(t *testing.T) {
+	var s structuredStream
+	if got := s.Write("``"); got != "" {
+		t.Errorf("partial fence surfaced %q, want it held back", got)
+	}
+	if got := s.Write("`json\n{\"text\": \"ok\"}"); got != "ok" {
+		t.Errorf("got %q, want ok", got)
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,7 +12,9 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/chzyer/readline"
 	"github.com/ollama/ollama/api"
 )
 
@@ -38,9 +40,25 @@ type StructuredResponse struct {
 // 4. Set up your Go module: `go mod init gemma_agent`
 // 5. Get the Ollama API library: `go get github.com/ollama/ollama/api`
 
+// chatModel is the Ollama model the REPL talks to.
+const chatModel = "gemma3:4b"
+
 func main() {
+	wrap := flag.Bool("wrap", true, "word-wrap streamed responses to the terminal width")
+	render := flag.Bool("render", false, "re-render each answer as markdown once it has streamed in (terminals only)")
+	historyPath := flag.String("history", defaultInputHistoryPath(), "file to persist input history in between sessions (empty to disable)")
+	warmup := flag.Bool("warmup", false, "load the model into memory at startup so the first answer comes quicker")
+	flag.Parse()
+
+	// Only wrap when stdout is a terminal of known width; otherwise stream raw.
+	width := 0
+	if *wrap {
+		width = terminalWidth(os.Stdout)
+	}
+
 	fmt.Println("Welcome! I am an agent powered by the gemma:270mb model.")
 	fmt.Println("Type 'exit' or 'quit' to end the conversation.")
+	fmt.Println("Type '/image <path> [question]' to ask about an image.")
 
 	url := &url.URL{
 		Scheme: "http",
@@ -78,15 +96,33 @@ func main() {
 	// Create a context for the chat request.
 	ctx := context.Background()
 
-	// Use bufio.NewScanner to read user input line by line.
-	scanner := bufio.NewScanner(os.Stdin)
+	if *warmup {
+		fmt.Println("Loading the model...")
+		if err := warmupModel(ctx, client, chatModel); err != nil {
+			log.Println("Warmup failed, the first answer may be slow:", err)
+		}
+	}
+
+	// Read user input with line editing and up/down arrow history.
+	rl, err := newLineReader(*historyPath)
+	if err != nil {
+		log.Fatalf("Failed to initialise input: %v", err)
+	}
+	defer rl.Close()
 
 	for {
-		fmt.Print("\nYou: ")
-		if !scanner.Scan() {
+		fmt.Println()
+		rl.SetPrompt(userPrompt)
+		user_input, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			continue // Ctrl-C discards the current line
+		}
+		if err != nil {
 			break // End of input
 		}
-		user_input := scanner.Text()
+		if strings.TrimSpace(user_input) != "" {
+			rl.SaveHistory(user_input)
+		}
 
 		// Check for exit commands
 		if user_input == "exit" || user_input == "quit" {
@@ -94,34 +130,54 @@ func main() {
 			break
 		}
 
-		// Add the user's message to the conversation history
-		messages = append(messages, api.Message{
-			Role:    "user",
-			Content: user_input,
-		})
+		// Add the user's message, with any attached image, to the conversation history
+		userMessage, err := buildUserMessage(user_input)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		messages = append(messages, userMessage)
 
 		// Send the conversation history to the model for a response.
 		// We use a handler function to process the streamed response.
-		fmt.Println("Thinking...")
-		fmt.Print("Agent: ")
+		// On a terminal, animate "Thinking..." until the first token arrives.
+		spin := newSpinner(os.Stdout, "Thinking...", 100*time.Millisecond)
+		if isTerminal(os.Stdout) {
+			spin.Start()
+		} else {
+			fmt.Println("Thinking...")
+		}
+		out := newWrapWriter(os.Stdout, width)
+		started := false
 
 		// Create a new request with the current conversation history.
 		req := &api.ChatRequest{
-			Model:    "gemma3:4b",
+			Model:    chatModel,
 			Messages: messages,
 		}
 
 		// The Chat function is a streaming function, so we need to collect all chunks.
 		// The Chat function is a streaming function. We'll print the content
 		// as it comes in and also collect it for the history.
-		var fullResponse string
+		// Structured responses surface only their "text" field while streaming.
+		var fullResponse, shown string
+		stream := &structuredStream{}
 		handler := func(resp api.ChatResponse) error {
-			fmt.Print(resp.Message.Content)
+			if !started {
+				spin.Stop()
+				fmt.Fprint(out, "Agent: ")
+				started = true
+			}
+			text := stream.Write(resp.Message.Content)
+			fmt.Fprint(out, text)
+			shown += text
 			fullResponse += resp.Message.Content
 			return nil
 		}
 
-		err := client.Chat(ctx, req, handler)
+		err = client.Chat(ctx, req, handler)
+		spin.Stop()
+		out.Flush()
 		if err != nil {
 			log.Println("An error occurred with Ollama:", err)
 			log.Println("Please ensure the Ollama server is running and the 'gemma:270mb' model is available.")
@@ -130,6 +186,19 @@ func main() {
 		}
 		fmt.Println() // Newline after the agent's response is complete.
 
+		// Swap the raw stream for a styled rendering of the same text.
+		if shouldRender(*render, isTerminal(os.Stdout)) && strings.TrimSpace(shown) != "" {
+			if rendered, err := renderMarkdown(shown, terminalWidth(os.Stdout)); err == nil {
+				rows := screenRows("Agent: "+shown, terminalWidth(os.Stdout))
+				if width > 0 {
+					// Word wrapping can take more rows than the terminal's own.
+					rows = out.Rows()
+				}
+				clearRows(os.Stdout, rows+1)
+				fmt.Print("Agent:\n" + rendered)
+			}
+		}
+
 		// Clean up the response, removing markdown code blocks if present.
 		responseStr := fullResponse
 		if strings.HasPrefix(responseStr, "```json") {
@@ -142,14 +211,12 @@ func main() {
 		var structuredResp StructuredResponse
 		err = json.Unmarshal([]byte(responseStr), &structuredResp)
 		if err == nil && len(structuredResp.Actions) > 0 {
-			fmt.Println(structuredResp.Text)
+			// The text was already shown while streaming.
 			for i, action := range structuredResp.Actions {
 				fmt.Printf("%d: %s\n", i+1, action.Label)
 			}
-			fmt.Print("Choose an action to execute (or press Enter to continue): ")
-
-			scanner.Scan()
-			choiceStr := scanner.Text()
+			rl.SetPrompt(actionPrompt)
+			choiceStr, _ := rl.Readline()
 			if choiceStr != "" {
 				choice, err := strconv.Atoi(choiceStr)
 				if err == nil && choice > 0 && choice <= len(structuredResp.Actions) {
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// shouldRender reports whether a streamed answer is re-rendered as markdown:
+// only when asked to and when the output is a terminal, since the rendering
+// relies on cursor movement and ANSI styling.
+func shouldRender(enabled, tty bool) bool {
+	return enabled && tty
+}
+
+// renderMarkdown styles text for a terminal of the given width.
+func renderMarkdown(text string, width int) (string, error) {
+	options := []glamour.TermRendererOption{glamour.WithAutoStyle()}
+	if width > 0 {
+		options = append(options, glamour.WithWordWrap(width))
+	}
+	r, err := glamour.NewTermRenderer(options...)
+	if err != nil {
+		return "", err
+	}
+	return r.Render(text)
+}
+
+// screenRows returns how many terminal rows text occupies when printed at the
+// start of a line on a terminal of the given width, soft wraps included.
+func screenRows(text string, width int) int {
+	rows := 0
+	for _, line := range strings.Split(text, "\n") {
+		n := utf8.RuneCountInString(line)
+		if width <= 0 || n <= width {
+			rows++
+			continue
+		}
+		rows += (n + width - 1) / width
+	}
+	return rows
+}
+
+// clearRows erases the last rows rows of output, leaving the cursor at the
+// start of the first of them.
+func clearRows(w io.Writer, rows int) {
+	if rows > 1 {
+		fmt.Fprintf(w, "\033[%dA", rows-1)
+	}
+	fmt.Fprint(w, "\r\033[J")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShouldRender(t *testing.T) {
+	tests := []struct {
+		enabled, tty, want bool
+	}{
+		{true, true, true},
+		{true, false, false},
+		{false, true, false},
+		{false, false, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRender(tt.enabled, tt.tty); got != tt.want {
+			t.Errorf("shouldRender(%v, %v) = %v, want %v", tt.enabled, tt.tty, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSkippedWhenNotATerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	for _, out := range []*os.File{f, w} {
+		if shouldRender(true, isTerminal(out)) {
+			t.Errorf("%s: would re-render output that is not a terminal", out.Name())
+		}
+		if width := terminalWidth(out); width != 0 {
+			t.Errorf("%s: terminalWidth = %d, want 0", out.Name(), width)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	got, err := renderMarkdown("# Title\n\n- first item\n- second item\n\n```go\nfmt.Println(1)\n```\n", 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Title", "first item", "second item", "fmt.Println(1)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered markdown lacks %q:\n%s", want, got)
+		}
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if n := len([]rune(stripANSI(line))); n > 40 {
+			t.Errorf("rendered line is %d columns wide, want at most 40: %q", n, line)
+		}
+	}
+}
+
+// stripANSI removes CSI escape sequences from s.
+func stripANSI(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\033' && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < '@' || s[i] > '~') {
+				i++
+			}
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func TestScreenRows(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  int
+	}{
+		{"short", 80, 1},
+		{"one\ntwo\nthree", 80, 3},
+		{strings.Repeat("x", 25), 10, 3},
+		{strings.Repeat("é", 20), 10, 2},
+		{strings.Repeat("x", 25), 0, 1},
+		{"", 10, 1},
+	}
+	for _, tt := range tests {
+		if got := screenRows(tt.text, tt.width); got != tt.want {
+			t.Errorf("screenRows(%q, %d) = %d, want %d", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestClearRows(t *testing.T) {
+	var sb strings.Builder
+	clearRows(&sb, 3)
+	if got := sb.String(); got != "\033[2A\r\033[J" {
+		t.Errorf("clearRows(3) = %q", got)
+	}
+	sb.Reset()
+	clearRows(&sb, 1)
+	if got := sb.String(); got != "\r\033[J" {
+		t.Errorf("clearRows(1) = %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// spinnerFrames are drawn in turn while the model is thinking.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// spinner animates a label on the current terminal line until stopped. It
+// redraws on every tick of the channel returned by newTicker, so tests can
+// drive it with a hand-fed channel instead of real time.
+type spinner struct {
+	w         io.Writer
+	label     string
+	newTicker func() (<-chan time.Time, func())
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// newSpinner returns a spinner that redraws label on w every interval.
+func newSpinner(w io.Writer, label string, interval time.Duration) *spinner {
+	return &spinner{
+		w:     w,
+		label: label,
+		newTicker: func() (<-chan time.Time, func()) {
+			t := time.NewTicker(interval)
+			return t.C, t.Stop
+		},
+	}
+}
+
+// Start draws the first frame and begins animating. Calling Start on a running
+// spinner does nothing.
+func (s *spinner) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	tick, stopTicker := s.newTicker()
+	fmt.Fprintf(s.w, "\r%s %s", spinnerFrames[0], s.label)
+	go func() {
+		defer close(s.done)
+		defer stopTicker()
+		for frame := 1; ; frame++ {
+			select {
+			case <-s.stop:
+				return
+			case <-tick:
+				fmt.Fprintf(s.w, "\r%s %s", spinnerFrames[frame%len(spinnerFrames)], s.label)
+			}
+		}
+	}()
+}
+
+// Stop halts the animation and clears the spinner line so that subsequent
+// output starts at column zero. It is safe to call Stop more than once or on a
+// spinner that was never started.
+func (s *spinner) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return
+	}
+	s.running = false
+	close(s.stop)
+	<-s.done
+	fmt.Fprint(s.w, "\r\033[K")
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the spinner goroutine to write to
+// while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// manualSpinner returns a spinner driven by the returned tick channel, and a
+// counter of how many tickers it started and stopped.
+func manualSpinner(w *syncBuffer) (s *spinner, tick chan time.Time, started, stopped *int) {
+	s = newSpinner(w, "Thinking...", time.Hour)
+	tick = make(chan time.Time)
+	started, stopped = new(int), new(int)
+	s.newTicker = func() (<-chan time.Time, func()) {
+		*started++
+		return tick, func() { *stopped++ }
+	}
+	return s, tick, started, stopped
+}
+
+func TestSpinnerAnimatesAndClears(t *testing.T) {
+	var out syncBuffer
+	s, tick, _, stopped := manualSpinner(&out)
+
+	s.Start()
+	if got := out.String(); got != "\r| Thinking..." {
+		t.Fatalf("after Start got %q, want the first frame", got)
+	}
+	for i := 0; i < 4; i++ {
+		tick <- time.Time{}
+	}
+	s.Stop()
+
+	want := "\r| Thinking...\r/ Thinking...\r- Thinking...\r\\ Thinking...\r| Thinking...\r\033[K"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if *stopped != 1 {
+		t.Errorf("ticker stopped %d times, want 1", *stopped)
+	}
+}
+
+func TestSpinnerStartStopAreIdempotent(t *testing.T) {
+	var out syncBuffer
+	s, _, started, stopped := manualSpinner(&out)
+
+	s.Stop() // never started
+	if got := out.String(); got != "" {
+		t.Errorf("Stop before Start wrote %q", got)
+	}
+
+	s.Start()
+	s.Start()
+	s.Stop()
+	s.Stop()
+	if *started != 1 || *stopped != 1 {
+		t.Errorf("started %d and stopped %d tickers, want 1 each", *started, *stopped)
+	}
+	if got, want := out.String(), "\r| Thinking...\r\033[K"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A stopped spinner can be started again.
+	s.Start()
+	s.Stop()
+	if *started != 2 || *stopped != 2 {
+		t.Errorf("restart: started %d and stopped %d tickers, want 2 each", *started, *stopped)
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+
+	"github.com/ollama/ollama/api"
+)
+
+// warmupModel makes Ollama load model into memory, so the first question does
+// not also pay for loading it. A generate request without a prompt only loads
+// the model.
+func warmupModel(ctx context.Context, client *api.Client, model string) error {
+	req := &api.GenerateRequest{Model: model}
+	return client.Generate(ctx, req, func(api.GenerateResponse) error { return nil })
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+func TestWarmupModel(t *testing.T) {
+	var got []api.GenerateRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("request to %s, want /api/generate", r.URL.Path)
+		}
+		var req api.GenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		got = append(got, req)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"model":"gemma3","response":"","done":true,"done_reason":"load"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	base, _ := url.Parse(srv.URL)
+	if err := warmupModel(context.Background(), api.NewClient(base, srv.Client()), "gemma3"); err != nil {
+		t.Fatalf("warmupModel: %v", err)
+	}
+	if len(got) != 1 || got[0].Model != "gemma3" || got[0].Prompt != "" {
+		t.Errorf("requests = %+v, want one load-only request for gemma3", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// wrapWriter word-wraps streamed text at a fixed column width. Partial words are
+// buffered until whitespace arrives so lines never break in the middle of a
+// word, which matters because the model streams tokens that split words
+// arbitrarily. A width of zero or less disables wrapping and passes writes
+// straight through.
+type wrapWriter struct {
+	w      io.Writer
+	width  int
+	col    int    // runes written on the current line
+	word   []rune // pending word not yet written
+	spaces int    // spaces owed before the next word on this line
+	breaks int    // line breaks written
+}
+
+// newWrapWriter returns a wrapWriter that wraps at width columns.
+func newWrapWriter(w io.Writer, width int) *wrapWriter {
+	return &wrapWriter{w: w, width: width}
+}
+
+// Write buffers p, emitting complete words and line breaks as they become known.
+func (ww *wrapWriter) Write(p []byte) (int, error) {
+	if ww.width <= 0 {
+		return ww.w.Write(p)
+	}
+
+	n := len(p)
+	var out []rune
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+
+		switch {
+		case r == '\n':
+			out = ww.flushWord(out)
+			out = append(out, '\n')
+			ww.col, ww.spaces = 0, 0
+			ww.breaks++
+		case unicode.IsSpace(r):
+			out = ww.flushWord(out)
+			if ww.col > 0 {
+				ww.spaces++
+			}
+		default:
+			ww.word = append(ww.word, r)
+			// A word longer than the whole line can never fit, so hard-break it.
+			if len(ww.word) >= ww.width {
+				out = ww.flushWord(out)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(ww.w, string(out)); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Flush writes any buffered partial word. Call it once the stream has ended.
+func (ww *wrapWriter) Flush() error {
+	if ww.width <= 0 || len(ww.word) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(ww.w, string(ww.flushWord(nil)))
+	return err
+}
+
+// Rows returns how many terminal rows the wrapped output written so far
+// occupies, counting from the row it started on. Without wrapping the
+// terminal breaks lines itself, so use screenRows instead.
+func (ww *wrapWriter) Rows() int {
+	return ww.breaks + 1
+}
+
+// flushWord appends the pending word to out, preceded by the spaces owed
+// before it or, if they and the word would overflow the current line, by a
+// line break instead, so lines never end in trailing spaces.
+func (ww *wrapWriter) flushWord(out []rune) []rune {
+	if len(ww.word) == 0 {
+		return out
+	}
+	if ww.col > 0 && ww.col+ww.spaces+len(ww.word) > ww.width {
+		out = append(out, '\n')
+		ww.col = 0
+		ww.breaks++
+	} else {
+		for i := 0; i < ww.spaces; i++ {
+			out = append(out, ' ')
+		}
+		ww.col += ww.spaces
+	}
+	ww.spaces = 0
+	out = append(out, ww.word...)
+	ww.col += len(ww.word)
+	ww.word = ww.word[:0]
+	return out
+}
+
+// terminalWidth reports the column width of f, or 0 when f is not a terminal or
+// its size cannot be determined.
+func terminalWidth(f *os.File) int {
+	fd := int(f.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil || width <= 0 {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// wrapAll writes chunks to a wrapWriter of the given width and returns the
+// flushed output.
+func wrapAll(t *testing.T, width int, chunks ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	ww := newWrapWriter(&buf, width)
+	for _, chunk := range chunks {
+		n, err := ww.Write([]byte(chunk))
+		if err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if err := ww.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestWrapWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		text  string
+		want  string
+	}{
+		{"fits", 20, "hello world", "hello world"},
+		{"breaks between words", 11, "hello world foo", "hello world\nfoo"},
+		{"word moves to next line", 8, "hello world", "hello\nworld"},
+		{"newline resets column", 5, "ab\ncd ef", "ab\ncd ef"},
+		{"long word hard-breaks", 4, "abcdefgh", "abcd\nefgh"},
+		{"counts runes not bytes", 5, "héllo wörld", "héllo\nwörld"},
+		{"drops leading space", 10, " a", "a"},
+		{"keeps inner spaces", 10, "a  b", "a  b"},
+		{"drops trailing space", 10, "a ", "a"},
+		{"disabled", 0, "a very long line that is not wrapped", "a very long line that is not wrapped"},
+	}
+	for _, tt := range tests {
+		if got := wrapAll(t, tt.width, tt.text); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrapWriterSplitTokens(t *testing.T) {
+	// Tokens split words arbitrarily; the output must not depend on where.
+	want := wrapAll(t, 12, "the quick brown fox jumps over the lazy dog")
+	got := wrapAll(t, 12, "the qu", "ick bro", "wn f", "ox ", "jumps over", " the la", "zy", " dog")
+	if got != want {
+		t.Errorf("split tokens gave %q, want %q", got, want)
+	}
+	if want != "the quick\nbrown fox\njumps over\nthe lazy dog" {
+		t.Errorf("got %q", want)
+	}
+}
+
+func TestWrapWriterHoldsPartialWord(t *testing.T) {
+	var buf bytes.Buffer
+	ww := newWrapWriter(&buf, 20)
+	ww.Write([]byte("hello wor"))
+	if got := buf.String(); got != "hello" {
+		t.Errorf("before the word ends got %q, want the partial word held back", got)
+	}
+	ww.Flush()
+	if got := buf.String(); got != "hello wor" {
+		t.Errorf("after Flush got %q", got)
+	}
+}
+
+func TestWrapWriterRows(t *testing.T) {
+	var buf bytes.Buffer
+	ww := newWrapWriter(&buf, 10)
+	ww.Write([]byte("Agent: abcde fghij klmno"))
+	ww.Flush()
+	// Word wrapping takes four rows where hard wrapping at 10 columns
+	// would take three.
+	if got := ww.Rows(); got != 4 {
+		t.Errorf("Rows() = %d for %q, want 4", got, buf.String())
+	}
+	if got := screenRows("Agent: abcde fghij klmno", 10); got != 3 {
+		t.Errorf("screenRows = %d, want 3", got)
+	}
+}